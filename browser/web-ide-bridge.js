@@ -45,6 +45,17 @@
     }
   }
 
+  /**
+   * Get the URL of the current page without fragment, to tell apart
+   * edit sessions of pages that use the same textarea IDs
+   */
+  function getPageUrl() {
+    if (typeof window === 'undefined' || !window.location) {
+      return '';
+    }
+    return String(window.location.href).split('#')[0];
+  }
+
   /**
    * Debounce function calls
    */
@@ -82,6 +93,7 @@
       const defaultOptions = {
         selector: 'textarea',
         buttonText: 'Edit in IDE ↗',
+        editingText: 'Editing in IDE…',
         buttonClass: 'web-ide-bridge-btn',
         position: 'after',
         fileTypeAttribute: 'data-language',
@@ -114,6 +126,7 @@
 
       const defaultOptions = {
         buttonText: 'Edit in IDE ↗',
+        editingText: 'Editing in IDE…',
         buttonClass: 'web-ide-bridge-btn',
         position: 'after',
         fileType: 'txt',
@@ -152,10 +165,27 @@
       this.injectedButtons.forEach(button => {
         button.disabled = !connected;
         // Always show the original text, do not change to 'Connect to Server First'
-        button.textContent = button.dataset.originalText;
+        button.textContent = button.dataset.editing ? button.dataset.editingText : button.dataset.originalText;
       });
     }
 
+    updateEditState(snippetId, event) {
+      const editing = event === 'edit_started';
+      if (!editing && !['launch_failed', 'editor_closed', 'watch_stopped'].includes(event)) {
+        return;
+      }
+      const button = this.injectedButtons.get(snippetId);
+      if (button) {
+        button.dataset.editing = editing ? 'true' : '';
+        button.textContent = editing ? button.dataset.editingText : button.dataset.originalText;
+        button.classList.toggle('web-ide-bridge-editing', editing);
+      }
+      const textarea = document.getElementById(snippetId);
+      if (this.webIdeBridge.options.lockWhileEditing && textarea && textarea.tagName === 'TEXTAREA') {
+        textarea.readOnly = editing;
+      }
+    }
+
     _initializeStyles(style) {
       if (this.styles || this.initialized) return;
 
@@ -326,6 +356,7 @@
       button.dataset.textareaId = textarea.id;
       button.dataset.fileType = config.fileType;
       button.dataset.originalText = config.buttonText;
+      button.dataset.editingText = config.editingText;
       button.disabled = !this.webIdeBridge.isConnected();
 
       button.addEventListener('click', async () => {
@@ -336,7 +367,9 @@
         try {
           const code = textarea.value;
           const fileType = button.dataset.fileType;
-          await this.webIdeBridge.editCodeSnippet(textarea.id, code, fileType);
+          const position = this._selectionPosition(textarea);
+          await this.webIdeBridge.editCodeSnippet(textarea.id, code, fileType, position ? { position } : {});
+          this._watchForLiveUpdates(textarea, button);
         } catch (error) {
           console.error('Failed to send code to IDE:', error);
           alert(`Failed to send code to IDE: ${error.message}. Please check your connection and try again.`);
@@ -368,6 +401,51 @@
       return button;
     }
 
+    /**
+     * Cursor position or selection of a textarea as 1-based line and column, so that the
+     * IDE opens where the user was looking; null when the cursor is at the very start
+     */
+    _selectionPosition(textarea) {
+      const start = textarea.selectionStart || 0;
+      const end = textarea.selectionEnd || start;
+      if (start === 0 && end === 0) {
+        return null;
+      }
+      const toLineColumn = offset => {
+        const lines = textarea.value.substring(0, offset).split('\n');
+        return { line: lines.length, column: lines[lines.length - 1].length + 1 };
+      };
+      const from = toLineColumn(start);
+      if (end === start) {
+        return from;
+      }
+      const to = toLineColumn(end);
+      return { line: from.line, column: from.column, endLine: to.line, endColumn: to.column };
+    }
+
+    /**
+     * Send textarea changes to the snippet open in the IDE, once the button was used.
+     * On by default; set the liveUpdates option to false to turn it off.
+     */
+    _watchForLiveUpdates(textarea, button) {
+      const options = this.webIdeBridge.options;
+      if (!options.liveUpdates || textarea.dataset.webIdeBridgeLive) {
+        return;
+      }
+      textarea.dataset.webIdeBridgeLive = 'true';
+      // Code updates from the IDE also fire input events; unchanged code is not sent back
+      textarea.addEventListener('input', debounce(() => {
+        if (!this.webIdeBridge.isConnected()) {
+          return;
+        }
+        try {
+          this.webIdeBridge.updateCodeSnippet(textarea.id, textarea.value, button.dataset.fileType);
+        } catch (error) {
+          console.error('Failed to send code update to IDE:', error);
+        }
+      }, options.liveUpdateDelay));
+    }
+
     _watchForDOMChanges(config) {
       const observer = new MutationObserver((mutations) => {
         let shouldRefresh = false;
@@ -423,6 +501,12 @@
         connectionTimeout: 10000,
         debug: false,
         addButtons: true, // new option
+        liveUpdates: true, // send textarea changes to open snippets
+        liveUpdateDelay: 500, // ms to wait after the last change
+        lockWhileEditing: false, // make textareas read-only while open in the IDE
+        pageUrl: getPageUrl(), // identifies edit sessions of this page
+        contextFiles: null, // read-only companion files for the IDE: array, or function(snippetId, fileType) returning one
+        snippetContext: null, // read-only code around fragment snippets: { prefix, suffix }, or function(snippetId, fileType) returning one
         ...options
       };
 
@@ -443,6 +527,8 @@
       this.codeUpdateCallbacks = [];
       this.errorCallbacks = [];
       this.messageCallbacks = [];
+      this.editEventCallbacks = [];
+      this.snippetCode = new Map(); // snippetId -> code last synced with the IDE
 
       this.uiManager = new UIManager(this);
       if (this.options.addButtons) {
@@ -499,7 +585,19 @@
       return 'disconnected';
     }
 
-    async editCodeSnippet(snippetId, code, fileType = 'txt') {
+    /**
+     * Open a code snippet in the desktop IDE. Optional options.contextFiles is an array of
+     * { path, content } read-only companion files, such as .d.ts declarations, written next
+     * to the snippet; it defaults to the contextFiles option. Optional options.position is a
+     * { line, column, endLine, endColumn } cursor position or selection, 1-based, where the
+     * IDE opens the snippet. Optional options.transform names a content transform of the
+     * desktop, such as 'json-yaml', or 'none' to edit the code as is. Optional
+     * options.snippetContext is { prefix, suffix } read-only code written around a fragment
+     * snippet, such as a function body, between guard comments; it defaults to the
+     * snippetContext option. Optional options.encoding is 'base64' or 'dataurl' for binary
+     * content, with options.mimeType; see editBinarySnippet().
+     */
+    async editCodeSnippet(snippetId, code, fileType = 'txt', options = {}) {
       if (!this.connected) {
         throw new Error('Not connected to server');
       }
@@ -512,22 +610,214 @@
         throw new Error('code must be a string');
       }
 
+      const contextFiles = this._resolveContextFiles(options, [{ snippetId, fileType: fileType || 'txt' }]);
+
       const message = {
         type: 'edit_request',
         connectionId: this.connectionId,
         userId: this.userId,
         snippetId,
+        pageUrl: this.options.pageUrl,
         code,
         fileType: fileType || 'txt',
         timestamp: Date.now()
       };
+      if (contextFiles.length > 0) {
+        message.contextFiles = contextFiles;
+      }
+      if (options.position) {
+        const { line, column, endLine, endColumn } = options.position;
+        const fields = { line, column, endLine, endColumn };
+        Object.keys(fields).forEach(field => {
+          if (fields[field] === undefined) {
+            return;
+          }
+          if (!Number.isInteger(fields[field]) || fields[field] < 1) {
+            throw new Error(`position.${field} must be a positive integer`);
+          }
+          message[field] = fields[field];
+        });
+        if (message.line === undefined) {
+          throw new Error('position.line is required');
+        }
+      }
+      if (options.transform !== undefined) {
+        message.transform = this._checkTransform(options.transform);
+      }
+      if (options.encoding !== undefined) {
+        if (options.encoding !== 'base64' && options.encoding !== 'dataurl') {
+          throw new Error("encoding must be 'base64' or 'dataurl'");
+        }
+        if (options.mimeType !== undefined && (typeof options.mimeType !== 'string' || options.mimeType.length > 255)) {
+          throw new Error('mimeType must be a string of 255 characters or less');
+        }
+        message.encoding = options.encoding;
+        message.mimeType = options.mimeType;
+      }
+      const snippetContext = options.encoding ? null : this._resolveSnippetContext(options, snippetId, fileType || 'txt');
+      if (snippetContext) {
+        message.contextPrefix = snippetContext.prefix;
+        message.contextSuffix = snippetContext.suffix;
+      }
 
-      this._log('Sending code snippet to IDE for editing', { snippetId, fileType });
+      this._log('Sending code snippet to IDE for editing', { snippetId, fileType, contextFiles: contextFiles.length, position: options.position || null, snippetContext: !!snippetContext });
       this._sendMessage(message);
+      this.snippetCode.set(snippetId, code);
 
       return snippetId;
     }
 
+    /**
+     * Open binary content, such as an image, in a local tool such as GIMP or Inkscape. data
+     * is base64 or a data: URL; the desktop app writes the raw bytes to a file named by
+     * options.fileType or the MIME type. Saves are sent back to onCodeUpdate() in the same
+     * form, for example as a data URL with the same header.
+     */
+    async editBinarySnippet(snippetId, data, mimeType, options = {}) {
+      if (typeof data !== 'string') {
+        throw new Error('data must be a base64 string or a data URL');
+      }
+      const encoding = data.startsWith('data:') ? 'dataurl' : 'base64';
+      return this.editCodeSnippet(snippetId, data, options.fileType || 'binary', { ...options, encoding, mimeType });
+    }
+
+    /**
+     * Open several code snippets of the page as one project, with a single IDE launch.
+     * snippets is an array of { snippetId, code, fileType }; saves of each file are sent
+     * back to its own snippet, and the snippets are closed together. A transform of a
+     * snippet overrides options.transform. Returns the snippet IDs.
+     */
+    async editCodeSnippets(snippets, options = {}) {
+      if (!this.connected) {
+        throw new Error('Not connected to server');
+      }
+
+      if (!Array.isArray(snippets) || snippets.length === 0) {
+        throw new Error('snippets must be a non-empty array');
+      }
+
+      const batch = snippets.map(snippet => {
+        if (!snippet || !snippet.snippetId || typeof snippet.snippetId !== 'string') {
+          throw new Error('snippetId is required and must be a string');
+        }
+        if (typeof snippet.code !== 'string') {
+          throw new Error('code must be a string');
+        }
+        const entry = { snippetId: snippet.snippetId, code: snippet.code, fileType: snippet.fileType || 'txt' };
+        if (snippet.transform !== undefined) {
+          entry.transform = this._checkTransform(snippet.transform);
+        }
+        return entry;
+      });
+      const contextFiles = this._resolveContextFiles(options, batch);
+
+      const message = {
+        type: 'edit_batch_request',
+        connectionId: this.connectionId,
+        userId: this.userId,
+        pageUrl: this.options.pageUrl,
+        snippets: batch,
+        timestamp: Date.now()
+      };
+      if (contextFiles.length > 0) {
+        message.contextFiles = contextFiles;
+      }
+      if (options.transform !== undefined) {
+        message.transform = this._checkTransform(options.transform);
+      }
+
+      this._log('Sending code snippets to IDE for editing as one project', { snippetIds: batch.map(snippet => snippet.snippetId), contextFiles: contextFiles.length });
+      this._sendMessage(message);
+      batch.forEach(snippet => this.snippetCode.set(snippet.snippetId, snippet.code));
+
+      return batch.map(snippet => snippet.snippetId);
+    }
+
+    /**
+     * Check a transform name of an edit request, returns it
+     */
+    _checkTransform(transform) {
+      if (typeof transform !== 'string' || transform.length > 64) {
+        throw new Error('transform must be a string of 64 characters or less');
+      }
+      return transform;
+    }
+
+    /**
+     * Read-only code around a snippet: options.snippetContext, else the snippetContext
+     * option, called for the snippet if it is a function. Returns { prefix, suffix } or null.
+     */
+    _resolveSnippetContext(options, snippetId, fileType) {
+      let source = options.snippetContext !== undefined ? options.snippetContext : this.options.snippetContext;
+      if (typeof source === 'function') {
+        source = source(snippetId, fileType);
+      }
+      if (!source) {
+        return null;
+      }
+      const prefix = source.prefix === undefined ? '' : source.prefix;
+      const suffix = source.suffix === undefined ? '' : source.suffix;
+      if (typeof prefix !== 'string' || typeof suffix !== 'string') {
+        throw new Error('snippetContext must be { prefix, suffix } strings');
+      }
+      return prefix || suffix ? { prefix, suffix } : null;
+    }
+
+    /**
+     * Companion files for snippets: options.contextFiles, else the contextFiles option.
+     * A function is called per snippet; files with the same path are sent once.
+     */
+    _resolveContextFiles(options, snippets) {
+      const source = options.contextFiles !== undefined ? options.contextFiles : this.options.contextFiles;
+      if (!source) {
+        return [];
+      }
+      const lists = typeof source === 'function'
+        ? snippets.map(snippet => source(snippet.snippetId, snippet.fileType) || [])
+        : [source];
+      const files = new Map();
+      lists.forEach(list => {
+        if (!Array.isArray(list) || list.some(file => !file || typeof file.path !== 'string' || typeof file.content !== 'string')) {
+          throw new Error('contextFiles must be an array of { path, content } strings');
+        }
+        list.forEach(file => files.set(file.path, { path: file.path, content: file.content }));
+      });
+      return Array.from(files.values());
+    }
+
+    /**
+     * Send code changed in the web page to a snippet that is open in the IDE.
+     * Returns false if the snippet is not open or the code is unchanged.
+     */
+    updateCodeSnippet(snippetId, code, fileType = 'txt') {
+      if (!this.connected) {
+        throw new Error('Not connected to server');
+      }
+
+      if (typeof code !== 'string') {
+        throw new Error('code must be a string');
+      }
+
+      if (!this.snippetCode.has(snippetId) || this.snippetCode.get(snippetId) === code) {
+        return false;
+      }
+
+      this._log('Sending code update to IDE', { snippetId, codeLength: code.length });
+      this._sendMessage({
+        type: 'browser_update',
+        connectionId: this.connectionId,
+        userId: this.userId,
+        snippetId,
+        pageUrl: this.options.pageUrl,
+        code,
+        fileType: fileType || 'txt',
+        timestamp: Date.now()
+      });
+      this.snippetCode.set(snippetId, code);
+
+      return true;
+    }
+
     onStatusChange(callback) {
       if (typeof callback !== 'function') {
         throw new Error('Callback must be a function');
@@ -539,6 +829,12 @@
       });
     }
 
+    /**
+     * Register a callback for code saved in the IDE: callback(snippetId, code, diagnostics).
+     * Diagnostics of the desktop validators are { line, column, severity, message, source }
+     * objects; severity is error, warning or info. A string result is shown in the desktop log.
+     * Binary snippets are passed in the base64 or data URL form they were opened with.
+     */
     onCodeUpdate(callback) {
       if (typeof callback !== 'function') {
         throw new Error('Callback must be a function');
@@ -553,6 +849,20 @@
       this.errorCallbacks.push(callback);
     }
 
+    /**
+     * Register a callback for edit lifecycle events: callback(snippetId, event, reason, diagnostics).
+     * Events: edit_started, launch_failed, editor_closed, watch_stopped, file_kept,
+     * merge_conflict, update_rejected, send_failed, format_failed, validation_failed,
+     * transform_failed, context_repaired and context_rejected; diagnostics are set for
+     * validation_failed.
+     */
+    onEditEvent(callback) {
+      if (typeof callback !== 'function') {
+        throw new Error('Callback must be a function');
+      }
+      this.editEventCallbacks.push(callback);
+    }
+
     onMessage(callback) {
       if (typeof callback !== 'function') {
         throw new Error('Callback must be a function');
@@ -675,6 +985,10 @@
             this._handleCodeUpdate(message);
             break;
 
+          case 'edit_event':
+            this._handleEditEvent(message);
+            break;
+
           case 'pong':
             this._log('Received heartbeat response from Web-IDE-Bridge server');
             break;
@@ -715,14 +1029,16 @@
       }
 
       const { snippetId, code } = message;
-      this._log('Received code update from IDE', { snippetId, codeLength: code.length });
+      const diagnostics = Array.isArray(message.diagnostics) ? message.diagnostics : [];
+      this._log('Received code update from IDE', { snippetId, codeLength: code.length, diagnostics: diagnostics.length });
+      this.snippetCode.set(snippetId, code);
       this._log('Number of code update callbacks:', this.codeUpdateCallbacks.length);
 
       let callbackExecuted = false;
       this.codeUpdateCallbacks.forEach(callback => {
         try {
           callbackExecuted = true;
-          const result = callback(snippetId, code);
+          const result = callback(snippetId, code, diagnostics);
           this._log('Callback result:', { result, type: typeof result, hasContent: result?.trim() });
           if (typeof result === 'string' && result.trim()) {
             // Send info message to server
@@ -757,6 +1073,30 @@
       }
     }
 
+    _handleEditEvent(message) {
+      const { snippetId, event, reason } = message;
+      const diagnostics = Array.isArray(message.diagnostics) ? message.diagnostics : [];
+      if (!snippetId || !event) {
+        this._log('Invalid edit event message', message);
+        return;
+      }
+      this._log('Received edit event from IDE', { snippetId, event, reason });
+
+      // The snippet is no longer open in the IDE
+      if (['launch_failed', 'editor_closed', 'watch_stopped'].includes(event)) {
+        this.snippetCode.delete(snippetId);
+      }
+      this.uiManager.updateEditState(snippetId, event);
+
+      this.editEventCallbacks.forEach(callback => {
+        try {
+          callback(snippetId, event, reason || '', diagnostics);
+        } catch (error) {
+          this._log('Error in edit event callback', error);
+        }
+      });
+    }
+
     _handleServerError(message) {
       const errorMsg = message.message || 'Unknown server error';
       this._log('Web-IDE-Bridge server error', errorMsg);
@@ -11,4 +11,4 @@
  * @license         GPL v3, see LICENSE file
  * @genai           99%, Cursor 1.2, Claude Sonnet 4
  */
-!function(e,t){"object"==typeof exports&&"undefined"!=typeof module?module.exports=t():"function"==typeof define&&define.amd?define(t):(e="undefined"!=typeof globalThis?globalThis:e||self).WebIdeBridge=t()}(this,function(){"use strict";function e(){return"xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx".replace(/[xy]/g,function(e){const t=16*Math.random()|0;return("x"===e?t:3&t|8).toString(16)})}class t{constructor(e){this.webIdeBridge=e,this.injectedButtons=new Map,this.observers=[],this.styles=null,this.initialized=!1}autoInjectButtons(e={}){const t={selector:"textarea",buttonText:"Edit in IDE ↗",buttonClass:"web-ide-bridge-btn",position:"after",fileTypeAttribute:"data-language",defaultFileType:"txt",excludeSelector:".web-ide-bridge-exclude",includeOnlySelector:null,watchForChanges:!0,style:"modern",...e};return this._initializeStyles(t.style),this._injectButtonsForSelector(t),t.watchForChanges&&this._watchForDOMChanges(t),{refresh:()=>this._injectButtonsForSelector(t),destroy:()=>this.removeAllButtons()}}injectButton(t,n={}){if(!t||"TEXTAREA"!==t.tagName)throw new Error("Element must be a textarea");const o={buttonText:"Edit in IDE ↗",buttonClass:"web-ide-bridge-btn",position:"after",fileType:"txt",style:"modern",...n};return this._initializeStyles(o.style),t.id||(t.id="web-ide-bridge-textarea-"+e()),this._createAndInjectButton(t,o)}removeAllButtons(){this.injectedButtons.forEach(e=>{e.parentNode&&e.parentNode.removeChild(e)}),this.injectedButtons.clear(),this.observers.forEach(e=>e.disconnect()),this.observers=[],this.styles&&this.styles.parentNode&&(this.styles.parentNode.removeChild(this.styles),this.styles=null)}updateButtonStates(e){this.injectedButtons.forEach(t=>{t.disabled=!e,t.textContent=t.dataset.originalText})}_initializeStyles(e){if(this.styles||this.initialized)return;const t=document.createElement("style");t.id="web-ide-bridge-styles";let n="";switch(e){case"modern":default:n=this._getModernButtonStyles();break;case"minimal":n=this._getMinimalButtonStyles()}t.textContent=n,document.head.appendChild(t),this.styles=t,this.initialized=!0}_getModernButtonStyles(){return"\n        .web-ide-bridge-btn {\n          background: linear-gradient(135deg, #4f46e5 0%, #7c3aed 100%);\n          color: white;\n          border: none;\n          padding: 0.75rem 1.5rem;\n          border-radius: 8px;\n          font-weight: 600;\n          font-size: 0.875rem;\n          cursor: pointer;\n          transition: all 0.3s ease;\n          display: inline-flex;\n          align-items: center;\n          gap: 0.5rem;\n          margin: 0.5rem 0;\n          font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;\n          text-decoration: none;\n          outline: none;\n        }\n\n        .web-ide-bridge-btn:hover:not(:disabled) {\n          transform: translateY(-1px);\n          box-shadow: 0 4px 12px rgba(79, 70, 229, 0.3);\n        }\n\n        .web-ide-bridge-btn:active:not(:disabled) {\n          transform: translateY(0);\n        }\n\n        .web-ide-bridge-btn:disabled {\n          background: #9ca3af;\n          cursor: not-allowed;\n          transform: none;\n          box-shadow: none;\n        }\n\n        .web-ide-bridge-btn:focus {\n          box-shadow: 0 0 0 3px rgba(79, 70, 229, 0.3);\n        }\n\n        .web-ide-bridge-container {\n          display: flex;\n          gap: 0.75rem;\n          align-items: center;\n          margin-top: 0.5rem;\n          flex-wrap: wrap;\n        }\n\n        .web-ide-bridge-file-type {\n          padding: 0.5rem;\n          border: 1px solid #d1d5db;\n          border-radius: 6px;\n          font-size: 0.875rem;\n          background: white;\n          color: #374151;\n        }\n      "}_getMinimalButtonStyles(){return"\n        .web-ide-bridge-btn {\n          background: #4f46e5;\n          color: white;\n          border: 1px solid #4f46e5;\n          padding: 0.5rem 1rem;\n          border-radius: 4px;\n          font-size: 0.875rem;\n          cursor: pointer;\n          transition: background-color 0.2s ease;\n          font-family: inherit;\n          outline: none;\n        }\n\n        .web-ide-bridge-btn:hover:not(:disabled) {\n          background: #4338ca;\n        }\n\n        .web-ide-bridge-btn:disabled {\n          background: #9ca3af;\n          border-color: #9ca3af;\n          cursor: not-allowed;\n        }\n\n        .web-ide-bridge-btn:focus {\n          box-shadow: 0 0 0 2px rgba(79, 70, 229, 0.5);\n        }\n\n        .web-ide-bridge-container {\n          margin-top: 0.5rem;\n        }\n\n        .web-ide-bridge-file-type {\n          margin-left: 0.5rem;\n          padding: 0.25rem 0.5rem;\n          border: 1px solid #ccc;\n          border-radius: 3px;\n          font-size: 0.8rem;\n        }\n      "}_injectButtonsForSelector(t){let n=document.querySelectorAll(t.selector);n=Array.from(n).filter(e=>(!t.excludeSelector||!e.matches(t.excludeSelector))&&!(t.includeOnlySelector&&!e.matches(t.includeOnlySelector))),n.forEach(n=>{if(n.id||(n.id="web-ide-bridge-textarea-"+e()),this.injectedButtons.has(n.id))return;const o=n.getAttribute(t.fileTypeAttribute)||t.defaultFileType;this._createAndInjectButton(n,{...t,fileType:o})})}_createAndInjectButton(e,t){const n=document.createElement("div");n.className="web-ide-bridge-container";const o=document.createElement("button");switch(o.className=t.buttonClass,o.textContent=t.buttonText,o.dataset.textareaId=e.id,o.dataset.fileType=t.fileType,o.dataset.originalText=t.buttonText,o.disabled=!this.webIdeBridge.isConnected(),o.addEventListener("click",async()=>{if(this.webIdeBridge.isConnected())try{const t=e.value,n=o.dataset.fileType;await this.webIdeBridge.editCodeSnippet(e.id,t,n)}catch(e){console.error("Failed to send code to IDE:",e),alert(`Failed to send code to IDE: ${e.message}. Please check your connection and try again.`)}else alert("Please connect to Web-IDE-Bridge server first to edit code in your IDE")}),n.appendChild(o),t.position){case"before":e.parentNode.insertBefore(n,e);break;case"after":default:e.parentNode.insertBefore(n,e.nextSibling);break;case"append":e.parentNode.appendChild(n)}return this.injectedButtons.set(e.id,o),this.webIdeBridge.onStatusChange(e=>{this.updateButtonStates(e.serverConnected)}),o}_watchForDOMChanges(e){const t=new MutationObserver(t=>{let n=!1;t.forEach(t=>{"childList"===t.type&&t.addedNodes.forEach(t=>{t.nodeType===Node.ELEMENT_NODE&&(t.matches&&t.matches(e.selector)||t.querySelector&&t.querySelector(e.selector))&&(n=!0)})}),n&&setTimeout(()=>{this._injectButtonsForSelector(e)},100)});t.observe(document.body,{childList:!0,subtree:!0}),this.observers.push(t)}}return class{constructor(n,o={}){if(!n||"string"!=typeof n)throw new Error("userId is required and must be a string");if(this.userId=n,this.connectionId=o.connectionId||e(),this.options={serverUrl:"ws://localhost:8071/web-ide-bridge/ws",autoReconnect:!0,reconnectInterval:5e3,maxReconnectAttempts:10,heartbeatInterval:3e4,connectionTimeout:1e4,debug:!1,addButtons:!0,...o},!function(e){if(!e||"string"!=typeof e)return!1;try{const t=new URL(e);return"ws:"===t.protocol||"wss:"===t.protocol}catch{return!1}}(this.options.serverUrl))throw new Error("Invalid server URL format");this.ws=null,this.connected=!1,this.connecting=!1,this.reconnectAttempts=0,this.reconnectTimeout=null,this.heartbeatTimeout=null,this.connectionTimeout=null,this.desktopConnected=!1,this.statusCallbacks=[],this.codeUpdateCallbacks=[],this.errorCallbacks=[],this.messageCallbacks=[],this.uiManager=new t(this),this.options.addButtons&&this.uiManager.autoInjectButtons(),this.debouncedReconnect=function(e,t,n=!1){let o;return function(...s){const i=n&&!o;clearTimeout(o),o=setTimeout(()=>{o=null,n||e.apply(this,s)},t),i&&e.apply(this,s)}}(this._attemptReconnect.bind(this),1e3),this._log("Web-IDE-Bridge initialized for user",{userId:n,connectionId:this.connectionId})}async connect(){if(this.connected||this.connecting)this._log("Already connected to server or connection in progress");else{this.connecting=!0,this._updateStatus();try{await this._establishConnection(),this.reconnectAttempts=0,this._log("Successfully connected to Web-IDE-Bridge server")}catch(e){throw this.connecting=!1,this._handleConnectionError(e),e}}}disconnect(){this._log("Disconnecting from Web-IDE-Bridge server"),this._clearTimeouts(),this.options.autoReconnect=!1,this.ws&&(this.ws.close(1e3,"Client disconnect"),this.ws=null),this.connected=!1,this.connecting=!1,this._updateStatus()}isConnected(){return this.connected}getConnectionState(){return this.connected?"connected":this.connecting?"connecting":"disconnected"}async editCodeSnippet(e,t,n="txt"){if(!this.connected)throw new Error("Not connected to server");if(!e||"string"!=typeof e)throw new Error("snippetId is required and must be a string");if("string"!=typeof t)throw new Error("code must be a string");const o={type:"edit_request",connectionId:this.connectionId,userId:this.userId,snippetId:e,code:t,fileType:n||"txt",timestamp:Date.now()};return this._log("Sending code snippet to IDE for editing",{snippetId:e,fileType:n}),this._sendMessage(o),e}onStatusChange(e){if("function"!=typeof e)throw new Error("Callback must be a function");this.statusCallbacks.push(e),e({serverConnected:this.connected,desktopConnected:this.desktopConnected})}onCodeUpdate(e){if("function"!=typeof e)throw new Error("Callback must be a function");this.codeUpdateCallbacks.push(e)}onError(e){if("function"!=typeof e)throw new Error("Callback must be a function");this.errorCallbacks.push(e)}onMessage(e){if("function"!=typeof e)throw new Error("Callback must be a function");this.messageCallbacks.push(e)}autoInjectButtons(e={}){return this.uiManager.autoInjectButtons(e)}injectButton(e,t={}){return this.uiManager.injectButton(e,t)}async _establishConnection(){return new Promise((e,t)=>{try{this._log("Establishing connection to Web-IDE-Bridge server",{url:this.options.serverUrl}),this.ws=new WebSocket(this.options.serverUrl),this.connectionTimeout=setTimeout(()=>{this.ws.readyState!==WebSocket.OPEN&&(this.ws.close(),t(new Error("Connection timeout")))},this.options.connectionTimeout),this.ws.onopen=()=>{clearTimeout(this.connectionTimeout),this._log("Connection to Web-IDE-Bridge server opened"),this._handleConnectionOpen(),e()},this.ws.onmessage=e=>{this._handleMessage(e)},this.ws.onclose=e=>{this._handleConnectionClose(e)},this.ws.onerror=e=>{clearTimeout(this.connectionTimeout),this._log("Connection to Web-IDE-Bridge server failed",e),t(new Error("Connection to Web-IDE-Bridge server failed"))}}catch(e){clearTimeout(this.connectionTimeout),t(e)}})}_handleConnectionOpen(){this.connected=!0,this.connecting=!1,this._updateStatus();const e={type:"browser_connect",connectionId:this.connectionId,userId:this.userId,timestamp:Date.now()};this._sendMessage(e),this._startHeartbeat()}_handleConnectionClose(e){this._log("Connection to Web-IDE-Bridge server closed",{code:e.code,reason:e.reason}),this.connected=!1,this.connecting=!1,this._clearTimeouts(),this._updateStatus(),this.options.autoReconnect&&1e3!==e.code&&this._scheduleReconnect()}_handleConnectionError(e){this._log("Connection to Web-IDE-Bridge server error",e),this._triggerErrorCallbacks(e.message||"Connection to Web-IDE-Bridge server failed"),this.options.autoReconnect&&this._scheduleReconnect()}_handleMessage(e){try{const t=JSON.parse(e.data);switch(this._log("Received message",t),this.messageCallbacks.forEach(e=>{try{e(t)}catch(e){this._log("Error in message callback",e)}}),t.type){case"connection_init":this._handleConnectionInit(t);break;case"connection_ack":this._log("Connection acknowledged by Web-IDE-Bridge server");break;case"code_update":this._handleCodeUpdate(t);break;case"pong":this._log("Received heartbeat response from Web-IDE-Bridge server");break;case"error":this._handleServerError(t);break;case"status_update":this._handleStatusUpdate(t);break;default:this._log("Unknown message type",t.type)}}catch(t){this._log("Error parsing message",t),this._log("Raw message data",e.data),this._triggerErrorCallbacks("Failed to parse server message: "+t.message)}}_handleConnectionInit(e){e.connectionId&&(this.connectionId=e.connectionId,this._log("Connection ID updated from Web-IDE-Bridge server",this.connectionId),this._startHeartbeat())}_handleCodeUpdate(e){if(!e.snippetId||!e.code)return void this._log("Invalid code update message",e);const{snippetId:t,code:n}=e;this._log("Received code update from IDE",{snippetId:t,codeLength:n.length}),this._log("Number of code update callbacks:",this.codeUpdateCallbacks.length);let o=!1;this.codeUpdateCallbacks.forEach(e=>{try{o=!0;const s=e(t,n);this._log("Callback result:",{result:s,type:typeof s,hasContent:s?.trim()}),"string"==typeof s&&s.trim()&&(this._log("Sending info message from callback result"),this._sendMessage({type:"info",connectionId:this.connectionId,userId:this.userId,snippetId:t,message:s.trim()}))}catch(e){this._log("Error in code update callback",e)}}),o||this._log("No code update callbacks executed for snippet:",t),!1!==this.options.addButtons&&(this._log("Sending default info message (addButtons mode)"),this._sendMessage({type:"info",connectionId:this.connectionId,userId:this.userId,snippetId:t,message:`Code snippet ${t} has been updated in the web application`}))}_handleServerError(e){const t=e.message||"Unknown server error";this._log("Web-IDE-Bridge server error",t),this._triggerErrorCallbacks(t)}_handleStatusUpdate(e){"boolean"==typeof e.desktopConnected&&(this.desktopConnected=e.desktopConnected,this._updateStatus())}_sendMessage(e){if(!this.ws||this.ws.readyState!==WebSocket.OPEN)throw new Error("WebSocket not connected");try{this.ws.send(JSON.stringify(e)),this._log("Sent message to Web-IDE-Bridge server",e)}catch(e){throw this._log("Error sending message to Web-IDE-Bridge server",e),new Error("Failed to send message to Web-IDE-Bridge server")}}_startHeartbeat(){this._clearHeartbeat(),this.options.heartbeatInterval>0&&(this.heartbeatTimeout=setTimeout(()=>{if(this.connected)try{this._sendMessage({type:"ping",connectionId:this.connectionId,timestamp:Date.now()}),this._startHeartbeat()}catch(e){this._log("Heartbeat to Web-IDE-Bridge server failed",e)}},this.options.heartbeatInterval))}_clearHeartbeat(){this.heartbeatTimeout&&(clearTimeout(this.heartbeatTimeout),this.heartbeatTimeout=null)}_scheduleReconnect(){if(this.reconnectAttempts>=this.options.maxReconnectAttempts)return this._log("Maximum reconnection attempts to Web-IDE-Bridge server reached"),void this._triggerErrorCallbacks("Maximum reconnection attempts to Web-IDE-Bridge server exceeded");const e=Math.min(this.options.reconnectInterval*Math.pow(2,this.reconnectAttempts),3e4);this._log(`Scheduling reconnection attempt ${this.reconnectAttempts+1} to Web-IDE-Bridge server in ${e}ms`),this.reconnectTimeout=setTimeout(()=>{this.debouncedReconnect()},e)}async _attemptReconnect(){if(!this.connected&&!this.connecting){this.reconnectAttempts++,this._log(`Reconnection attempt ${this.reconnectAttempts} to Web-IDE-Bridge server`);try{await this.connect()}catch(e){this._log("Reconnection to Web-IDE-Bridge server failed",e),this.reconnectAttempts<this.options.maxReconnectAttempts&&this._scheduleReconnect()}}}_clearTimeouts(){this.reconnectTimeout&&(clearTimeout(this.reconnectTimeout),this.reconnectTimeout=null),this.connectionTimeout&&(clearTimeout(this.connectionTimeout),this.connectionTimeout=null),this._clearHeartbeat()}_updateStatus(){this.statusCallbacks.forEach(e=>{try{e({serverConnected:this.connected,desktopConnected:this.desktopConnected})}catch(e){this._log("Error in status callback",e)}})}_triggerErrorCallbacks(e){this.errorCallbacks.forEach(t=>{try{t(e)}catch(e){this._log("Error in error callback",e)}})}_log(e,t=null){if(this.options.debug){const n=`[WebIdeBridge] ${e}`;t?console.log(n,t):console.log(n)}}}});
\ No newline at end of file
+(function(e,t){typeof exports==='object'&&typeof module!=='undefined'?module.exports=t():typeof define==='function'&&define.amd?define(t):(e=typeof globalThis!=='undefined'?globalThis:e||self,e.WebIdeBridge=t())}(this,function(){'use strict';function e(){return'xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx'.replace(/[xy]/g,function(t){const e=Math.random()*16|0;const n=t==='x'?e:e&3|8;return n.toString(16)})}function n(e){if(!e||typeof e!=='string'){return!1}try{const t=new URL(e);return t.protocol==='ws:'||t.protocol==='wss:'}catch{return!1}}function o(){if(typeof window==='undefined'||!window.location){return''}return String(window.location.href).split('#')[0]}function t(t,o,n=!1){let e;return function i(...i){const r=()=>{e=null;if(!n)t.apply(this,i)};const s=n&&!e;clearTimeout(e);e=setTimeout(r,o);if(s)t.apply(this,i)}}class i{constructor(e){this.webIdeBridge=e;this.injectedButtons=new Map();this.observers=[];this.styles=null;this.initialized=!1}autoInjectButtons(t={}){const n={selector:'textarea',buttonText:'Edit in IDE \u2197',editingText:'Editing in IDE\u2026',buttonClass:'web-ide-bridge-btn',position:'after',fileTypeAttribute:'data-language',defaultFileType:'txt',excludeSelector:'.web-ide-bridge-exclude',includeOnlySelector:null,watchForChanges:!0,style:'modern'};const e={...n,...t};this._initializeStyles(e.style);this._injectButtonsForSelector(e);if(e.watchForChanges){this._watchForDOMChanges(e)}return{refresh:()=>this._injectButtonsForSelector(e),destroy:()=>this.removeAllButtons()}}injectButton(t,o={}){if(!t||t.tagName!=='TEXTAREA'){throw new Error('Element must be a textarea')}const i={buttonText:'Edit in IDE \u2197',editingText:'Editing in IDE\u2026',buttonClass:'web-ide-bridge-btn',position:'after',fileType:'txt',style:'modern'};const n={...i,...o};this._initializeStyles(n.style);if(!t.id){t.id='web-ide-bridge-textarea-'+e()}return this._createAndInjectButton(t,n)}removeAllButtons(){this.injectedButtons.forEach(e=>{if(e.parentNode){e.parentNode.removeChild(e)}});this.injectedButtons.clear();this.observers.forEach(e=>e.disconnect());this.observers=[];if(this.styles&&this.styles.parentNode){this.styles.parentNode.removeChild(this.styles);this.styles=null}}updateButtonStates(e){this.injectedButtons.forEach(t=>{t.disabled=!e;t.textContent=t.dataset.editing?t.dataset.editingText:t.dataset.originalText})}updateEditState(o,i){const t=i==='edit_started';if(!t&&!['launch_failed','editor_closed','watch_stopped'].includes(i)){return}const e=this.injectedButtons.get(o);if(e){e.dataset.editing=t?'true':'';e.textContent=t?e.dataset.editingText:e.dataset.originalText;e.classList.toggle('web-ide-bridge-editing',t)}const n=document.getElementById(o);if(this.webIdeBridge.options.lockWhileEditing&&n&&n.tagName==='TEXTAREA'){n.readOnly=t}}_initializeStyles(n){if(this.styles||this.initialized)return;const e=document.createElement('style');e.id='web-ide-bridge-styles';let t='';switch(n){case'modern':t=this._getModernButtonStyles();break;case'minimal':t=this._getMinimalButtonStyles();break;default:t=this._getModernButtonStyles()}e.textContent=t;document.head.appendChild(e);this.styles=e;this.initialized=!0}_getModernButtonStyles(){return"\n        .web-ide-bridge-btn {\n          background: linear-gradient(135deg, #4f46e5 0%, #7c3aed 100%);\n          color: white;\n          border: none;\n          padding: 0.75rem 1.5rem;\n          border-radius: 8px;\n          font-weight: 600;\n          font-size: 0.875rem;\n          cursor: pointer;\n          transition: all 0.3s ease;\n          display: inline-flex;\n          align-items: center;\n          gap: 0.5rem;\n          margin: 0.5rem 0;\n          font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;\n          text-decoration: none;\n          outline: none;\n        }\n\n        .web-ide-bridge-btn:hover:not(:disabled) {\n          transform: translateY(-1px);\n          box-shadow: 0 4px 12px rgba(79, 70, 229, 0.3);\n        }\n\n        .web-ide-bridge-btn:active:not(:disabled) {\n          transform: translateY(0);\n        }\n\n        .web-ide-bridge-btn:disabled {\n          background: #9ca3af;\n          cursor: not-allowed;\n          transform: none;\n          box-shadow: none;\n        }\n\n        .web-ide-bridge-btn:focus {\n          box-shadow: 0 0 0 3px rgba(79, 70, 229, 0.3);\n        }\n\n        .web-ide-bridge-container {\n          display: flex;\n          gap: 0.75rem;\n          align-items: center;\n          margin-top: 0.5rem;\n          flex-wrap: wrap;\n        }\n\n        .web-ide-bridge-file-type {\n          padding: 0.5rem;\n          border: 1px solid #d1d5db;\n          border-radius: 6px;\n          font-size: 0.875rem;\n          background: white;\n          color: #374151;\n        }\n      "}_getMinimalButtonStyles(){return'\n        .web-ide-bridge-btn {\n          background: #4f46e5;\n          color: white;\n          border: 1px solid #4f46e5;\n          padding: 0.5rem 1rem;\n          border-radius: 4px;\n          font-size: 0.875rem;\n          cursor: pointer;\n          transition: background-color 0.2s ease;\n          font-family: inherit;\n          outline: none;\n        }\n\n        .web-ide-bridge-btn:hover:not(:disabled) {\n          background: #4338ca;\n        }\n\n        .web-ide-bridge-btn:disabled {\n          background: #9ca3af;\n          border-color: #9ca3af;\n          cursor: not-allowed;\n        }\n\n        .web-ide-bridge-btn:focus {\n          box-shadow: 0 0 0 2px rgba(79, 70, 229, 0.5);\n        }\n\n        .web-ide-bridge-container {\n          margin-top: 0.5rem;\n        }\n\n        .web-ide-bridge-file-type {\n          margin-left: 0.5rem;\n          padding: 0.25rem 0.5rem;\n          border: 1px solid #ccc;\n          border-radius: 3px;\n          font-size: 0.8rem;\n        }\n      '}_injectButtonsForSelector(t){let n=document.querySelectorAll(t.selector);n=Array.from(n).filter(e=>{if(t.excludeSelector&&e.matches(t.excludeSelector)){return!1}if(t.includeOnlySelector&&!e.matches(t.includeOnlySelector)){return!1}return!0});n.forEach(n=>{if(!n.id){n.id='web-ide-bridge-textarea-'+e()}if(this.injectedButtons.has(n.id)){return}const o=n.getAttribute(t.fileTypeAttribute)||t.defaultFileType;this._createAndInjectButton(n,{...t,fileType:o})})}_createAndInjectButton(e,n){const o=document.createElement('div');o.className='web-ide-bridge-container';const t=document.createElement('button');t.className=n.buttonClass;t.textContent=n.buttonText;t.dataset.textareaId=e.id;t.dataset.fileType=n.fileType;t.dataset.originalText=n.buttonText;t.dataset.editingText=n.editingText;t.disabled=!this.webIdeBridge.isConnected();t.addEventListener('click',async()=>{if(!this.webIdeBridge.isConnected()){alert('Please connect to Web-IDE-Bridge server first to edit code in your IDE');return}try{const o=e.value;const i=t.dataset.fileType;const n=this._selectionPosition(e);await this.webIdeBridge.editCodeSnippet(e.id,o,i,n?{position:n}:{});this._watchForLiveUpdates(e,t)}catch(e){console.error('Failed to send code to IDE:',e);alert(`Failed to send code to IDE: ${e.message}. Please check your connection and try again.`)}});o.appendChild(t);switch(n.position){case'before':e.parentNode.insertBefore(o,e);break;case'after':e.parentNode.insertBefore(o,e.nextSibling);break;case'append':e.parentNode.appendChild(o);break;default:e.parentNode.insertBefore(o,e.nextSibling)}this.injectedButtons.set(e.id,t);this.webIdeBridge.onStatusChange(e=>{this.updateButtonStates(e.serverConnected)});return t}_selectionPosition(t){const e=t.selectionStart||0;const n=t.selectionEnd||e;if(e===0&&n===0){return null}const i=n=>{const e=t.value.substring(0,n).split('\n');return{line:e.length,column:e[e.length-1].length+1}};const o=i(e);if(n===e){return o}const r=i(n);return{line:o.line,column:o.column,endLine:r.line,endColumn:r.column}}_watchForLiveUpdates(e,o){const n=this.webIdeBridge.options;if(!n.liveUpdates||e.dataset.webIdeBridgeLive){return}e.dataset.webIdeBridgeLive='true';e.addEventListener('input',t(()=>{if(!this.webIdeBridge.isConnected()){return}try{this.webIdeBridge.updateCodeSnippet(e.id,e.value,o.dataset.fileType)}catch(e){console.error('Failed to send code update to IDE:',e)}},n.liveUpdateDelay))}_watchForDOMChanges(e){const t=new MutationObserver(n=>{let t=!1;n.forEach(n=>{if(n.type==='childList'){n.addedNodes.forEach(n=>{if(n.nodeType===Node.ELEMENT_NODE){if(n.matches&&n.matches(e.selector)){t=!0}else if(n.querySelector&&n.querySelector(e.selector)){t=!0}}})}});if(t){setTimeout(()=>{this._injectButtonsForSelector(e)},100)}});t.observe(document.body,{childList:!0,subtree:!0});this.observers.push(t)}}class r{constructor(r,s={}){if(!r||typeof r!=='string'){throw new Error('userId is required and must be a string')}this.userId=r;this.connectionId=s.connectionId||e();this.options={serverUrl:'ws://localhost:8071/web-ide-bridge/ws',autoReconnect:!0,reconnectInterval:5e3,maxReconnectAttempts:10,heartbeatInterval:3e4,connectionTimeout:1e4,debug:!1,addButtons:!0,liveUpdates:!0,liveUpdateDelay:500,lockWhileEditing:!1,pageUrl:o(),contextFiles:null,snippetContext:null,...s};if(!n(this.options.serverUrl)){throw new Error('Invalid server URL format')}this.ws=null;this.connected=!1;this.connecting=!1;this.reconnectAttempts=0;this.reconnectTimeout=null;this.heartbeatTimeout=null;this.connectionTimeout=null;this.desktopConnected=!1;this.statusCallbacks=[];this.codeUpdateCallbacks=[];this.errorCallbacks=[];this.messageCallbacks=[];this.editEventCallbacks=[];this.snippetCode=new Map();this.uiManager=new i(this);if(this.options.addButtons){this.uiManager.autoInjectButtons()}this.debouncedReconnect=t(this._attemptReconnect.bind(this),1e3);this._log('Web-IDE-Bridge initialized for user',{userId:r,connectionId:this.connectionId})}async connect(){if(this.connected||this.connecting){this._log('Already connected to server or connection in progress');return}this.connecting=!0;this._updateStatus();try{await this._establishConnection();this.reconnectAttempts=0;this._log('Successfully connected to Web-IDE-Bridge server')}catch(e){this.connecting=!1;this._handleConnectionError(e);throw e}}disconnect(){this._log('Disconnecting from Web-IDE-Bridge server');this._clearTimeouts();this.options.autoReconnect=!1;if(this.ws){this.ws.close(1e3,'Client disconnect');this.ws=null}this.connected=!1;this.connecting=!1;this._updateStatus()}isConnected(){return this.connected}getConnectionState(){if(this.connected)return'connected';if(this.connecting)return'connecting';return'disconnected'}async editCodeSnippet(n,r,o='txt',e={}){if(!this.connected){throw new Error('Not connected to server')}if(!n||typeof n!=='string'){throw new Error('snippetId is required and must be a string')}if(typeof r!=='string'){throw new Error('code must be a string')}const s=this._resolveContextFiles(e,[{snippetId:n,fileType:o||'txt'}]);const t={type:'edit_request',connectionId:this.connectionId,userId:this.userId,snippetId:n,pageUrl:this.options.pageUrl,code:r,fileType:o||'txt',timestamp:Date.now()};if(s.length>0){t.contextFiles=s}if(e.position){const {line:o,column:i,endLine:r,endColumn:s}=e.position;const n={line:o,column:i,endLine:r,endColumn:s};Object.keys(n).forEach(e=>{if(n[e]===undefined){return}if(!Number.isInteger(n[e])||n[e]<1){throw new Error(`position.${e} must be a positive integer`)}t[e]=n[e]});if(t.line===undefined){throw new Error('position.line is required')}}if(e.transform!==undefined){t.transform=this._checkTransform(e.transform)}if(e.encoding!==undefined){if(e.encoding!=='base64'&&e.encoding!=='dataurl'){throw new Error("encoding must be 'base64' or 'dataurl'")}if(e.mimeType!==undefined&&(typeof e.mimeType!=='string'||e.mimeType.length>255)){throw new Error('mimeType must be a string of 255 characters or less')}t.encoding=e.encoding;t.mimeType=e.mimeType}const i=e.encoding?null:this._resolveSnippetContext(e,n,o||'txt');if(i){t.contextPrefix=i.prefix;t.contextSuffix=i.suffix}this._log('Sending code snippet to IDE for editing',{snippetId:n,fileType:o,contextFiles:s.length,position:e.position||null,snippetContext:!!i});this._sendMessage(t);this.snippetCode.set(n,r);return n}async editBinarySnippet(n,e,o,t={}){if(typeof e!=='string'){throw new Error('data must be a base64 string or a data URL')}const i=e.startsWith('data:')?'dataurl':'base64';return this.editCodeSnippet(n,e,t.fileType||'binary',{...t,encoding:i,mimeType:o})}async editCodeSnippets(t,n={}){if(!this.connected){throw new Error('Not connected to server')}if(!Array.isArray(t)||t.length===0){throw new Error('snippets must be a non-empty array')}const e=t.map(e=>{if(!e||!e.snippetId||typeof e.snippetId!=='string'){throw new Error('snippetId is required and must be a string')}if(typeof e.code!=='string'){throw new Error('code must be a string')}const t={snippetId:e.snippetId,code:e.code,fileType:e.fileType||'txt'};if(e.transform!==undefined){t.transform=this._checkTransform(e.transform)}return t});const o=this._resolveContextFiles(n,e);const i={type:'edit_batch_request',connectionId:this.connectionId,userId:this.userId,pageUrl:this.options.pageUrl,snippets:e,timestamp:Date.now()};if(o.length>0){i.contextFiles=o}if(n.transform!==undefined){i.transform=this._checkTransform(n.transform)}this._log('Sending code snippets to IDE for editing as one project',{snippetIds:e.map(e=>e.snippetId),contextFiles:o.length});this._sendMessage(i);e.forEach(e=>this.snippetCode.set(e.snippetId,e.code));return e.map(e=>e.snippetId)}_checkTransform(e){if(typeof e!=='string'||e.length>64){throw new Error('transform must be a string of 64 characters or less')}return e}_resolveSnippetContext(o,i,r){let e=o.snippetContext!==undefined?o.snippetContext:this.options.snippetContext;if(typeof e==='function'){e=e(i,r)}if(!e){return null}const t=e.prefix===undefined?'':e.prefix;const n=e.suffix===undefined?'':e.suffix;if(typeof t!=='string'||typeof n!=='string'){throw new Error('snippetContext must be { prefix, suffix } strings')}return t||n?{prefix:t,suffix:n}:null}_resolveContextFiles(t,o){const e=t.contextFiles!==undefined?t.contextFiles:this.options.contextFiles;if(!e){return[]}const i=typeof e==='function'?o.map(t=>e(t.snippetId,t.fileType)||[]):[e];const n=new Map();i.forEach(e=>{if(!Array.isArray(e)||e.some(e=>!e||typeof e.path!=='string'||typeof e.content!=='string')){throw new Error('contextFiles must be an array of { path, content } strings')}e.forEach(e=>n.set(e.path,{path:e.path,content:e.content}))});return Array.from(n.values())}updateCodeSnippet(e,t,n='txt'){if(!this.connected){throw new Error('Not connected to server')}if(typeof t!=='string'){throw new Error('code must be a string')}if(!this.snippetCode.has(e)||this.snippetCode.get(e)===t){return!1}this._log('Sending code update to IDE',{snippetId:e,codeLength:t.length});this._sendMessage({type:'browser_update',connectionId:this.connectionId,userId:this.userId,snippetId:e,pageUrl:this.options.pageUrl,code:t,fileType:n||'txt',timestamp:Date.now()});this.snippetCode.set(e,t);return!0}onStatusChange(e){if(typeof e!=='function'){throw new Error('Callback must be a function')}this.statusCallbacks.push(e);e({serverConnected:this.connected,desktopConnected:this.desktopConnected})}onCodeUpdate(e){if(typeof e!=='function'){throw new Error('Callback must be a function')}this.codeUpdateCallbacks.push(e)}onError(e){if(typeof e!=='function'){throw new Error('Callback must be a function')}this.errorCallbacks.push(e)}onEditEvent(e){if(typeof e!=='function'){throw new Error('Callback must be a function')}this.editEventCallbacks.push(e)}onMessage(e){if(typeof e!=='function'){throw new Error('Callback must be a function')}this.messageCallbacks.push(e)}autoInjectButtons(e={}){return this.uiManager.autoInjectButtons(e)}injectButton(e,t={}){return this.uiManager.injectButton(e,t)}async _establishConnection(){return new Promise((t,e)=>{try{this._log('Establishing connection to Web-IDE-Bridge server',{url:this.options.serverUrl});this.ws=new WebSocket(this.options.serverUrl);this.connectionTimeout=setTimeout(()=>{if(this.ws.readyState!==WebSocket.OPEN){this.ws.close();e(new Error('Connection timeout'))}},this.options.connectionTimeout);this.ws.onopen=()=>{clearTimeout(this.connectionTimeout);this._log('Connection to Web-IDE-Bridge server opened');this._handleConnectionOpen();t()};this.ws.onmessage=e=>{this._handleMessage(e)};this.ws.onclose=e=>{this._handleConnectionClose(e)};this.ws.onerror=t=>{clearTimeout(this.connectionTimeout);this._log('Connection to Web-IDE-Bridge server failed',t);e(new Error('Connection to Web-IDE-Bridge server failed'))}}catch(t){clearTimeout(this.connectionTimeout);e(t)}})}_handleConnectionOpen(){this.connected=!0;this.connecting=!1;this._updateStatus();const e={type:'browser_connect',connectionId:this.connectionId,userId:this.userId,timestamp:Date.now()};this._sendMessage(e);this._startHeartbeat()}_handleConnectionClose(e){this._log('Connection to Web-IDE-Bridge server closed',{code:e.code,reason:e.reason});this.connected=!1;this.connecting=!1;this._clearTimeouts();this._updateStatus();if(this.options.autoReconnect&&e.code!==1e3){this._scheduleReconnect()}}_handleConnectionError(e){this._log('Connection to Web-IDE-Bridge server error',e);this._triggerErrorCallbacks(e.message||'Connection to Web-IDE-Bridge server failed');if(this.options.autoReconnect){this._scheduleReconnect()}}_handleMessage(e){try{const t=JSON.parse(e.data);this._log('Received message',t);this.messageCallbacks.forEach(e=>{try{e(t)}catch(e){this._log('Error in message callback',e)}});switch(t.type){case'connection_init':this._handleConnectionInit(t);break;case'connection_ack':this._log('Connection acknowledged by Web-IDE-Bridge server');break;case'code_update':this._handleCodeUpdate(t);break;case'edit_event':this._handleEditEvent(t);break;case'pong':this._log('Received heartbeat response from Web-IDE-Bridge server');break;case'error':this._handleServerError(t);break;case'status_update':this._handleStatusUpdate(t);break;default:this._log('Unknown message type',t.type)}}catch(t){this._log('Error parsing message',t);this._log('Raw message data',e.data);this._triggerErrorCallbacks('Failed to parse server message: '+t.message)}}_handleConnectionInit(e){if(e.connectionId){this.connectionId=e.connectionId;this._log('Connection ID updated from Web-IDE-Bridge server',this.connectionId);this._startHeartbeat()}}_handleCodeUpdate(t){if(!t.snippetId||!t.code){this._log('Invalid code update message',t);return}const {snippetId:e,code:n}=t;const o=Array.isArray(t.diagnostics)?t.diagnostics:[];this._log('Received code update from IDE',{snippetId:e,codeLength:n.length,diagnostics:o.length});this.snippetCode.set(e,n);this._log('Number of code update callbacks:',this.codeUpdateCallbacks.length);let i=!1;this.codeUpdateCallbacks.forEach(t=>{try{i=!0;const r=t(e,n,o);this._log('Callback result:',{result:r,type:typeof r,hasContent:r?.trim()});if(typeof r==='string'&&r.trim()){this._log('Sending info message from callback result');this._sendMessage({type:'info',connectionId:this.connectionId,userId:this.userId,snippetId:e,message:r.trim()})}}catch(e){this._log('Error in code update callback',e)}});if(!i){this._log('No code update callbacks executed for snippet:',e)}if(this.options.addButtons!==!1){this._log('Sending default info message (addButtons mode)');this._sendMessage({type:'info',connectionId:this.connectionId,userId:this.userId,snippetId:e,message:`Code snippet ${e} has been updated in the web application`})}}_handleEditEvent(n){const {snippetId:e,event:t,reason:o}=n;const i=Array.isArray(n.diagnostics)?n.diagnostics:[];if(!e||!t){this._log('Invalid edit event message',n);return}this._log('Received edit event from IDE',{snippetId:e,event:t,reason:o});if(['launch_failed','editor_closed','watch_stopped'].includes(t)){this.snippetCode.delete(e)}this.uiManager.updateEditState(e,t);this.editEventCallbacks.forEach(n=>{try{n(e,t,o||'',i)}catch(e){this._log('Error in edit event callback',e)}})}_handleServerError(t){const e=t.message||'Unknown server error';this._log('Web-IDE-Bridge server error',e);this._triggerErrorCallbacks(e)}_handleStatusUpdate(e){if(typeof e.desktopConnected==='boolean'){this.desktopConnected=e.desktopConnected;this._updateStatus()}}_sendMessage(e){if(!this.ws||this.ws.readyState!==WebSocket.OPEN){throw new Error('WebSocket not connected')}try{this.ws.send(JSON.stringify(e));this._log('Sent message to Web-IDE-Bridge server',e)}catch(e){this._log('Error sending message to Web-IDE-Bridge server',e);throw new Error('Failed to send message to Web-IDE-Bridge server')}}_startHeartbeat(){this._clearHeartbeat();if(this.options.heartbeatInterval>0){this.heartbeatTimeout=setTimeout(()=>{if(this.connected){try{this._sendMessage({type:'ping',connectionId:this.connectionId,timestamp:Date.now()});this._startHeartbeat()}catch(e){this._log('Heartbeat to Web-IDE-Bridge server failed',e)}}},this.options.heartbeatInterval)}}_clearHeartbeat(){if(this.heartbeatTimeout){clearTimeout(this.heartbeatTimeout);this.heartbeatTimeout=null}}_scheduleReconnect(){if(this.reconnectAttempts>=this.options.maxReconnectAttempts){this._log('Maximum reconnection attempts to Web-IDE-Bridge server reached');this._triggerErrorCallbacks('Maximum reconnection attempts to Web-IDE-Bridge server exceeded');return}const e=Math.min(this.options.reconnectInterval*Math.pow(2,this.reconnectAttempts),3e4);this._log(`Scheduling reconnection attempt ${this.reconnectAttempts+1} to Web-IDE-Bridge server in ${e}ms`);this.reconnectTimeout=setTimeout(()=>{this.debouncedReconnect()},e)}async _attemptReconnect(){if(this.connected||this.connecting){return}this.reconnectAttempts++;this._log(`Reconnection attempt ${this.reconnectAttempts} to Web-IDE-Bridge server`);try{await this.connect()}catch(e){this._log('Reconnection to Web-IDE-Bridge server failed',e);if(this.reconnectAttempts<this.options.maxReconnectAttempts){this._scheduleReconnect()}}}_clearTimeouts(){if(this.reconnectTimeout){clearTimeout(this.reconnectTimeout);this.reconnectTimeout=null}if(this.connectionTimeout){clearTimeout(this.connectionTimeout);this.connectionTimeout=null}this._clearHeartbeat()}_updateStatus(){this.statusCallbacks.forEach(e=>{try{e({serverConnected:this.connected,desktopConnected:this.desktopConnected})}catch(e){this._log('Error in status callback',e)}})}_triggerErrorCallbacks(e){this.errorCallbacks.forEach(t=>{try{t(e)}catch(e){this._log('Error in error callback',e)}})}_log(t,e=null){if(this.options.debug){const n=`[WebIdeBridge] ${t}`;if(e){console.log(n,e)}else{console.log(n)}}}}return r}))
\ No newline at end of file
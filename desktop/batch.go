@@ -0,0 +1,160 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Batch
+ * @tagline         Multi-file edit sessions opened as one project
+ * @description     Opens several snippets of a page in one workspace folder with a single
+ *                  IDE launch; each file syncs to its own snippet, and the batch ends as a unit
+ * @file            desktop/batch.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// parseBatchSnippets reads the snippets of an edit_batch_request from the current server
+func (c *WebSocketClient) parseBatchSnippets(m map[string]interface{}) []bridge.BatchSnippet {
+	c.statusMu.Lock()
+	server := c.cfg.WebSocket
+	c.statusMu.Unlock()
+	return bridge.ParseBatchSnippets(server, m)
+}
+
+// handleBatchEditRequest saves all snippets of a batch into the workspace folder of
+// their page, starts a watcher per snippet, and launches the IDE once for the folder
+func (c *WebSocketClient) handleBatchEditRequest(snippets []bridge.BatchSnippet, companions []bridge.CompanionFile) {
+	if len(snippets) == 0 {
+		return
+	}
+	c.statusMu.Lock()
+	currentCfg := c.cfg
+	c.statusMu.Unlock()
+
+	// A batch always opens as one folder, whatever the workspace mode
+	batch := generateUUID()
+	files := make([]string, len(snippets))
+	for i, s := range snippets {
+		c.stopFileWatcher(s.Key)
+		c.recordHistory(s.Key, bridge.HistoryReceived, s.FileType, "", s.Code)
+		// Snippets without fileType are text, unless their language is detected
+		s.FileType = c.detectFileType(currentCfg.DetectLanguage, s.Key, s.Code, s.FileType)
+		if s.FileType == "" {
+			s.FileType = "txt"
+		}
+		var applied *sessionTransform
+		snippets[i].Code, snippets[i].FileType, applied = c.decodeForEdit(currentCfg.Transforms, s.Transform, s.Key, s.Code, s.FileType)
+		c.setTransform(s.Key, applied)
+		c.setGuard(s.Key, nil)
+		c.setBinary(s.Key, nil)
+		files[i] = bridge.SessionFilePath(bridge.WorkspacePage, s.Key, snippets[i].FileType)
+	}
+	workspace := bridge.WorkspaceOf(files[0])
+	first := snippets[0]
+	ideCmd, profile, source := selectLaunch(currentCfg, first.FileType, filepath.Base(files[0]))
+	c.log(fmt.Sprintf("Saving %d code snippets to workspace folder %s, and launching IDE %s (%s)", len(snippets), workspace, ideCmd, source))
+
+	created := 0
+	for i, s := range snippets {
+		n, err := scaffoldWorkspace(workspace, s.Key, s.FileType, filepath.Base(files[i]), currentCfg.WorkspaceTemplates)
+		if err != nil {
+			c.log("Failed to create workspace folder: " + err.Error())
+			return
+		}
+		created += n
+	}
+	if created > 0 {
+		c.log(fmt.Sprintf("Created workspace folder %s with %d scaffolding files", workspace, created))
+	}
+	if err := c.tempFiles.Record(workspace, first.Key.String()); err != nil {
+		c.log("Failed to update temp file manifest: " + err.Error())
+	}
+	if written, err := bridge.WriteCompanionFiles(workspace, companions, files...); err != nil {
+		c.log("Failed to write companion files: " + err.Error())
+	} else if written > 0 {
+		c.log(fmt.Sprintf("Wrote %d of %d companion files for the batch", written, len(companions)))
+	}
+
+	var opened []bridge.BatchSnippet
+	var openedFiles []string
+	for i, s := range snippets {
+		merge, err := c.writeEditFile(s.Key, files[i], s.FileType, s.Code)
+		if err != nil {
+			c.log(fmt.Sprintf("Failed to save code snippet %s to temp file: %s", s.Key, err.Error()))
+			c.sendEditEvent(s.Key, "launch_failed", err.Error())
+			continue
+		}
+		if err := c.tempFiles.Record(files[i], s.Key.String()); err != nil {
+			c.log("Failed to update temp file manifest: " + err.Error())
+		}
+		c.startFileWatcher(s.Key, files[i], s.FileType)
+		c.setBatch(s.Key, batch)
+		if merge.local != "" {
+			c.setLocalEdits(s.Key)
+		}
+		if merge.conflicts > 0 {
+			if w, ok := c.watcher(s.Key); ok {
+				c.setSyncState(w, bridge.SyncStateConflict, false)
+			}
+		}
+		opened = append(opened, s)
+		openedFiles = append(openedFiles, files[i])
+	}
+	if len(opened) == 0 {
+		return
+	}
+
+	launch, err := bridge.ResolveLaunch(profile, bridge.LaunchContext{
+		File:      openedFiles[0],
+		Files:     openedFiles,
+		Workspace: workspace,
+		SnippetID: opened[0].Key.SnippetID,
+		FileType:  opened[0].FileType,
+		IDE:       ideCmd,
+	})
+	if err == nil {
+		c.log("Launching IDE: " + launch.String())
+		err = c.launchIDE(opened[0].Key, launch)
+	}
+	if err != nil {
+		c.log("Failed to launch IDE: " + err.Error())
+		for _, s := range opened {
+			c.sendEditEvent(s.Key, "launch_failed", err.Error())
+			c.endEditSession(s.Key, false, c.hasLocalEdits(s.Key))
+		}
+		return
+	}
+	for _, s := range opened {
+		c.setEditor(s.Key, filepath.Base(ideCmd))
+		c.sendEditEvent(s.Key, "edit_started", fmt.Sprintf("opened in %s with %d files", ideCmd, len(opened)))
+	}
+}
+
+// setBatch marks a session as part of a batch
+func (c *WebSocketClient) setBatch(key bridge.SessionKey, batch string) {
+	c.watchersMu.Lock()
+	if w, ok := c.watchers[key]; ok {
+		w.batch = batch
+	}
+	c.watchersMu.Unlock()
+}
+
+// sessionGroup returns the sessions that end together with a session, see bridge.BatchGroup
+func (c *WebSocketClient) sessionGroup(key bridge.SessionKey) []bridge.SessionKey {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	batches := make(map[bridge.SessionKey]string, len(c.watchers))
+	for k, w := range c.watchers {
+		batches[k] = w.batch
+	}
+	return bridge.BatchGroup(key, batches)
+}
@@ -0,0 +1,110 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Binary
+ * @tagline         Binary snippets such as images and other non-text content
+ * @description     Writes the raw bytes of base64 or data URL snippets, see bridge/binary.go,
+ *                  to the temp file for editing in tools such as GIMP or Inkscape, and applies
+ *                  browser updates unless the file has local changes
+ * @file            desktop/binary.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// setBinary records the format of a binary edit session, or removes it for nil
+func (c *WebSocketClient) setBinary(key bridge.SessionKey, f *bridge.BinaryFormat) {
+	c.watchersMu.Lock()
+	if f == nil {
+		delete(c.binaries, key)
+	} else {
+		c.binaries[key] = *f
+	}
+	c.watchersMu.Unlock()
+}
+
+// binaryOf returns the format of a binary edit session
+func (c *WebSocketClient) binaryOf(key bridge.SessionKey) (bridge.BinaryFormat, bool) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	f, ok := c.binaries[key]
+	return f, ok
+}
+
+// writeBinaryFile writes the bytes of a binary snippet to the temp file. Binary content
+// cannot be merged: local edits that were never delivered are kept in a copy next to it.
+func (c *WebSocketClient) writeBinaryFile(key bridge.SessionKey, tmpFile string, data []byte) error {
+	local, errLocal := os.ReadFile(tmpFile)
+	base, errBase := os.ReadFile(baseVersionPath(tmpFile))
+	if errLocal == nil && errBase == nil && !bytes.Equal(local, base) && !bytes.Equal(local, data) {
+		ext := filepath.Ext(tmpFile)
+		keep := strings.TrimSuffix(tmpFile, ext) + "-local" + ext
+		if err := os.WriteFile(keep, local, 0644); err != nil {
+			c.log("Failed to keep undelivered local edits: " + err.Error())
+		} else {
+			c.tempFiles.Record(keep, key.String())
+			c.log(fmt.Sprintf("Snippet %s has undelivered local edits, kept them in %s", key, keep))
+		}
+	}
+	if err := bridge.WriteFileAtomic(tmpFile, data); err != nil {
+		return err
+	}
+	if err := saveBaseVersion(tmpFile, string(data)); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	return nil
+}
+
+// applyBinaryUpdate writes a browser update of a binary snippet to the temp file, unless
+// the file has local changes that were not sent yet
+func (c *WebSocketClient) applyBinaryUpdate(key bridge.SessionKey, w *fileWatch, f bridge.BinaryFormat, payload string) {
+	data, err := f.Decode(payload)
+	if err != nil {
+		c.log(fmt.Sprintf("Browser update for snippet %s not applied: %s", key, err.Error()))
+		c.sendEditEvent(key, "update_rejected", err.Error())
+		return
+	}
+	raw, err := os.ReadFile(w.tmpFile)
+	if err != nil {
+		c.log("Failed to read temp file: " + err.Error())
+		return
+	}
+	if bytes.Equal(raw, data) {
+		w.setSynced(sha256.Sum256(raw))
+		c.setSyncState(w, bridge.SyncStateSynced, false)
+		return
+	}
+	base, err := os.ReadFile(baseVersionPath(w.tmpFile))
+	if err != nil || !bytes.Equal(base, raw) {
+		c.log(fmt.Sprintf("Browser update for snippet %s not applied: the temp file has local changes that were not sent yet. Save in the IDE to send them.", key))
+		c.sendEditEvent(key, "update_rejected", "local changes not sent yet")
+		c.setSyncState(w, bridge.SyncStateUnsent, false)
+		return
+	}
+	w.setSynced(sha256.Sum256(data))
+	if err := bridge.WriteFileAtomic(w.tmpFile, data); err != nil {
+		c.log("Failed to write browser update to temp file: " + err.Error())
+		return
+	}
+	if err := saveBaseVersion(w.tmpFile, string(data)); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	c.tempFiles.Record(w.tmpFile, key.String())
+	c.setSyncState(w, bridge.SyncStateSynced, false)
+	c.log(fmt.Sprintf("Applied browser update to binary snippet %s, %d bytes", key, len(data)))
+}
@@ -0,0 +1,69 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Batch
+ * @tagline         Snippets and sessions of multi-file edit requests
+ * @description     Reads the snippets of an edit_batch_request, and groups the sessions
+ *                  of a batch that end as a unit
+ * @file            desktop/bridge/batch.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import "sort"
+
+// BatchSnippet is one snippet of a batch edit request
+type BatchSnippet struct {
+	Key       SessionKey
+	Code      string
+	FileType  string
+	Transform string // requested transform of the snippet or the batch
+}
+
+// ParseBatchSnippets reads the snippets of an edit_batch_request from a server.
+// Snippets without snippetId are skipped.
+func ParseBatchSnippets(server string, m map[string]interface{}) []BatchSnippet {
+	list, _ := m["snippets"].([]interface{})
+	pageUrl, _ := m["pageUrl"].(string)
+	snippets := make([]BatchSnippet, 0, len(list))
+	for _, item := range list {
+		entry, _ := item.(map[string]interface{})
+		snippetId, _ := entry["snippetId"].(string)
+		key := SessionKey{Server: server, Page: pageUrl, SnippetID: snippetId}
+		code, _ := entry["code"].(string)
+		code = NormalizeText(code)
+		fileType, _ := entry["fileType"].(string)
+		// A transform of the snippet overrides the transform of the batch
+		transform, _ := entry["transform"].(string)
+		if transform == "" {
+			transform, _ = m["transform"].(string)
+		}
+		if key.SnippetID != "" {
+			snippets = append(snippets, BatchSnippet{Key: key, Code: code, FileType: fileType, Transform: transform})
+		}
+	}
+	return snippets
+}
+
+// BatchGroup returns the sessions that end together with a session: all sessions of
+// its batch, sorted by snippet ID, or only the session itself. batches maps the active
+// sessions to their batch ID, empty for single snippets.
+func BatchGroup(key SessionKey, batches map[SessionKey]string) []SessionKey {
+	batch, ok := batches[key]
+	if !ok || batch == "" {
+		return []SessionKey{key}
+	}
+	var group []SessionKey
+	for k, other := range batches {
+		if other == batch {
+			group = append(group, k)
+		}
+	}
+	sort.Slice(group, func(i, j int) bool { return group[i].SnippetID < group[j].SnippetID })
+	return group
+}
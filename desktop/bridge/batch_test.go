@@ -0,0 +1,86 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Batch Tests
+ * @tagline         Tests for multi-file edit requests
+ * @description     Tests reading the snippets of a batch, and grouping the sessions of a
+ *                  batch that end as a unit
+ * @file            desktop/bridge/batch_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestParseBatchSnippets(t *testing.T) {
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"type": "edit_batch_request",
+		"pageUrl": "https://example.com/demo",
+		"transform": "json-yaml",
+		"snippets": [
+			{"snippetId": "code", "code": "let a = 1;\r\n", "fileType": "js"},
+			{"snippetId": "config", "code": "{}", "fileType": "json", "transform": "none"},
+			{"code": "no snippetId"},
+			"not an object",
+			{"snippetId": "notes"}
+		]
+	}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snippets := ParseBatchSnippets("ws://a", m)
+	want := []BatchSnippet{
+		{Key: SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: "code"}, Code: "let a = 1;\n", FileType: "js", Transform: "json-yaml"},
+		{Key: SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: "config"}, Code: "{}", FileType: "json", Transform: "none"},
+		{Key: SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: "notes"}, Transform: "json-yaml"},
+	}
+	if fmt.Sprintf("%+v", snippets) != fmt.Sprintf("%+v", want) {
+		t.Errorf("snippets\n%+v\nwant\n%+v", snippets, want)
+	}
+	if snippets := ParseBatchSnippets("ws://a", map[string]interface{}{"snippets": "none"}); len(snippets) != 0 {
+		t.Errorf("snippets of an invalid batch: %+v", snippets)
+	}
+}
+
+func TestBatchGroup(t *testing.T) {
+	key := func(id string) SessionKey {
+		return SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: id}
+	}
+	batches := map[SessionKey]string{
+		key("style"):  "batch-1",
+		key("code"):   "batch-1",
+		key("markup"): "batch-1",
+		key("other"):  "batch-2",
+		key("single"): "",
+	}
+	tests := []struct {
+		key  SessionKey
+		want string
+	}{
+		{key("style"), "[code markup style]"},
+		{key("code"), "[code markup style]"},
+		{key("other"), "[other]"},
+		{key("single"), "[single]"},
+		// Sessions that already ended end alone
+		{key("gone"), "[gone]"},
+	}
+	for _, tt := range tests {
+		var ids []string
+		for _, k := range BatchGroup(tt.key, batches) {
+			ids = append(ids, k.SnippetID)
+		}
+		if got := fmt.Sprint(ids); got != tt.want {
+			t.Errorf("BatchGroup(%s) = %s, want %s", tt.key.SnippetID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Binary
+ * @tagline         Encoding of binary snippets such as images
+ * @description     Decodes base64 and data URL snippets to raw bytes, and encodes saved
+ *                  bytes in the form the browser sent them, with the same data URL header
+ *                  and base64 line wrapping
+ * @file            desktop/bridge/binary.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Encodings of binary snippets in edit_request and code_update messages
+const (
+	BinaryBase64  = "base64"  // code is base64, optionally wrapped in lines
+	BinaryDataURL = "dataurl" // code is a data: URL, base64 or percent-encoded
+)
+
+// BinaryExtensions are the temp file extensions of common MIME types; others are looked
+// up in the MIME tables of the system, or written as .bin
+var BinaryExtensions = map[string]string{
+	"image/png": "png", "image/jpeg": "jpg", "image/gif": "gif", "image/webp": "webp",
+	"image/avif": "avif", "image/bmp": "bmp", "image/tiff": "tiff", "image/x-icon": "ico",
+	"image/vnd.microsoft.icon": "ico", "image/svg+xml": "svg", "application/pdf": "pdf",
+	"font/woff": "woff", "font/woff2": "woff2", "font/ttf": "ttf", "font/otf": "otf",
+	"audio/mpeg": "mp3", "audio/wav": "wav", "audio/ogg": "ogg", "video/mp4": "mp4", "video/webm": "webm",
+	"application/zip": "zip", "application/octet-stream": "bin",
+}
+
+// BinaryFormat is how the browser encoded a binary snippet, so that saves are sent back
+// in the same form
+type BinaryFormat struct {
+	Encoding     string // BinaryBase64 or BinaryDataURL
+	MimeType     string
+	Header       string // data URL up to the comma, for example "data:image/png;base64"
+	Percent      bool   // data URL without ;base64, with percent-encoded bytes
+	Wrap         int    // line length of wrapped base64, 0 if not wrapped
+	FinalNewline bool   // base64 ends with a newline
+}
+
+// ParseBinaryFormat reads the encoding and mimeType of an edit_request; returns nil for
+// text snippets
+func ParseBinaryFormat(m map[string]interface{}, payload string) (*BinaryFormat, error) {
+	encoding, _ := m["encoding"].(string)
+	mimeType, _ := m["mimeType"].(string)
+	f := &BinaryFormat{Encoding: encoding, MimeType: strings.ToLower(strings.TrimSpace(mimeType))}
+	body := payload
+	switch encoding {
+	case "", "text":
+		return nil, nil
+	case BinaryBase64:
+	case BinaryDataURL:
+		comma := strings.Index(payload, ",")
+		if !strings.HasPrefix(payload, "data:") || comma < 0 {
+			return nil, fmt.Errorf("not a data URL")
+		}
+		f.Header, body = payload[:comma], payload[comma+1:]
+		f.Percent = !strings.HasSuffix(f.Header, ";base64")
+		if f.MimeType == "" {
+			f.MimeType, _, _ = strings.Cut(strings.TrimPrefix(f.Header, "data:"), ";")
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if f.MimeType == "" {
+		f.MimeType = "application/octet-stream"
+	}
+	if !f.Percent {
+		body = strings.ReplaceAll(body, "\r\n", "\n")
+		if i := strings.Index(body, "\n"); i >= 0 && i < len(body)-1 {
+			f.Wrap = i
+		}
+		f.FinalNewline = strings.HasSuffix(body, "\n")
+	}
+	return f, nil
+}
+
+// Decode returns the bytes of a payload; data URLs are decoded by their own header
+func (f BinaryFormat) Decode(payload string) ([]byte, error) {
+	body := payload
+	percent := false
+	if f.Encoding == BinaryDataURL {
+		comma := strings.Index(payload, ",")
+		if !strings.HasPrefix(payload, "data:") || comma < 0 {
+			return nil, fmt.Errorf("not a data URL")
+		}
+		percent = !strings.HasSuffix(payload[:comma], ";base64")
+		body = payload[comma+1:]
+	}
+	if percent {
+		text, err := url.PathUnescape(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URL: %v", err)
+		}
+		return []byte(text), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %v", err)
+	}
+	return data, nil
+}
+
+// Encode returns the payload of bytes in the form the browser sent
+func (f BinaryFormat) Encode(data []byte) string {
+	if f.Percent {
+		return f.Header + "," + PercentEncode(data)
+	}
+	body := base64.StdEncoding.EncodeToString(data)
+	if f.Wrap > 0 {
+		var buf strings.Builder
+		for len(body) > f.Wrap {
+			buf.WriteString(body[:f.Wrap] + "\n")
+			body = body[f.Wrap:]
+		}
+		buf.WriteString(body)
+		body = buf.String()
+	}
+	if f.FinalNewline {
+		body += "\n"
+	}
+	if f.Encoding == BinaryDataURL {
+		return f.Header + "," + body
+	}
+	return body
+}
+
+// PercentEncode escapes bytes like encodeURIComponent of JavaScript, which web pages
+// commonly use for data URLs of SVG images
+func PercentEncode(data []byte) string {
+	const unreserved = "-_.!~*'()"
+	var buf strings.Builder
+	for _, b := range data {
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || strings.IndexByte(unreserved, b) >= 0 {
+			buf.WriteByte(b)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+	return buf.String()
+}
+
+// Extension returns the temp file extension: the fileType of the browser if set, else
+// the extension of the MIME type
+func (f BinaryFormat) Extension(fileType string) string {
+	if fileType != "" && fileType != "binary" {
+		return fileType
+	}
+	if ext, ok := BinaryExtensions[f.MimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(f.MimeType); err == nil && len(exts) > 0 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+	return "bin"
+}
+
+// Label describes a binary snippet for the sessions panel
+func (f BinaryFormat) Label(fileType string) string {
+	return fmt.Sprintf("%s (%s, %s)", fileType, f.MimeType, f.Encoding)
+}
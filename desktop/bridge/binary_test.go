@@ -0,0 +1,127 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Binary Tests
+ * @tagline         Tests for the encoding of binary snippets
+ * @description     Tests that base64 and data URL snippets decode to their bytes, and that
+ *                  saves are encoded back in the exact form the browser sent
+ * @file            desktop/bridge/binary_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseBinaryFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       map[string]interface{}
+		payload string
+		want    BinaryFormat
+	}{
+		{"base64", map[string]interface{}{"encoding": "base64", "mimeType": " Image/PNG "}, "iVBORw0KGgo=",
+			BinaryFormat{Encoding: BinaryBase64, MimeType: "image/png"}},
+		{"base64 without type", map[string]interface{}{"encoding": "base64"}, "AAEC",
+			BinaryFormat{Encoding: BinaryBase64, MimeType: "application/octet-stream"}},
+		{"wrapped base64", map[string]interface{}{"encoding": "base64"}, "AAEC\r\nAwQF\r\nBg==\r\n",
+			BinaryFormat{Encoding: BinaryBase64, MimeType: "application/octet-stream", Wrap: 4, FinalNewline: true}},
+		{"data URL", map[string]interface{}{"encoding": "dataurl"}, "data:image/gif;base64,R0lGOD",
+			BinaryFormat{Encoding: BinaryDataURL, MimeType: "image/gif", Header: "data:image/gif;base64"}},
+		{"percent data URL", map[string]interface{}{"encoding": "dataurl", "mimeType": "image/svg+xml"}, "data:image/svg+xml;utf8,%3Csvg%2F%3E",
+			BinaryFormat{Encoding: BinaryDataURL, MimeType: "image/svg+xml", Header: "data:image/svg+xml;utf8", Percent: true}},
+	}
+	for _, tt := range tests {
+		f, err := ParseBinaryFormat(tt.m, tt.payload)
+		if err != nil || f == nil || *f != tt.want {
+			t.Errorf("%s: got %+v %v, want %+v", tt.name, f, err, tt.want)
+		}
+	}
+	for _, encoding := range []interface{}{nil, "", "text"} {
+		if f, err := ParseBinaryFormat(map[string]interface{}{"encoding": encoding}, "code"); f != nil || err != nil {
+			t.Errorf("encoding %v: expected a text snippet, got %+v %v", encoding, f, err)
+		}
+	}
+	if _, err := ParseBinaryFormat(map[string]interface{}{"encoding": "hex"}, "00"); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+	if _, err := ParseBinaryFormat(map[string]interface{}{"encoding": "dataurl"}, "image/png;base64,AA=="); err == nil {
+		t.Error("expected an error for a payload that is not a data URL")
+	}
+}
+
+func TestBinaryFormatRoundTrip(t *testing.T) {
+	data := []byte{0x89, 'P', 'N', 'G', 0, 1, 2, 0xFF, 0xFE, 10, 13, 42}
+	payloads := []struct {
+		name, encoding, payload string
+	}{
+		{"base64", "base64", "iVBORwABAv/+Cg0q"},
+		{"wrapped base64", "base64", "iVBO\nRwAB\nAv/+\nCg0q\n"},
+		{"data URL", "dataurl", "data:image/png;base64,iVBORwABAv/+Cg0q"},
+		{"percent data URL", "dataurl", "data:application/octet-stream,%89PNG%00%01%02%FF%FE%0A%0D*"},
+	}
+	for _, tt := range payloads {
+		f, err := ParseBinaryFormat(map[string]interface{}{"encoding": tt.encoding}, tt.payload)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := f.Decode(tt.payload)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: decoded %v %v", tt.name, got, err)
+		}
+		if enc := f.Encode(data); enc != tt.payload {
+			t.Errorf("%s: encoded %q, want %q", tt.name, enc, tt.payload)
+		}
+	}
+}
+
+func TestBinaryFormatDecode(t *testing.T) {
+	f := BinaryFormat{Encoding: BinaryDataURL, Header: "data:image/png;base64"}
+	// a browser update may change the header, for example from base64 to percent-encoding
+	if got, err := f.Decode("data:text/plain,a%20b"); err != nil || string(got) != "a b" {
+		t.Errorf("got %q %v", got, err)
+	}
+	if _, err := f.Decode("AAAA"); err == nil {
+		t.Error("expected an error for a payload that is not a data URL")
+	}
+	if _, err := (BinaryFormat{Encoding: BinaryBase64}).Decode("not base64!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+	if _, err := f.Decode("data:text/plain,%zz"); err == nil {
+		t.Error("expected an error for invalid percent-encoding")
+	}
+}
+
+func TestPercentEncode(t *testing.T) {
+	in := []byte("<svg a='1'>é ~!*()</svg>")
+	want := "%3Csvg%20a%3D'1'%3E%C3%A9%20~!*()%3C%2Fsvg%3E"
+	if got := PercentEncode(in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBinaryFormatExtension(t *testing.T) {
+	tests := []struct {
+		mimeType, fileType, want string
+	}{
+		{"image/png", "", "png"},
+		{"image/svg+xml", "binary", "svg"},
+		{"image/png", "webp", "webp"},
+		{"application/x-unknown-type", "", "bin"},
+	}
+	for _, tt := range tests {
+		if got := (BinaryFormat{MimeType: tt.mimeType}).Extension(tt.fileType); got != tt.want {
+			t.Errorf("Extension(%q) of %s = %q, want %q", tt.fileType, tt.mimeType, got, tt.want)
+		}
+	}
+	if got := (BinaryFormat{Encoding: BinaryBase64, MimeType: "image/png"}).Label("png"); got != "png (image/png, base64)" {
+		t.Errorf("unexpected label %q", got)
+	}
+}
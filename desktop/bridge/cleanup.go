@@ -0,0 +1,269 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Cleanup
+ * @tagline         Temp file ownership manifest and safe cleanup
+ * @description     Records temp files created by the app, and removes only those files
+ *                  when they are old, over quota, or when wiping on quit
+ * @file            desktop/bridge/cleanup.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TempFileRecord is one temp file created by the app
+type TempFileRecord struct {
+	Path      string    `json:"path"`
+	SnippetID string    `json:"snippet_id"`
+	Created   time.Time `json:"created"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+// TempManifest lists the temp files owned by the app, persisted next to the user config
+type TempManifest struct {
+	mu      sync.Mutex
+	path    string
+	baseDir string                     // folder of the base versions of the temp files
+	Files   map[string]*TempFileRecord `json:"files"` // path -> record
+}
+
+// CleanupPolicy limits the age and total size of temp files
+type CleanupPolicy struct {
+	MaxAge        time.Duration
+	MaxTotalBytes int64 // 0 for no limit
+	MaxFiles      int   // 0 for no limit
+}
+
+// CleanupSummary reports the result of one cleanup run
+type CleanupSummary struct {
+	Removed      int
+	RemovedBytes int64
+	Kept         int
+	Active       int
+	Missing      int
+	Failed       int
+}
+
+func (s CleanupSummary) String() string {
+	return fmt.Sprintf("removed %d files (%d KB), kept %d, skipped %d active, forgot %d missing, %d failed",
+		s.Removed, (s.RemovedBytes+1023)/1024, s.Kept, s.Active, s.Missing, s.Failed)
+}
+
+// WorkspaceOf returns the workspace folder of a snippet file, or "" for loose temp files
+func WorkspaceOf(tmpFile string) string {
+	dir := filepath.Dir(tmpFile)
+	if dir == filepath.Clean(os.TempDir()) {
+		return ""
+	}
+	return dir
+}
+
+// BaseVersionPath returns where the last synced version of a temp file is kept in baseDir
+func BaseVersionPath(baseDir, tmpFile string) string {
+	name := filepath.Base(tmpFile)
+	if workspace := WorkspaceOf(tmpFile); workspace != "" {
+		// Files in different workspace folders may have the same name
+		name = filepath.Base(workspace) + "-" + name
+	}
+	return filepath.Join(baseDir, name)
+}
+
+// LoadTempManifest loads the manifest, or returns an empty one if missing or invalid.
+// Base versions of removed temp files are removed from baseDir.
+func LoadTempManifest(path, baseDir string) *TempManifest {
+	m := &TempManifest{path: path, baseDir: baseDir, Files: map[string]*TempFileRecord{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, m); err != nil || m.Files == nil {
+		m.Files = map[string]*TempFileRecord{}
+	}
+	return m
+}
+
+// save writes the manifest atomically; the caller holds m.mu
+func (m *TempManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// removeBase removes the base version of a temp file
+func (m *TempManifest) removeBase(path string) {
+	os.Remove(BaseVersionPath(m.baseDir, path))
+}
+
+// Record marks a temp file as owned by the app
+func (m *TempManifest) Record(path, snippetId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if r, ok := m.Files[path]; ok {
+		r.LastUsed = now
+		r.SnippetID = snippetId
+	} else {
+		m.Files[path] = &TempFileRecord{Path: path, SnippetID: snippetId, Created: now, LastUsed: now}
+	}
+	return m.save()
+}
+
+// Forget removes a temp file and its base version from the manifest, after the file was deleted
+func (m *TempManifest) Forget(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBase(path)
+	if _, ok := m.Files[path]; !ok {
+		return nil
+	}
+	delete(m.Files, path)
+	return m.save()
+}
+
+// Cleanup removes owned temp files that are older than the max age, then the oldest
+// files until the size and count quotas are met. Active files are never removed.
+func (m *TempManifest) Cleanup(policy CleanupPolicy, active map[string]bool, now time.Time) CleanupSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	type candidate struct {
+		path     string
+		size     int64
+		lastUsed time.Time
+	}
+	var summary CleanupSummary
+	var candidates []candidate
+	var totalBytes int64
+	totalFiles := 0
+	remove := func(path string, size int64) bool {
+		if err := os.RemoveAll(path); err != nil {
+			summary.Failed++
+			return false
+		}
+		delete(m.Files, path)
+		m.removeBase(path)
+		summary.Removed++
+		summary.RemovedBytes += size
+		return true
+	}
+
+	for path, r := range m.Files {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			delete(m.Files, path)
+			m.removeBase(path)
+			summary.Missing++
+			continue
+		} else if err != nil {
+			summary.Failed++
+			continue
+		}
+		size := info.Size()
+		if info.IsDir() {
+			size = dirSize(path)
+		}
+		lastUsed := r.LastUsed
+		if info.ModTime().After(lastUsed) {
+			lastUsed = info.ModTime()
+		}
+		totalBytes += size
+		totalFiles++
+		if active[path] {
+			summary.Active++
+			continue
+		}
+		if policy.MaxAge > 0 && now.Sub(lastUsed) > policy.MaxAge {
+			if remove(path, size) {
+				totalBytes -= size
+				totalFiles--
+			}
+			continue
+		}
+		candidates = append(candidates, candidate{path: path, size: size, lastUsed: lastUsed})
+	}
+
+	// Enforce quotas, oldest first
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastUsed.Before(candidates[j].lastUsed) })
+	for _, c := range candidates {
+		overSize := policy.MaxTotalBytes > 0 && totalBytes > policy.MaxTotalBytes
+		overCount := policy.MaxFiles > 0 && totalFiles > policy.MaxFiles
+		if (overSize || overCount) && remove(c.path, c.size) {
+			totalBytes -= c.size
+			totalFiles--
+			continue
+		}
+		summary.Kept++
+	}
+
+	if err := m.save(); err != nil {
+		summary.Failed++
+	}
+	return summary
+}
+
+// Wipe removes all owned temp files, for shared machines
+func (m *TempManifest) Wipe() CleanupSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var summary CleanupSummary
+	for path := range m.Files {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			delete(m.Files, path)
+			m.removeBase(path)
+			summary.Missing++
+			continue
+		}
+		var size int64
+		if err == nil {
+			size = info.Size()
+			if info.IsDir() {
+				size = dirSize(path)
+			}
+		}
+		if err := os.RemoveAll(path); err != nil {
+			summary.Failed++
+			continue
+		}
+		delete(m.Files, path)
+		m.removeBase(path)
+		summary.Removed++
+		summary.RemovedBytes += size
+	}
+	if err := m.save(); err != nil {
+		summary.Failed++
+	}
+	return summary
+}
+
+// dirSize returns the total size of the files in a directory
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
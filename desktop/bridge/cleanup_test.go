@@ -0,0 +1,190 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Cleanup Tests
+ * @tagline         Tests for temp file cleanup
+ * @description     Tests that temp file cleanup only removes files owned by the app, and
+ *                  for the age and size quotas
+ * @file            desktop/bridge/cleanup_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTempManifestCleanupKeepsFilesNotOwned(t *testing.T) {
+	dir := t.TempDir()
+	m := LoadTempManifest(filepath.Join(dir, "temp-files.json"), filepath.Join(dir, "base"))
+	owned := filepath.Join(dir, "page-snippet-1.js")
+	workspace := filepath.Join(dir, "workspace-1")
+	others := []string{
+		filepath.Join(dir, "page-snippet-2.js"),
+		filepath.Join(dir, "page-snippet-1.js.orig"),
+		filepath.Join(dir, "workspace-10", "a.js"),
+		filepath.Join(dir, "notes.txt"),
+	}
+	writeTempTestFile(t, owned, 10, time.Now())
+	writeTempTestFile(t, BaseVersionPath(m.baseDir, owned), 10, time.Now())
+	writeTempTestFile(t, filepath.Join(workspace, "src", "a.js"), 10, time.Now())
+	for _, path := range others {
+		writeTempTestFile(t, path, 10, time.Now())
+	}
+	m.Record(owned, "snippet-1")
+	m.Record(workspace, "snippet-1")
+
+	// Everything is too old, and over quota
+	summary := m.Cleanup(CleanupPolicy{MaxAge: time.Hour, MaxTotalBytes: 1, MaxFiles: 1}, nil, time.Now().Add(48*time.Hour))
+	if summary.Removed != 2 || summary.RemovedBytes != 20 || summary.Failed != 0 {
+		t.Errorf("Expected the two owned entries to be removed, got %s", summary)
+	}
+	for _, path := range []string{owned, BaseVersionPath(m.baseDir, owned), workspace} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	for _, path := range others {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("File not owned by the app was removed: %s", path)
+		}
+	}
+	if len(m.Files) != 0 {
+		t.Errorf("Expected an empty manifest, got %d entries", len(m.Files))
+	}
+
+	// Wiping on quit removes owned files only as well
+	owned = filepath.Join(dir, "page-snippet-3.js")
+	writeTempTestFile(t, owned, 10, time.Now())
+	m.Record(owned, "snippet-3")
+	if summary := m.Wipe(); summary.Removed != 1 || summary.Failed != 0 {
+		t.Errorf("Expected one file to be wiped, got %s", summary)
+	}
+	for _, path := range others {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("File not owned by the app was wiped: %s", path)
+		}
+	}
+}
+
+func TestTempManifestCleanupQuotas(t *testing.T) {
+	dir := t.TempDir()
+	m := LoadTempManifest(filepath.Join(dir, "temp-files.json"), filepath.Join(dir, "base"))
+	now := time.Now()
+	var files []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("snippet-%d.js", i))
+		// Later files were saved later
+		writeTempTestFile(t, path, 100, now.Add(time.Duration(i)*time.Minute))
+		m.Record(path, fmt.Sprintf("snippet-%d", i))
+		files = append(files, path)
+	}
+	exists := func() []bool {
+		var result []bool
+		for _, path := range files {
+			_, err := os.Stat(path)
+			result = append(result, err == nil)
+		}
+		return result
+	}
+	active := map[string]bool{files[0]: true}
+	checkExists := func(want ...bool) {
+		t.Helper()
+		if got := exists(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Expected files %v, got %v", want, got)
+		}
+	}
+
+	// Nothing to do without limits
+	summary := m.Cleanup(CleanupPolicy{}, active, now)
+	if summary.Removed != 0 || summary.Kept != 4 || summary.Active != 1 {
+		t.Errorf("Expected nothing to be removed, got %s", summary)
+	}
+
+	// The oldest inactive files go first; the active file counts but is never removed
+	summary = m.Cleanup(CleanupPolicy{MaxTotalBytes: 300}, active, now)
+	if summary.Removed != 2 || summary.RemovedBytes != 200 || summary.Kept != 2 {
+		t.Errorf("Expected two files removed over the size quota, got %s", summary)
+	}
+	checkExists(true, false, false, true, true)
+
+	summary = m.Cleanup(CleanupPolicy{MaxFiles: 1}, active, now)
+	if summary.Removed != 2 || summary.Active != 1 {
+		t.Errorf("Expected two files removed over the count quota, got %s", summary)
+	}
+	checkExists(true, false, false, false, false)
+
+	// The active file is kept even over the quota and age limits
+	summary = m.Cleanup(CleanupPolicy{MaxAge: time.Minute, MaxTotalBytes: 1}, active, now.Add(24*time.Hour))
+	if summary.Removed != 0 || summary.Active != 1 {
+		t.Errorf("Expected the active file to be kept, got %s", summary)
+	}
+	checkExists(true, false, false, false, false)
+
+	// The manifest is saved: files deleted by others are forgotten on the next run
+	os.Remove(files[0])
+	reloaded := LoadTempManifest(filepath.Join(dir, "temp-files.json"), filepath.Join(dir, "base"))
+	if len(reloaded.Files) != 1 || reloaded.Files[files[0]] == nil {
+		t.Fatalf("Expected the saved manifest to list %s, got %v", files[0], reloaded.Files)
+	}
+	if summary := reloaded.Cleanup(CleanupPolicy{}, nil, now); summary.Missing != 1 || len(reloaded.Files) != 0 {
+		t.Errorf("Expected the missing file to be forgotten, got %s", summary)
+	}
+}
+
+func TestTempManifestForgetKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	m := LoadTempManifest(filepath.Join(dir, "temp-files.json"), filepath.Join(dir, "base"))
+	path := filepath.Join(dir, "snippet-1.js")
+	writeTempTestFile(t, path, 10, time.Now())
+	writeTempTestFile(t, BaseVersionPath(m.baseDir, path), 10, time.Now())
+	m.Record(path, "snippet-1")
+
+	// forget is called after the file was deleted, or when the file is handed over
+	if err := m.Forget(path); err != nil {
+		t.Fatalf("forget failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("forget removed the temp file: %v", err)
+	}
+	if _, err := os.Stat(BaseVersionPath(m.baseDir, path)); !os.IsNotExist(err) {
+		t.Error("Expected forget to remove the base version")
+	}
+	if summary := m.Cleanup(CleanupPolicy{MaxAge: time.Nanosecond}, nil, time.Now().Add(time.Hour)); summary.Removed != 0 {
+		t.Errorf("Expected a forgotten file to be left alone, got %s", summary)
+	}
+}
+
+func TestTempManifestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "temp-files.json")
+	for _, content := range []string{"", "not json", `{"files": null}`, `[1, 2]`} {
+		os.WriteFile(path, []byte(content), 0600)
+		if m := LoadTempManifest(path, ""); m.Files == nil || len(m.Files) != 0 {
+			t.Errorf("Expected an empty manifest for %q, got %v", content, m.Files)
+		}
+	}
+}
+
+// writeTempTestFile writes a file of the given size and modification time
+func writeTempTestFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set time of %s: %v", path, err)
+	}
+}
@@ -0,0 +1,79 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Companion
+ * @tagline         Read-only companion files sent with edit requests
+ * @description     Writes companion files from the browser, such as .d.ts declarations,
+ *                  stubs or sample data, next to the snippet in its workspace folder
+ * @file            desktop/bridge/companion.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CompanionFile is a read-only file that gives the IDE context for a snippet.
+// Companion files are never watched or sent back to the browser.
+type CompanionFile struct {
+	Path    string // relative to the workspace folder, with forward slashes
+	Content string
+}
+
+// ParseCompanionFiles reads the contextFiles field of an edit request
+func ParseCompanionFiles(m map[string]interface{}) []CompanionFile {
+	list, _ := m["contextFiles"].([]interface{})
+	files := make([]CompanionFile, 0, len(list))
+	for _, item := range list {
+		entry, _ := item.(map[string]interface{})
+		path, _ := entry["path"].(string)
+		content, _ := entry["content"].(string)
+		if path != "" {
+			files = append(files, CompanionFile{Path: path, Content: content})
+		}
+	}
+	return files
+}
+
+// WriteCompanionFiles writes companion files into a workspace folder as read-only
+// files. Files are only rewritten when the browser sends different content, so the
+// IDE reloads them only when a newer version arrives. Returns the number of files written.
+func WriteCompanionFiles(dir string, files []CompanionFile, snippetFiles ...string) (int, error) {
+	written := 0
+	for _, f := range files {
+		rel := filepath.FromSlash(f.Path)
+		if !filepath.IsLocal(rel) {
+			return written, fmt.Errorf("companion file path %q is outside of the workspace", f.Path)
+		}
+		path := filepath.Join(dir, rel)
+		for _, snippetFile := range snippetFiles {
+			if path == snippetFile {
+				return written, fmt.Errorf("companion file %q has the name of a snippet file", f.Path)
+			}
+		}
+		if current, err := os.ReadFile(path); err == nil && string(current) == f.Content {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return written, err
+		}
+		// Make an existing version writable, so that it can be replaced on all platforms
+		os.Chmod(path, 0644)
+		if err := WriteFileAtomic(path, []byte(f.Content)); err != nil {
+			return written, err
+		}
+		if err := os.Chmod(path, 0444); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
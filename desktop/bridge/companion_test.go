@@ -0,0 +1,124 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Companion Tests
+ * @tagline         Tests for read-only companion files
+ * @description     Tests that companion files are written read-only inside the workspace,
+ *                  rewritten only when changed, and that unsafe paths are rejected
+ * @file            desktop/bridge/companion_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseCompanionFiles(t *testing.T) {
+	var m map[string]interface{}
+	json.Unmarshal([]byte(`{"contextFiles": [
+		{"path": "types/app.d.ts", "content": "declare const app: any;"},
+		{"path": "", "content": "no path"},
+		{"content": "no path either"},
+		"not an object",
+		{"path": "data/sample.json"}
+	]}`), &m)
+	files := ParseCompanionFiles(m)
+	if len(files) != 2 || files[0] != (CompanionFile{Path: "types/app.d.ts", Content: "declare const app: any;"}) || files[1] != (CompanionFile{Path: "data/sample.json"}) {
+		t.Errorf("unexpected companion files %+v", files)
+	}
+	if files := ParseCompanionFiles(map[string]interface{}{}); len(files) != 0 {
+		t.Errorf("companion files without contextFiles: %+v", files)
+	}
+}
+
+func TestWriteCompanionFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []CompanionFile{
+		{Path: "types/app.d.ts", Content: "declare const app: any;"},
+		{Path: "sample.json", Content: "{}"},
+	}
+	written, err := WriteCompanionFiles(dir, files, filepath.Join(dir, "code.js"))
+	if err != nil || written != 2 {
+		t.Fatalf("wrote %d files: %v", written, err)
+	}
+	path := filepath.Join(dir, "types", "app.d.ts")
+	if data, _ := os.ReadFile(path); string(data) != files[0].Content {
+		t.Errorf("content %q", data)
+	}
+	if info, err := os.Stat(path); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0444) {
+		t.Errorf("companion file not read-only: %v %v", info.Mode(), err)
+	}
+
+	// Unchanged files are not rewritten, changed read-only files are replaced
+	files[1].Content = `{"a": 1}`
+	if written, err = WriteCompanionFiles(dir, files); err != nil || written != 1 {
+		t.Errorf("rewrote %d files: %v", written, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "sample.json")); string(data) != files[1].Content {
+		t.Errorf("changed companion file not replaced: %q", data)
+	}
+}
+
+func TestWriteCompanionFilesRejectsPaths(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "workspace")
+	snippet := filepath.Join(dir, "code.js")
+	tests := []string{
+		"../outside.txt",
+		"a/../../outside.txt",
+		"/etc/outside.txt",
+		"",
+		"code.js", // would replace the snippet file
+		"./code.js",
+	}
+	for _, path := range tests {
+		files := []CompanionFile{{Path: path, Content: "x"}}
+		if written, err := WriteCompanionFiles(dir, files, snippet); err == nil || written != 0 {
+			t.Errorf("path %q: wrote %d files, err %v", path, written, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside.txt")); err == nil {
+		t.Error("file written outside of the workspace")
+	}
+	if _, err := os.Stat(snippet); err == nil {
+		t.Error("snippet file replaced by a companion file")
+	}
+
+	// Files before a rejected path are written and counted
+	files := []CompanionFile{{Path: "ok.txt", Content: "ok"}, {Path: "../bad.txt", Content: "x"}, {Path: "later.txt", Content: "x"}}
+	written, err := WriteCompanionFiles(dir, files, snippet)
+	if err == nil || written != 1 {
+		t.Errorf("wrote %d files, err %v", written, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "later.txt")); err == nil {
+		t.Error("files after a rejected path written")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "code.js")
+	os.WriteFile(path, []byte("old"), 0600)
+	if err := WriteFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content %q", data)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("permissions not kept: %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
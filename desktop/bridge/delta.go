@@ -0,0 +1,88 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Delta
+ * @tagline         Line patches of code updates
+ * @description     Hashes code the way the server does, and computes line patches from
+ *                  the code the server has to the edited code
+ * @file            desktop/bridge/delta.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MaxDeltaEdits is the limit of inserted and deleted lines of a patch
+const MaxDeltaEdits = 2000
+
+// CodeHash returns the hex SHA-256 hash of code, as the server computes it
+func CodeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// LineDiff returns a line patch from base to code: a positive number copies lines of
+// base, a negative number skips lines of base, and a string inserts text. The patch
+// covers all lines of base, for example [12, -1, "total: 42\n", 300]. ok is false if
+// there are more than MaxDeltaEdits inserted and deleted lines.
+func LineDiff(base, code string) (ops []interface{}, ok bool) {
+	a, b := SplitLines(base), SplitLines(code)
+	match, ok := MatchLinesWithin(a, b, MaxDeltaEdits)
+	if !ok {
+		return nil, false
+	}
+	keep, skip := 0, 0
+	var insert strings.Builder
+	// flushChange adds the pending change before kept lines, flushKeep the kept lines before a change
+	flushChange := func() {
+		if skip > 0 {
+			ops = append(ops, -skip)
+			skip = 0
+		}
+		if insert.Len() > 0 {
+			ops = append(ops, insert.String())
+			insert.Reset()
+		}
+	}
+	flushKeep := func() {
+		if keep > 0 {
+			ops = append(ops, keep)
+			keep = 0
+		}
+	}
+	j := 0
+	for i := range a {
+		if match[i] < 0 {
+			flushKeep()
+			skip++
+			continue
+		}
+		if j < match[i] {
+			flushKeep()
+			for ; j < match[i]; j++ {
+				insert.WriteString(b[j])
+			}
+		}
+		flushChange()
+		keep++
+		j++
+	}
+	if j < len(b) {
+		flushKeep()
+		for ; j < len(b); j++ {
+			insert.WriteString(b[j])
+		}
+	}
+	flushKeep()
+	flushChange()
+	return ops, true
+}
@@ -0,0 +1,175 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Delta Tests
+ * @tagline         Tests for line patches of code updates
+ * @description     Tests that line patches apply to the code the server has, with fixtures
+ *                  shared with the server tests, and a benchmark of the bytes saved
+ * @file            desktop/bridge/delta_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeltaPatchRoundTrip(t *testing.T) {
+	cases := [][2]string{
+		{"", ""},
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc", "a\nB\nc"},
+		{"a\nb\nc\n", "a\nb\nc"},
+		{"a\nb\nc", "x\na\nc\ny"},
+		{"one\ntwo\nthree\nfour\n", "zero\none\nthree\nfour\nfive\n"},
+	}
+	for _, pair := range editSnippets() {
+		cases = append(cases, pair, [2]string{pair[1], pair[0]})
+	}
+	for _, c := range cases {
+		ops, ok := LineDiff(c[0], c[1])
+		if !ok {
+			t.Fatalf("Expected a patch from %q to %q", c[0], c[1])
+		}
+		// Patches go through JSON, where numbers become float64
+		data, err := json.Marshal(ops)
+		if err != nil {
+			t.Fatalf("Failed to encode patch: %v", err)
+		}
+		var decoded []interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to decode patch: %v", err)
+		}
+		got, err := applyLinePatch(c[0], decoded)
+		if err != nil {
+			t.Fatalf("Failed to apply patch %s: %v", data, err)
+		}
+		if got != c[1] {
+			t.Errorf("Patch %s of %q gives %q, expected %q", data, c[0], got, c[1])
+		}
+	}
+}
+
+// The patches in testdata/delta/patches.json are also applied by the server tests, so
+// that the desktop and server ends of the patch format are checked against each other
+
+func TestDeltaPatchFixtures(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "delta", "patches.json"))
+	if err != nil {
+		t.Fatalf("Failed to read patch fixtures: %v", err)
+	}
+	var fixtures []struct {
+		Base  string          `json:"base"`
+		Code  string          `json:"code"`
+		Patch json.RawMessage `json:"patch"`
+	}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("Failed to decode patch fixtures: %v", err)
+	}
+	for _, f := range fixtures {
+		ops, ok := LineDiff(f.Base, f.Code)
+		if !ok {
+			t.Fatalf("Expected a patch from %q to %q", f.Base, f.Code)
+		}
+		if ops == nil {
+			ops = []interface{}{}
+		}
+		got, _ := json.Marshal(ops)
+		var want bytes.Buffer
+		json.Compact(&want, f.Patch)
+		if string(got) != want.String() {
+			t.Errorf("Patch from %q to %q is %s, fixture has %s; update testdata/delta/patches.json", f.Base, f.Code, got, want.String())
+		}
+	}
+}
+
+func TestDeltaPatchRejectsOtherBase(t *testing.T) {
+	ops, _ := LineDiff("a\nb\nc\n", "a\nB\nc\n")
+	if _, err := applyLinePatch("a\nb\n", ops); err == nil {
+		t.Error("Expected patch not to apply to a shorter base")
+	}
+	if _, err := applyLinePatch("a\nb\nc\nd\n", ops); err == nil {
+		t.Error("Expected patch not to apply to a longer base")
+	}
+}
+
+func TestDeltaPatchEditLimit(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		fmt.Fprintf(&b, "changed %d\n", i)
+	}
+	if _, ok := LineDiff(a.String(), b.String()); ok {
+		t.Error("Expected no patch for a rewrite beyond the edit limit")
+	}
+}
+
+// BenchmarkDeltaPatch reports the size of code_update patches compared to the full code
+func BenchmarkDeltaPatch(b *testing.B) {
+	for name, pair := range editSnippets() {
+		b.Run(name, func(b *testing.B) {
+			full, _ := json.Marshal(pair[1])
+			var patch []byte
+			for i := 0; i < b.N; i++ {
+				ops, _ := LineDiff(pair[0], pair[1])
+				patch, _ = json.Marshal(ops)
+			}
+			b.ReportMetric(float64(len(full)), "full-bytes")
+			b.ReportMetric(float64(len(patch)), "patch-bytes")
+			b.ReportMetric(100*(1-float64(len(patch))/float64(len(full))), "%saved")
+		})
+	}
+}
+
+// applyLinePatch applies a line patch the way the server does, with numbers decoded
+// from JSON as float64: a Go port of applyLinePatch in server/web-ide-bridge-server.js
+func applyLinePatch(base string, ops []interface{}) (string, error) {
+	lines := SplitLines(base)
+	pos := 0
+	var code strings.Builder
+	for _, op := range ops {
+		var n int
+		switch v := op.(type) {
+		case string:
+			code.WriteString(v)
+			continue
+		case int:
+			n = v
+		case float64:
+			n = int(v)
+		default:
+			return "", fmt.Errorf("invalid patch operation %v", op)
+		}
+		if pos+abs(n) > len(lines) {
+			return "", fmt.Errorf("patch does not fit the base")
+		}
+		if n > 0 {
+			code.WriteString(strings.Join(lines[pos:pos+n], ""))
+		}
+		pos += abs(n)
+	}
+	if pos != len(lines) {
+		return "", fmt.Errorf("patch does not cover the base")
+	}
+	return code.String(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,344 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Detect
+ * @tagline         Content-based language detection of snippets without a fileType
+ * @description     Detects the language of snippets whose fileType is missing or generic,
+ *                  from shebangs, JSON and XML parse attempts, HTML doctypes, SQL keywords
+ *                  and a small classifier, so that the temp file gets a better extension
+ * @file            desktop/bridge/detect.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Language detection modes: "auto" detects snippets without fileType or with a generic
+// one such as txt, "empty" only snippets without fileType, "off" never
+const (
+	DetectAuto  = "auto"
+	DetectEmpty = "empty"
+	DetectOff   = "off"
+)
+
+// genericFileTypes are fileTypes that say nothing about the language
+var genericFileTypes = map[string]bool{"": true, "txt": true, "text": true, "plain": true, "plaintext": true}
+
+// maxDetectSize is how much of a snippet is looked at
+const maxDetectSize = 64 * 1024
+
+// NormalizeDetectLanguage maps unknown or empty values to auto
+func NormalizeDetectLanguage(mode string) string {
+	switch mode {
+	case DetectEmpty, DetectOff:
+		return mode
+	}
+	return DetectAuto
+}
+
+// ShouldDetect reports whether the language of a snippet with a fileType is detected
+func ShouldDetect(mode, fileType string) bool {
+	fileType = strings.ToLower(strings.TrimSpace(fileType))
+	switch NormalizeDetectLanguage(mode) {
+	case DetectOff:
+		return false
+	case DetectEmpty:
+		return fileType == ""
+	}
+	return genericFileTypes[fileType]
+}
+
+// shebangTypes are the fileTypes of interpreters in #! lines, without version numbers
+var shebangTypes = map[string]string{
+	"python": "py", "pypy": "py", "sh": "sh", "bash": "sh", "dash": "sh", "ksh": "sh", "zsh": "sh",
+	"node": "js", "nodejs": "js", "deno": "ts", "ts-node": "ts", "tsx": "ts", "perl": "pl",
+	"ruby": "rb", "php": "php", "lua": "lua", "rscript": "r", "pwsh": "ps1", "powershell": "ps1",
+}
+
+// htmlTags are element names that make markup HTML rather than XML
+var htmlTags = map[string]bool{
+	"html": true, "head": true, "body": true, "div": true, "span": true, "p": true, "a": true,
+	"ul": true, "ol": true, "li": true, "table": true, "tr": true, "td": true, "th": true,
+	"form": true, "input": true, "button": true, "img": true, "script": true, "style": true,
+	"section": true, "article": true, "nav": true, "header": true, "footer": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "label": true,
+	"select": true, "option": true, "textarea": true, "template": true, "br": true, "hr": true,
+}
+
+// sqlStatement matches the start of common SQL statements
+var sqlStatement = regexp.MustCompile(`(?is)^(select\s.+\sfrom\s|insert\s+into\s|update\s+\S+\s+set\s|delete\s+from\s|` +
+	`create\s+(or\s+replace\s+)?(temporary\s+)?(table|view|index|unique\s+index|function|procedure|trigger|schema|database)\s|` +
+	`alter\s+table\s|drop\s+(table|view|index|schema|database)\s|with\s+\w+\s+as\s*\(\s*select\s|merge\s+into\s|truncate\s+table\s)`)
+
+// sqlComment matches SQL comments before the first statement
+var sqlComment = regexp.MustCompile(`^(--[^\n]*\n|/\*(?s:.*?)\*/)\s*`)
+
+// languageFeature is a pattern of the classifier; each match, up to maxFeatureMatches,
+// adds its weight to the score of the language
+type languageFeature struct {
+	re     *regexp.Regexp
+	weight float64
+}
+
+const maxFeatureMatches = 5
+
+// Scores below minClassifierScore, and scores less than classifierMargin times the
+// score of the runner-up, leave a snippet undetected
+const (
+	minClassifierScore = 4
+	classifierMargin   = 1.5
+)
+
+func feature(pattern string, weight float64) languageFeature {
+	return languageFeature{re: regexp.MustCompile(pattern), weight: weight}
+}
+
+// classifierFeatures are the patterns of the classifier by fileType
+var classifierFeatures = map[string][]languageFeature{
+	"js": {
+		feature(`(?m)^\s*(const|let|var)\s+[\w$]+\s*=`, 2),
+		feature(`\bfunction\s*[\w$]*\s*\(`, 2),
+		feature(`=>`, 1),
+		feature(`\bconsole\.\w+\(`, 3),
+		feature(`\b(document|window)\.\w+`, 2),
+		feature(`\brequire\(['"]|\bmodule\.exports\b|(?m)^\s*export\s+(default|const|function|class)\b|(?m)^\s*import\s+.+\s+from\s+['"]`, 3),
+		feature(`===|!==`, 2),
+		feature(`(?m);\s*$`, 0.5),
+	},
+	"py": {
+		feature(`(?m)^\s*def\s+\w+\s*\(.*\)\s*(->\s*[\w\[\], .]+)?:\s*$`, 3),
+		feature(`(?m)^\s*(import\s+\w+|from\s+[\w.]+\s+import\s)`, 3),
+		feature(`(?m)^\s*(if|elif|for|while|with|try|except|else|finally)\b.*:\s*$`, 2),
+		feature(`\bself\.\w+`, 2),
+		feature(`\bprint\(`, 1),
+		feature(`\b(None|True|False)\b`, 1),
+		feature(`(?m)^\s*class\s+\w+(\(.*\))?:\s*$`, 3),
+		feature(`__\w+__`, 1),
+	},
+	"rb": {
+		feature(`(?m)^\s*def\s+[\w?!.]+(\s*\(.*\))?\s*$`, 3),
+		feature(`(?m)^\s*end\s*$`, 2),
+		feature(`\bputs\b`, 2),
+		feature(`(?m)^\s*require(_relative)?\s+['"]`, 3),
+		feature(`\.each\s+do\s*\|`, 3),
+		feature(`(?m)^\s*(module|class)\s+[A-Z]\w*(\s*<\s*\w+)?\s*$`, 2),
+	},
+	"go": {
+		feature(`(?m)^package\s+\w+\s*$`, 5),
+		feature(`(?m)^func\s+(\(\w+\s+\*?\w+\)\s*)?\w+\(`, 3),
+		feature(`:=`, 1),
+		feature(`\bfmt\.\w+\(`, 3),
+		feature(`\berr\s*!=\s*nil\b`, 3),
+	},
+	"css": {
+		feature(`(?m)^\s*[\w.#:*\[\]="'>+~ ,()-]+\{\s*$`, 1),
+		feature(`(?m)^\s*-?[a-z][a-z-]*\s*:\s*[^;{}\n]+;\s*$`, 2),
+		feature(`@(media|import|font-face|keyframes|supports)\b`, 3),
+		feature(`\b\d+(\.\d+)?(px|em|rem|vh|vw)\b`, 1),
+		feature(`#[0-9a-fA-F]{3,6}\b`, 1),
+	},
+	"sh": {
+		feature(`(?m)^\s*(echo|export|source|cd|fi|done|esac|exit)\b`, 2),
+		feature(`\$\{\w+\}|\$\w+`, 1),
+		feature(`(?m)^\s*(if|while)\s+\[\[?\s`, 3),
+		feature(`(?m);\s*(then|do)\s*$`, 2),
+		feature(`\|\s*(grep|awk|sed|xargs|sort|head|tail|wc|cut)\b`, 2),
+		feature(`(?m)^\s*[A-Za-z_]\w*=\S`, 1),
+	},
+	"yaml": {
+		feature(`(?m)^\s*[\w"'-]+:\s+[^\s{;]`, 1),
+		feature(`(?m)^\s*[\w"'-]+:\s*$`, 1),
+		feature(`(?m)^\s*-\s+[\w"']`, 1),
+		feature(`(?m)^---\s*$`, 3),
+	},
+	"md": {
+		feature(`(?m)^#{1,6}\s+\S`, 2),
+		feature(`\[[^\]\n]+\]\([^)\n]+\)`, 3),
+		feature(`(?m)^\s*[-*+]\s+\S`, 1),
+		feature(`(?m)^\s*\d+\.\s+\S`, 1),
+		feature(`\*\*[^*\n]+\*\*`, 2),
+		feature("(?m)^```", 3),
+		feature(`(?m)^>\s`, 1),
+	},
+	"html": {
+		feature(`</?(div|span|p|a|ul|ol|li|table|tr|td|body|head|script|style|form|input|button|img|br|h[1-6]|section|label)\b[^>]*>`, 2),
+		feature(`&[a-z]+;`, 1),
+		feature(`\s(class|id|href|src)="`, 1),
+	},
+	"sql": {
+		feature(`\b(SELECT|FROM|WHERE|JOIN|GROUP BY|ORDER BY|INSERT|VALUES|UPDATE|DELETE)\b`, 1),
+		feature(`(?m);\s*$`, 0.5),
+	},
+}
+
+// tsFeatures turn a snippet classified as JavaScript into TypeScript
+var tsFeatures = []languageFeature{
+	feature(`[\w$)]\s*:\s*(string|number|boolean|any|void|unknown|never)\b`, 2),
+	feature(`(?m)^\s*(export\s+)?interface\s+\w+`, 3),
+	feature(`(?m)^\s*(export\s+)?type\s+\w+\s*=`, 2),
+	feature(`\b(public|private|protected|readonly)\s+\w+\s*[:(]`, 2),
+	feature(`\bas\s+(string|number|const|any)\b`, 2),
+}
+
+// score returns the weighted number of matches of features in code
+func score(features []languageFeature, code string) float64 {
+	total := 0.0
+	for _, f := range features {
+		total += f.weight * float64(len(f.re.FindAllStringIndex(code, maxFeatureMatches)))
+	}
+	return total
+}
+
+// DetectLanguage returns the fileType of code and how it was detected, or "" if the
+// language is not clear
+func DetectLanguage(code string) (fileType, reason string) {
+	if len(code) > maxDetectSize {
+		code = code[:maxDetectSize]
+	}
+	text := strings.TrimSpace(NormalizeText(code))
+	if text == "" {
+		return "", ""
+	}
+	if strings.HasPrefix(text, "#!") {
+		if fileType := shebangType(text); fileType != "" {
+			return fileType, "shebang"
+		}
+	}
+	lower := strings.ToLower(text[:min(len(text), 256)])
+	switch {
+	case strings.HasPrefix(lower, "<?php"):
+		return "php", "PHP tag"
+	case strings.HasPrefix(lower, "<!doctype html"):
+		return "html", "HTML doctype"
+	}
+	if text[0] == '{' || text[0] == '[' {
+		if json.Valid([]byte(text)) {
+			return "json", "JSON parse"
+		}
+	}
+	if text[0] == '<' {
+		if root, ok := xmlRoot(text); ok {
+			switch {
+			case root == "svg":
+				return "svg", "XML parse, svg root"
+			case htmlTags[root]:
+				return "html", "XML parse, HTML root"
+			}
+			return "xml", "XML parse"
+		}
+	}
+	if sqlStatement.MatchString(sqlComment.ReplaceAllString(text, "")) {
+		return "sql", "SQL keywords"
+	}
+	return classify(text)
+}
+
+// shebangType returns the fileType of the interpreter of a #! line
+func shebangType(text string) string {
+	line, _, _ := strings.Cut(text[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := path.Base(fields[0])
+	if name == "env" {
+		name = ""
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				name = arg
+				break
+			}
+		}
+	}
+	name = strings.ToLower(strings.TrimRight(name, "0123456789."))
+	return shebangTypes[name]
+}
+
+// xmlRoot returns the name of the root element if text is well-formed XML with one root
+func xmlRoot(text string) (string, bool) {
+	d := xml.NewDecoder(strings.NewReader(text))
+	root, depth := "", 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return strings.ToLower(root), root != "" && depth == 0
+		}
+		if err != nil {
+			return "", false
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if root != "" {
+					return "", false
+				}
+				root = t.Name.Local
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && strings.TrimSpace(string(t)) != "" {
+				return "", false
+			}
+		}
+	}
+}
+
+// classify scores code against the features of each language, and returns the best
+// language if it is clearly ahead
+func classify(text string) (string, string) {
+	scores := make(map[string]float64, len(classifierFeatures))
+	for fileType, features := range classifierFeatures {
+		scores[fileType] = score(features, text)
+	}
+	// Key-value text that parses as a YAML mapping or list is likely YAML
+	if scores["yaml"] > 0 && strings.Count(text, "\n") > 0 {
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+			scores["yaml"] = 0
+		} else {
+			switch doc.(type) {
+			case map[string]interface{}, []interface{}:
+				scores["yaml"] += 3
+			default:
+				scores["yaml"] = 0
+			}
+		}
+	}
+	best := ""
+	for fileType, s := range scores {
+		if best == "" || s > scores[best] || (s == scores[best] && fileType < best) {
+			best = fileType
+		}
+	}
+	second := 0.0
+	for fileType, s := range scores {
+		if fileType != best {
+			second = max(second, s)
+		}
+	}
+	if scores[best] < minClassifierScore || scores[best] < classifierMargin*second {
+		return "", ""
+	}
+	reason := fmt.Sprintf("classifier, score %.1f", scores[best])
+	if best == "js" && score(tsFeatures, text) >= minClassifierScore/2 {
+		return "ts", reason + ", type annotations"
+	}
+	return best, reason
+}
@@ -0,0 +1,77 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Detect Tests
+ * @tagline         Tests for content-based language detection
+ * @description     Tests the detection corpus in testdata/detect and the detection modes
+ * @file            desktop/bridge/detect_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The detection corpus is in testdata/detect: the extension of each sample is the
+// fileType it must be detected as, txt for samples that must stay undetected
+
+func TestDetectLanguageCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "detect", "*"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No detection corpus found: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		want := strings.TrimPrefix(filepath.Ext(file), ".")
+		got, reason := DetectLanguage(string(data))
+		if got == "" {
+			got = "txt"
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s (%s)", filepath.Base(file), want, got, reason)
+		}
+	}
+}
+
+func TestDetectLanguageCRLFAndBOM(t *testing.T) {
+	code := "\ufeff#!/usr/bin/env python3.12\r\nprint('hi')\r\n"
+	if got, reason := DetectLanguage(code); got != "py" || reason != "shebang" {
+		t.Errorf("Expected py by shebang, got %q (%s)", got, reason)
+	}
+	if got, _ := DetectLanguage("  \n\t\n"); got != "" {
+		t.Errorf("Expected no language for blank code, got %q", got)
+	}
+}
+
+func TestDetectLanguageModes(t *testing.T) {
+	cases := []struct {
+		mode, fileType string
+		want           bool
+	}{
+		{"", "", true},
+		{"auto", "txt", true},
+		{"auto", "Text", true},
+		{"auto", "js", false},
+		{"empty", "", true},
+		{"empty", "txt", false},
+		{"off", "", false},
+		{"bogus", "plain", true},
+	}
+	for _, tc := range cases {
+		if got := ShouldDetect(tc.mode, tc.fileType); got != tc.want {
+			t.Errorf("ShouldDetect(%q, %q) = %v, expected %v", tc.mode, tc.fileType, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Editors
+ * @tagline         Popular editors and their launch conventions
+ * @description     Command names and macOS app names of popular editors, used by editor
+ *                  discovery and by the default launch templates
+ * @file            desktop/bridge/editors.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import "strings"
+
+// KnownEditor lists command names and macOS app names of a popular editor
+type KnownEditor struct {
+	Name     string
+	Commands []string
+	MacApp   string
+}
+
+// KnownEditors are the editors looked for by editor discovery, best candidates first
+var KnownEditors = []KnownEditor{
+	{"Visual Studio Code", []string{"code"}, "Visual Studio Code"},
+	{"VSCodium", []string{"codium"}, "VSCodium"},
+	{"Cursor", []string{"cursor"}, "Cursor"},
+	{"Windsurf", []string{"windsurf"}, "Windsurf"},
+	{"Zed", []string{"zed", "zeditor"}, "Zed"},
+	{"Sublime Text", []string{"subl", "sublime_text"}, "Sublime Text"},
+	{"IntelliJ IDEA", []string{"idea", "idea.sh"}, "IntelliJ IDEA"},
+	{"PyCharm", []string{"pycharm", "pycharm.sh"}, "PyCharm"},
+	{"WebStorm", []string{"webstorm", "webstorm.sh"}, "WebStorm"},
+	{"GoLand", []string{"goland", "goland.sh"}, "GoLand"},
+	{"Nova", nil, "Nova"},
+	{"BBEdit", []string{"bbedit"}, "BBEdit"},
+	{"CotEditor", nil, "CotEditor"},
+	{"Xcode", nil, "Xcode"},
+	{"Notepad++", []string{"notepad++"}, ""},
+	{"Kate", []string{"kate"}, ""},
+	{"KWrite", []string{"kwrite"}, ""},
+	{"GNOME Text Editor", []string{"gnome-text-editor"}, ""},
+	{"gedit", []string{"gedit"}, ""},
+	{"Mousepad", []string{"mousepad"}, ""},
+	{"Pluma", []string{"pluma"}, ""},
+	{"Xed", []string{"xed"}, ""},
+	{"Geany", []string{"geany"}, ""},
+	{"Emacs", []string{"emacs"}, "Emacs"},
+	{"GVim", []string{"gvim"}, "MacVim"},
+	{"TextEdit", nil, "TextEdit"},
+}
+
+// folderEditors open a folder as project together with a file
+var folderEditors = map[string]bool{
+	"Visual Studio Code": true,
+	"VSCodium":           true,
+	"Cursor":             true,
+	"Windsurf":           true,
+	"Zed":                true,
+	"Sublime Text":       true,
+	"IntelliJ IDEA":      true,
+	"PyCharm":            true,
+	"WebStorm":           true,
+	"GoLand":             true,
+}
+
+// OpensFolders reports whether an IDE command is a known editor that opens folders
+func OpensFolders(ideCmd string) bool {
+	name := EditorCommandName(ideCmd)
+	for _, e := range KnownEditors {
+		if !folderEditors[e.Name] {
+			continue
+		}
+		if strings.EqualFold(e.MacApp, name) {
+			return true
+		}
+		for _, cmd := range e.Commands {
+			if strings.TrimSuffix(cmd, ".sh") == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WorkspaceLaunchTemplate returns the default template for snippets in a workspace
+// folder: editors that open folders get the folder and the file, others the file only
+func WorkspaceLaunchTemplate(ideCmd string) string {
+	return LaunchTemplateFor(ideCmd, OpensFolders(ideCmd), false)
+}
@@ -0,0 +1,247 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Encoding
+ * @tagline         Text encoding and line endings of temp files
+ * @description     Converts text to the form exchanged with the browser, UTF-8 with LF line
+ *                  endings and no byte order mark, and back to the encoding, byte order mark
+ *                  and line endings of temp files
+ * @file            desktop/bridge/encoding.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// UTF8BOM is the byte order mark of UTF-8 text
+var UTF8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// NormalizeText converts text to LF line endings and removes a byte order mark
+func NormalizeText(text string) string {
+	text = strings.TrimPrefix(text, string(UTF8BOM))
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// Line ending policies of temp files: "auto" writes LF until the editor saves CRLF
+const (
+	LineEndingsAuto = "auto"
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// Text encodings of temp files: "auto" writes UTF-8, with a byte order mark if the
+// editor saves one
+const (
+	EncodingAuto    = "auto"
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingCP1252  = "windows-1252"
+)
+
+// cp1252High maps the bytes 0x80 to 0x9F of Windows-1252 to runes; 0 marks bytes that
+// are not defined. Other bytes are the same as in Latin-1.
+var cp1252High = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// NormalizeLineEndings maps unknown or empty values to auto
+func NormalizeLineEndings(policy string) string {
+	switch policy {
+	case LineEndingsLF, LineEndingsCRLF:
+		return policy
+	}
+	return LineEndingsAuto
+}
+
+// NormalizeTextEncoding maps unknown or empty values to auto
+func NormalizeTextEncoding(encoding string) string {
+	switch encoding {
+	case EncodingUTF8, EncodingUTF8BOM, EncodingCP1252:
+		return encoding
+	}
+	return EncodingAuto
+}
+
+// TextStyle is how the text of a temp file is stored
+type TextStyle struct {
+	Encoding string // utf-8, utf-8-bom or windows-1252
+	CRLF     bool
+}
+
+// String describes a text style for the activity log
+func (s TextStyle) String() string {
+	if s.CRLF {
+		return s.Encoding + ", CRLF"
+	}
+	return s.Encoding + ", LF"
+}
+
+// InitialTextStyle returns the style of new temp files for the configured policies
+func InitialTextStyle(encoding, lineEndings string) TextStyle {
+	style := TextStyle{Encoding: EncodingUTF8, CRLF: NormalizeLineEndings(lineEndings) == LineEndingsCRLF}
+	switch NormalizeTextEncoding(encoding) {
+	case EncodingUTF8BOM, EncodingCP1252:
+		style.Encoding = NormalizeTextEncoding(encoding)
+	}
+	return style
+}
+
+// TextError reports bytes of a temp file that are not valid in its encoding
+type TextError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *TextError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Diagnostic returns the error as a diagnostic for the browser
+func (e *TextError) Diagnostic() Diagnostic {
+	return Diagnostic{Line: e.Line, Column: e.Column, Severity: SeverityError, Message: e.Message, Source: "encoding"}
+}
+
+// detectCRLF reports whether most line breaks of raw content are CRLF; known is false
+// if there are no line breaks
+func detectCRLF(raw []byte) (crlf bool, known bool) {
+	lines := bytes.Count(raw, []byte("\n"))
+	if lines == 0 {
+		return false, false
+	}
+	crlfs := bytes.Count(raw, []byte("\r\n"))
+	return crlfs*2 > lines, true
+}
+
+// DecodeText converts the content of a temp file to UTF-8 text with LF line endings, and
+// returns the style it was stored in. Content with a byte order mark is UTF-8; other
+// content is Windows-1252 if that is the expected encoding, else it must be valid UTF-8.
+// The line endings of the style are only set if the content has line breaks.
+func DecodeText(raw []byte, encoding string) ([]byte, TextStyle, error) {
+	style := TextStyle{Encoding: EncodingUTF8}
+	style.CRLF, _ = detectCRLF(raw)
+	var text []byte
+	switch {
+	case bytes.HasPrefix(raw, UTF8BOM):
+		style.Encoding = EncodingUTF8BOM
+		text = raw[len(UTF8BOM):]
+		if err := checkUTF8(raw, len(UTF8BOM)); err != nil {
+			return nil, style, err
+		}
+	case encoding == EncodingCP1252:
+		style.Encoding = EncodingCP1252
+		var err error
+		if text, err = decodeCP1252(raw); err != nil {
+			return nil, style, err
+		}
+	default:
+		if err := checkUTF8(raw, 0); err != nil {
+			return nil, style, err
+		}
+		text = raw
+	}
+	return []byte(NormalizeText(string(text))), style, nil
+}
+
+// checkUTF8 returns the position of the first invalid UTF-8 sequence after start
+func checkUTF8(raw []byte, start int) error {
+	for i := start; i < len(raw); {
+		r, size := utf8.DecodeRune(raw[i:])
+		if r == utf8.RuneError && size <= 1 {
+			line, column := LineColumn(raw[start:], int64(i-start))
+			return &TextError{Line: line, Column: column,
+				Message: fmt.Sprintf("invalid UTF-8 byte 0x%02X; if the editor saves Windows-1252, set text_encoding to windows-1252", raw[i])}
+		}
+		i += size
+	}
+	return nil
+}
+
+// decodeCP1252 converts Windows-1252 to UTF-8
+func decodeCP1252(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(raw))
+	for i, b := range raw {
+		switch {
+		case b < 0x80 || b >= 0xA0:
+			buf.WriteRune(rune(b))
+		case cp1252High[b-0x80] != 0:
+			buf.WriteRune(cp1252High[b-0x80])
+		default:
+			line, column := LineColumn(raw, int64(i))
+			return nil, &TextError{Line: line, Column: column, Message: fmt.Sprintf("byte 0x%02X is not defined in Windows-1252", b)}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCP1252 converts UTF-8 to Windows-1252; ok is false if the text has characters
+// that Windows-1252 cannot represent
+func encodeCP1252(text []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(text))
+	for _, r := range string(text) {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		default:
+			i := 0
+			for i < len(cp1252High) && cp1252High[i] != r {
+				i++
+			}
+			if i == len(cp1252High) {
+				return nil, false
+			}
+			out = append(out, byte(0x80+i))
+		}
+	}
+	return out, true
+}
+
+// EncodeText converts UTF-8 text with LF line endings to the bytes of a temp file in a
+// style. Text that Windows-1252 cannot represent is written as UTF-8; the returned style
+// is the one used.
+func EncodeText(text []byte, style TextStyle) ([]byte, TextStyle) {
+	if style.CRLF {
+		text = bytes.ReplaceAll(text, []byte("\n"), []byte("\r\n"))
+	}
+	switch style.Encoding {
+	case EncodingUTF8BOM:
+		return append(append([]byte{}, UTF8BOM...), text...), style
+	case EncodingCP1252:
+		if out, ok := encodeCP1252(text); ok {
+			return out, style
+		}
+		style.Encoding = EncodingUTF8
+	}
+	return text, style
+}
+
+// SavedTextStyle returns the style of later writes after the editor saved raw content
+// in the saved style. With the auto policies, the byte order mark and line endings of the
+// save are kept, so that files are written in the editor's style.
+func SavedTextStyle(current, saved TextStyle, raw []byte, encoding, lineEndings string) TextStyle {
+	style := current
+	if NormalizeTextEncoding(encoding) == EncodingAuto {
+		style.Encoding = saved.Encoding
+	}
+	if _, known := detectCRLF(raw); known && NormalizeLineEndings(lineEndings) == LineEndingsAuto {
+		style.CRLF = saved.CRLF
+	}
+	return style
+}
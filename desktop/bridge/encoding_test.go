@@ -0,0 +1,166 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Encoding Tests
+ * @tagline         Tests for the text encoding and line endings of temp files
+ * @description     Tests decoding saved temp files with CRLF line endings, a byte order mark
+ *                  or Windows-1252, the position of invalid bytes, and encoding text back in
+ *                  the style of the temp file
+ * @file            desktop/bridge/encoding_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := map[string]string{
+		"a\nb\n":             "a\nb\n",
+		"a\r\nb\r\n":         "a\nb\n",
+		"a\rb":               "a\nb",
+		"\xEF\xBB\xBFa\r\nb": "a\nb",
+		"\xEF\xBB\xBF":       "",
+	}
+	for in, want := range tests {
+		if got := NormalizeText(in); got != want {
+			t.Errorf("NormalizeText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizePolicies(t *testing.T) {
+	if NormalizeLineEndings("crlf") != LineEndingsCRLF || NormalizeLineEndings("CRLF") != LineEndingsAuto || NormalizeLineEndings("") != LineEndingsAuto {
+		t.Error("unexpected line ending policy")
+	}
+	if NormalizeTextEncoding("windows-1252") != EncodingCP1252 || NormalizeTextEncoding("latin1") != EncodingAuto || NormalizeTextEncoding("") != EncodingAuto {
+		t.Error("unexpected text encoding")
+	}
+}
+
+func TestInitialTextStyle(t *testing.T) {
+	tests := []struct {
+		encoding, lineEndings string
+		want                  TextStyle
+	}{
+		{"", "", TextStyle{Encoding: EncodingUTF8}},
+		{"auto", "auto", TextStyle{Encoding: EncodingUTF8}},
+		{"utf-8-bom", "crlf", TextStyle{Encoding: EncodingUTF8BOM, CRLF: true}},
+		{"windows-1252", "lf", TextStyle{Encoding: EncodingCP1252}},
+		{"ebcdic", "cr", TextStyle{Encoding: EncodingUTF8}},
+	}
+	for _, tt := range tests {
+		if got := InitialTextStyle(tt.encoding, tt.lineEndings); got != tt.want {
+			t.Errorf("InitialTextStyle(%q, %q) = %+v, want %+v", tt.encoding, tt.lineEndings, got, tt.want)
+		}
+	}
+	if s := (TextStyle{Encoding: EncodingUTF8BOM, CRLF: true}).String(); s != "utf-8-bom, CRLF" {
+		t.Errorf("unexpected description %q", s)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name, raw, encoding, want string
+		style                     TextStyle
+	}{
+		{"utf-8", "héllo\nworld\n", EncodingUTF8, "héllo\nworld\n", TextStyle{Encoding: EncodingUTF8}},
+		{"crlf", "a\r\nb\r\nc", EncodingUTF8, "a\nb\nc", TextStyle{Encoding: EncodingUTF8, CRLF: true}},
+		{"mostly lf", "a\r\nb\nc\n", EncodingUTF8, "a\nb\nc\n", TextStyle{Encoding: EncodingUTF8}},
+		{"bom", "\xEF\xBB\xBFa\r\nb\r\n", EncodingUTF8, "a\nb\n", TextStyle{Encoding: EncodingUTF8BOM, CRLF: true}},
+		{"bom wins over cp1252", "\xEF\xBB\xBFé", EncodingCP1252, "é", TextStyle{Encoding: EncodingUTF8BOM}},
+		{"cp1252", "caf\xE9 \x80 \x96\r\n", EncodingCP1252, "café € –\n", TextStyle{Encoding: EncodingCP1252, CRLF: true}},
+	}
+	for _, tt := range tests {
+		text, style, err := DecodeText([]byte(tt.raw), tt.encoding)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if string(text) != tt.want || style != tt.style {
+			t.Errorf("%s: got %q %+v, want %q %+v", tt.name, text, style, tt.want, tt.style)
+		}
+	}
+}
+
+func TestDecodeTextInvalid(t *testing.T) {
+	tests := []struct {
+		name, raw, encoding string
+		line, column        int
+	}{
+		{"invalid utf-8", "ok\ncaf\xE9\n", EncodingUTF8, 2, 4},
+		{"invalid after bom", "\xEF\xBB\xBFab\xFF", EncodingUTF8, 1, 3},
+		{"undefined in cp1252", "a\nb\r\n  \x81", EncodingCP1252, 3, 3},
+	}
+	for _, tt := range tests {
+		_, _, err := DecodeText([]byte(tt.raw), tt.encoding)
+		var textErr *TextError
+		if !errors.As(err, &textErr) {
+			t.Errorf("%s: expected a text error, got %v", tt.name, err)
+			continue
+		}
+		if textErr.Line != tt.line || textErr.Column != tt.column {
+			t.Errorf("%s: got line %d, column %d, want %d, %d", tt.name, textErr.Line, textErr.Column, tt.line, tt.column)
+		}
+		d := textErr.Diagnostic()
+		if d.Line != tt.line || d.Column != tt.column || d.Severity != SeverityError || d.Source != "encoding" {
+			t.Errorf("%s: unexpected diagnostic %+v", tt.name, d)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		name, text string
+		style      TextStyle
+		want       string
+		used       TextStyle
+	}{
+		{"utf-8", "a\nb\n", TextStyle{Encoding: EncodingUTF8}, "a\nb\n", TextStyle{Encoding: EncodingUTF8}},
+		{"crlf", "a\nb\n", TextStyle{Encoding: EncodingUTF8, CRLF: true}, "a\r\nb\r\n", TextStyle{Encoding: EncodingUTF8, CRLF: true}},
+		{"bom", "é\n", TextStyle{Encoding: EncodingUTF8BOM}, "\xEF\xBB\xBFé\n", TextStyle{Encoding: EncodingUTF8BOM}},
+		{"cp1252", "café €\n", TextStyle{Encoding: EncodingCP1252, CRLF: true}, "caf\xE9 \x80\r\n", TextStyle{Encoding: EncodingCP1252, CRLF: true}},
+		{"cp1252 fallback", "ok 🙂\n", TextStyle{Encoding: EncodingCP1252}, "ok 🙂\n", TextStyle{Encoding: EncodingUTF8}},
+	}
+	for _, tt := range tests {
+		data, used := EncodeText([]byte(tt.text), tt.style)
+		if string(data) != tt.want || used != tt.used {
+			t.Errorf("%s: got %q %+v, want %q %+v", tt.name, data, used, tt.want, tt.used)
+		}
+		text, style, err := DecodeText(data, used.Encoding)
+		if err != nil || string(text) != tt.text || style != used {
+			t.Errorf("%s: round trip gave %q %+v %v", tt.name, text, style, err)
+		}
+	}
+	text := []byte("a\nb\n")
+	if EncodeText(text, TextStyle{Encoding: EncodingUTF8BOM, CRLF: true}); !bytes.Equal(text, []byte("a\nb\n")) {
+		t.Error("text was changed in place")
+	}
+}
+
+func TestSavedTextStyle(t *testing.T) {
+	current := TextStyle{Encoding: EncodingUTF8}
+	saved := TextStyle{Encoding: EncodingUTF8BOM, CRLF: true}
+	raw := []byte("\xEF\xBB\xBFa\r\n")
+	if got := SavedTextStyle(current, saved, raw, EncodingAuto, LineEndingsAuto); got != saved {
+		t.Errorf("auto policies: got %+v", got)
+	}
+	if got := SavedTextStyle(current, saved, raw, EncodingUTF8, LineEndingsLF); got != current {
+		t.Errorf("fixed policies: got %+v", got)
+	}
+	if got := SavedTextStyle(current, saved, raw, EncodingAuto, LineEndingsLF); got != (TextStyle{Encoding: EncodingUTF8BOM}) {
+		t.Errorf("fixed line endings: got %+v", got)
+	}
+	crlf := TextStyle{Encoding: EncodingUTF8, CRLF: true}
+	if got := SavedTextStyle(crlf, TextStyle{Encoding: EncodingUTF8}, []byte("one line"), EncodingAuto, LineEndingsAuto); got != crlf {
+		t.Errorf("save without line breaks changed the line endings: %+v", got)
+	}
+}
@@ -0,0 +1,70 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Events
+ * @tagline         Edit session events for the browser
+ * @description     Builds edit_event messages, and queues events raised while disconnected
+ *                  until the connection to their server is back
+ * @file            desktop/bridge/events.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import "time"
+
+// EditEvent returns an edit_event message without the fields of its session: edit_started,
+// launch_failed, editor_closed, watch_stopped, file_kept, merge_conflict, update_rejected,
+// send_failed, format_failed or validation_failed
+func EditEvent(connectionId, userId, event, reason string, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"type":         "edit_event",
+		"connectionId": connectionId,
+		"userId":       userId,
+		"event":        event,
+		"reason":       reason,
+		"timestamp":    now.UnixMilli(),
+	}
+}
+
+// MaxPendingEvents is the number of edit events kept while disconnected
+const MaxPendingEvents = 100
+
+// pendingEvent is an edit event waiting for a connection to its server
+type pendingEvent struct {
+	server string
+	data   []byte
+}
+
+// EventQueue holds edit events raised while disconnected; the oldest events are dropped
+// beyond MaxPendingEvents. The caller guards the queue.
+type EventQueue struct {
+	events []pendingEvent
+}
+
+// Add queues an encoded event for a server
+func (q *EventQueue) Add(server string, data []byte) {
+	if len(q.events) >= MaxPendingEvents {
+		q.events = q.events[1:]
+	}
+	q.events = append(q.events, pendingEvent{server: server, data: data})
+}
+
+// Take removes and returns the events of a server, oldest first
+func (q *EventQueue) Take(server string) [][]byte {
+	var pending [][]byte
+	var keep []pendingEvent
+	for _, e := range q.events {
+		if e.server == server {
+			pending = append(pending, e.data)
+		} else {
+			keep = append(keep, e)
+		}
+	}
+	q.events = keep
+	return pending
+}
@@ -0,0 +1,87 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Events Tests
+ * @tagline         Tests for edit session events
+ * @description     Tests the edit_event message, and the queue of events raised while
+ *                  disconnected
+ * @file            desktop/bridge/events_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEditEvent(t *testing.T) {
+	now := time.UnixMilli(1724400000123)
+	msg := EditEvent("conn-1", "user-1", "launch_failed", "exec: \"nope\": executable file not found", now)
+	msg["snippetId"] = "code"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"type":         "edit_event",
+		"connectionId": "conn-1",
+		"userId":       "user-1",
+		"snippetId":    "code",
+		"event":        "launch_failed",
+		"reason":       "exec: \"nope\": executable file not found",
+		"timestamp":    float64(1724400000123),
+	}
+	if len(got) != len(want) {
+		t.Errorf("got fields %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestEventQueueTakeByServer(t *testing.T) {
+	var q EventQueue
+	q.Add("ws://a", []byte("a1"))
+	q.Add("ws://b", []byte("b1"))
+	q.Add("ws://a", []byte("a2"))
+	if got := fmt.Sprintf("%s", q.Take("ws://a")); got != "[a1 a2]" {
+		t.Errorf("Take(a) = %s, want [a1 a2]", got)
+	}
+	if got := q.Take("ws://a"); len(got) != 0 {
+		t.Errorf("events of a sent twice: %s", got)
+	}
+	if got := fmt.Sprintf("%s", q.Take("ws://b")); got != "[b1]" {
+		t.Errorf("Take(b) = %s, want [b1]", got)
+	}
+	if got := q.Take("ws://c"); got != nil {
+		t.Errorf("Take(c) = %s, want none", got)
+	}
+}
+
+func TestEventQueueDropsOldest(t *testing.T) {
+	var q EventQueue
+	for i := 0; i < MaxPendingEvents+5; i++ {
+		q.Add("ws://a", []byte(fmt.Sprint(i)))
+	}
+	got := q.Take("ws://a")
+	if len(got) != MaxPendingEvents {
+		t.Fatalf("kept %d events, want %d", len(got), MaxPendingEvents)
+	}
+	if string(got[0]) != "5" || string(got[len(got)-1]) != fmt.Sprint(MaxPendingEvents+4) {
+		t.Errorf("kept events %s to %s, want the newest", got[0], got[len(got)-1])
+	}
+}
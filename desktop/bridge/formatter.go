@@ -0,0 +1,122 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Formatter
+ * @tagline         Outbound formatter pipeline for saved snippets
+ * @description     Runs external formatter commands on saved snippets in order, each on
+ *                  the output of the previous one, before the code is sent to the browser
+ * @file            desktop/bridge/formatter.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultToolTimeoutMs is the time a formatter or validator may run if none is configured
+const DefaultToolTimeoutMs = 10000
+
+// Formatter is one step of the outbound formatter pipeline. The command reads the code
+// on stdin and writes the formatted code to stdout. Placeholders: {file} (the temp file,
+// for formatters that pick options by file name), {dir}, {fileType}, {snippetId}
+type Formatter struct {
+	Pattern   string `json:"pattern"`              // fileTypes or file name globs, as in IDE mappings
+	Command   string `json:"command"`              // e.g. `prettier --stdin-filepath {file}`
+	TimeoutMs int    `json:"timeout_ms,omitempty"` // defaults to 10 seconds
+}
+
+// ToolTimeout returns the time a formatter or validator may run
+func ToolTimeout(ms int) time.Duration {
+	if ms <= 0 {
+		ms = DefaultToolTimeoutMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Run formats code with the formatter command
+func (f Formatter) Run(code []byte, key SessionKey, tmpFile, fileType string) ([]byte, error) {
+	stdout, stderr, err := RunTool(f.Command, ToolTimeout(f.TimeoutMs), code, key, tmpFile, fileType)
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			if len(msg) > 200 {
+				msg = msg[:200] + "..."
+			}
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	if len(stdout) == 0 && len(bytes.TrimSpace(code)) > 0 {
+		// An empty result is more likely a formatter writing to a file than empty code
+		return nil, fmt.Errorf("no output on stdout")
+	}
+	return stdout, nil
+}
+
+// RunTool runs an external formatter or validator command with code on stdin, in the
+// folder of the temp file so that it finds workspace config files. Placeholders: {file},
+// {dir}, {fileType}, {snippetId}. A non-zero exit returns the output and an *exec.ExitError.
+func RunTool(command string, timeout time.Duration, code []byte, key SessionKey, tmpFile, fileType string) ([]byte, []byte, error) {
+	words, err := SplitCommandLine(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("command is empty")
+	}
+	replacer := strings.NewReplacer(
+		"{file}", tmpFile,
+		"{dir}", filepath.Dir(tmpFile),
+		"{fileType}", fileType,
+		"{snippetId}", key.SnippetID,
+	)
+	for i, word := range words {
+		words[i] = replacer.Replace(word)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd.Dir = filepath.Dir(tmpFile)
+	cmd.Stdin = bytes.NewReader(code)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Do not wait for child processes that keep the output pipes open after a timeout
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, nil, fmt.Errorf("timed out after %v", timeout)
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// Format runs the formatters that match a snippet in order, each on the output of the
+// previous one. A failing formatter is skipped and passed to failed, so it never blocks
+// the sync. Returns the formatted code.
+func Format(formatters []Formatter, code []byte, key SessionKey, tmpFile, fileType string, failed func(Formatter, error)) []byte {
+	fileName := filepath.Base(tmpFile)
+	for _, f := range formatters {
+		if !MatchesFileType(f.Pattern, fileType, fileName) {
+			continue
+		}
+		formatted, err := f.Run(code, key, tmpFile, fileType)
+		if err != nil {
+			failed(f, err)
+			continue
+		}
+		code = formatted
+	}
+	return code
+}
@@ -0,0 +1,118 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Formatter Tests
+ * @tagline         Tests for the outbound formatter pipeline
+ * @description     Tests that matching formatters run in order on the output of the previous
+ *                  one, with placeholders and timeouts, and that failing formatters are skipped
+ * @file            desktop/bridge/formatter_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// skipWithoutShell skips tests that run POSIX tools as formatters and validators
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("formatter commands of this test need a POSIX shell")
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	if got := ToolTimeout(0); got != DefaultToolTimeoutMs*time.Millisecond {
+		t.Errorf("ToolTimeout(0) = %v, want the default", got)
+	}
+	if got := ToolTimeout(250); got != 250*time.Millisecond {
+		t.Errorf("ToolTimeout(250) = %v", got)
+	}
+}
+
+func TestFormatPipeline(t *testing.T) {
+	skipWithoutShell(t)
+	tmpFile := filepath.Join(t.TempDir(), "code.js")
+	key := SessionKey{SnippetID: "code"}
+	formatters := []Formatter{
+		{Pattern: "js", Command: "tr a-z A-Z"},
+		{Pattern: "css", Command: "tr A-Z x"}, // does not match
+		{Pattern: "*.js", Command: "sed s/LET/const/"},
+		{Pattern: "js", Command: "sh -c 'echo bad input >&2; exit 2'"},
+		{Pattern: "js", Command: "no-such-formatter-web-ide-bridge"},
+		{Pattern: "js", Command: "true"}, // writes to a file instead of stdout
+		{Pattern: "js", Command: "sed 's/$/;/'"},
+	}
+	var failed []string
+	code := Format(formatters, []byte("let a = 1\n"), key, tmpFile, "js", func(f Formatter, err error) {
+		failed = append(failed, f.Command+": "+err.Error())
+	})
+	if string(code) != "const A = 1;\n" {
+		t.Errorf("formatted code %q", code)
+	}
+	if len(failed) != 3 {
+		t.Fatalf("failed formatters %q", failed)
+	}
+	if !strings.Contains(failed[0], "exit status 2: bad input") {
+		t.Errorf("stderr not reported: %s", failed[0])
+	}
+	if !strings.Contains(failed[2], "no output on stdout") {
+		t.Errorf("empty output not reported: %s", failed[2])
+	}
+
+	// Nothing matches: the code is unchanged
+	code = Format(formatters, []byte("a {}\n"), key, filepath.Join(t.TempDir(), "style.scss"), "scss", func(Formatter, error) {
+		t.Error("formatter for another fileType ran")
+	})
+	if string(code) != "a {}\n" {
+		t.Errorf("unmatched code changed: %q", code)
+	}
+}
+
+func TestRunToolPlaceholders(t *testing.T) {
+	skipWithoutShell(t)
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "code.js")
+	stdout, _, err := RunTool("sh -c 'printf \"%s|%s|%s|%s|\" \"$0\" \"$1\" \"$2\" \"$3\"; pwd' {file} {dir} {fileType} {snippetId}",
+		time.Second, nil, SessionKey{SnippetID: "my code"}, tmpFile, "js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(stdout)), "|")
+	if len(parts) != 5 || parts[0] != tmpFile || parts[1] != dir || parts[2] != "js" || parts[3] != "my code" {
+		t.Fatalf("placeholders %q", parts)
+	}
+	// The tool runs in the folder of the temp file, to find workspace config files
+	if real, _ := filepath.EvalSymlinks(dir); parts[4] != dir && parts[4] != real {
+		t.Errorf("working directory %s, want %s", parts[4], dir)
+	}
+}
+
+func TestRunToolErrors(t *testing.T) {
+	skipWithoutShell(t)
+	tmpFile := filepath.Join(t.TempDir(), "code.js")
+	key := SessionKey{SnippetID: "code"}
+	start := time.Now()
+	if _, _, err := RunTool("sleep 5", 100*time.Millisecond, nil, key, tmpFile, "js"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow tool: %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("timeout took %v", time.Since(start))
+	}
+	if _, _, err := RunTool("  ", time.Second, nil, key, tmpFile, "js"); err == nil {
+		t.Error("empty command accepted")
+	}
+	if _, _, err := RunTool("tr 'a", time.Second, nil, key, tmpFile, "js"); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
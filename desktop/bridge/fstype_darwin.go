@@ -0,0 +1,47 @@
+//go:build darwin
+
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Filesystem Type
+ * @tagline         Network filesystem detection for macOS
+ * @description     Detects NFS, SMB, AFP, WebDAV and FUSE mounts where FSEvents are not delivered
+ * @file            desktop/bridge/fstype_darwin.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import "syscall"
+
+// Filesystem type names from statfs(2) that do not deliver FSEvents reliably
+var networkFilesystems = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"cifs":    true,
+	"osxfuse": true,
+	"macfuse": true,
+}
+
+// isNetworkFilesystem reports whether path is on a network or bind-mounted filesystem
+func isNetworkFilesystem(path string) (bool, string) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false, ""
+	}
+	b := make([]byte, 0, len(st.Fstypename))
+	for _, ch := range st.Fstypename {
+		if ch == 0 {
+			break
+		}
+		b = append(b, byte(ch))
+	}
+	name := string(b)
+	return networkFilesystems[name], name
+}
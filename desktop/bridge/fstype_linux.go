@@ -0,0 +1,43 @@
+//go:build linux
+
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Filesystem Type
+ * @tagline         Network filesystem detection for Linux
+ * @description     Detects NFS, SMB, FUSE and 9p mounts where inotify events are not delivered
+ * @file            desktop/bridge/fstype_linux.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import "syscall"
+
+// Filesystem magic numbers from statfs(2) that do not deliver inotify events reliably
+var networkFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x01021997: "9p",
+	0x564c:     "ncp",
+	0x5346414f: "afs",
+	0x73757245: "coda",
+	0x00c36400: "ceph",
+}
+
+// isNetworkFilesystem reports whether path is on a network or bind-mounted filesystem
+func isNetworkFilesystem(path string) (bool, string) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false, ""
+	}
+	name, ok := networkFilesystems[uint32(st.Type)]
+	return ok, name
+}
@@ -0,0 +1,23 @@
+//go:build !linux && !darwin
+
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Filesystem Type
+ * @tagline         Network filesystem detection fallback
+ * @description     Platforms without filesystem type detection rely on the auto watch mode
+ *                  to switch to polling when change events are not delivered
+ * @file            desktop/bridge/fstype_other.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+// isNetworkFilesystem is not detected on this platform
+func isNetworkFilesystem(path string) (bool, string) {
+	return false, ""
+}
@@ -0,0 +1,180 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Guard
+ * @tagline         Guarded read-only context around fragment snippets
+ * @description     Writes read-only prefix and suffix code around fragment snippets between
+ *                  guard comments, strips it before the code is sent to the browser, and
+ *                  repairs or rejects saves that changed the context or the guards
+ * @file            desktop/bridge/guard.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// guardMarker identifies guard comment lines, also when they were edited
+const guardMarker = "Web-IDE-Bridge: read-only context"
+
+// Text of the guard comments before and after the snippet
+const (
+	guardBeginText = "==== " + guardMarker + " above, edit the snippet below ===="
+	guardEndText   = "==== " + guardMarker + " below, edit the snippet above ===="
+)
+
+// SnippetContext is the read-only code around a fragment snippet, from an edit_request
+type SnippetContext struct {
+	Prefix string
+	Suffix string
+}
+
+// ParseSnippetContext reads the optional contextPrefix and contextSuffix of an edit_request
+func ParseSnippetContext(m map[string]interface{}) SnippetContext {
+	prefix, _ := m["contextPrefix"].(string)
+	suffix, _ := m["contextSuffix"].(string)
+	return SnippetContext{Prefix: NormalizeText(prefix), Suffix: NormalizeText(suffix)}
+}
+
+// lineComments are the comment delimiters of guards by fileType; other fileTypes use //
+var lineComments = map[string][2]string{
+	"py": {"#", ""}, "python": {"#", ""}, "sh": {"#", ""}, "bash": {"#", ""}, "zsh": {"#", ""},
+	"rb": {"#", ""}, "ruby": {"#", ""}, "pl": {"#", ""}, "perl": {"#", ""}, "r": {"#", ""},
+	"yaml": {"#", ""}, "yml": {"#", ""}, "toml": {"#", ""}, "ps1": {"#", ""}, "conf": {"#", ""},
+	"sql": {"--", ""}, "lua": {"--", ""}, "hs": {"--", ""}, "haskell": {"--", ""},
+	"lisp": {";", ""}, "clj": {";", ""}, "el": {";", ""}, "scm": {";", ""}, "ini": {";", ""},
+	"tex": {"%", ""}, "latex": {"%", ""}, "erl": {"%", ""},
+	"html": {"<!--", "-->"}, "htm": {"<!--", "-->"}, "xml": {"<!--", "-->"}, "svg": {"<!--", "-->"},
+	"xsl": {"<!--", "-->"}, "xslt": {"<!--", "-->"}, "xsd": {"<!--", "-->"}, "vue": {"<!--", "-->"},
+	"md": {"<!--", "-->"}, "markdown": {"<!--", "-->"},
+	"css": {"/*", "*/"},
+	"hbs": {"{{!--", "--}}"}, "handlebars": {"{{!--", "--}}"}, "mustache": {"{{!", "}}"},
+	"j2": {"{#", "#}"}, "jinja": {"{#", "#}"}, "jinja2": {"{#", "#}"}, "njk": {"{#", "#}"},
+	"erb": {"<%#", "%>"},
+}
+
+// guardComment returns a guard comment line for a fileType, without newline
+func guardComment(fileType, text string) string {
+	delims, ok := lineComments[strings.ToLower(fileType)]
+	if !ok {
+		delims = [2]string{"//", ""}
+	}
+	if delims[1] == "" {
+		return delims[0] + " " + text
+	}
+	return delims[0] + " " + text + " " + delims[1]
+}
+
+// SnippetGuard is the read-only context of an edit session, as written to the temp file:
+// the prefix and its guard comment above the snippet, the guard comment and the suffix
+// below it. The line break before the closing guard is part of the guard, so that code
+// without a final newline keeps it.
+type SnippetGuard struct {
+	prefix string // context above the snippet, with a final newline, empty if none
+	suffix string // context below the snippet, empty if none
+	begin  string // guard comment line above the snippet, without newline
+	end    string // guard comment line below the snippet, without newline
+}
+
+// NewSnippetGuard returns the guard of a snippet context for a fileType, or nil if
+// there is no context
+func NewSnippetGuard(ctx SnippetContext, fileType string) *SnippetGuard {
+	if ctx.Prefix == "" && ctx.Suffix == "" {
+		return nil
+	}
+	g := &SnippetGuard{suffix: ctx.Suffix}
+	if ctx.Prefix != "" {
+		g.prefix = ctx.Prefix
+		if !strings.HasSuffix(g.prefix, "\n") {
+			g.prefix += "\n"
+		}
+		g.begin = guardComment(fileType, guardBeginText)
+	}
+	if ctx.Suffix != "" {
+		g.end = guardComment(fileType, guardEndText)
+	}
+	return g
+}
+
+// above returns the content of the temp file above the snippet
+func (g SnippetGuard) above() string {
+	if g.prefix == "" {
+		return ""
+	}
+	return g.prefix + g.begin + "\n"
+}
+
+// below returns the content of the temp file below the snippet
+func (g SnippetGuard) below() string {
+	if g.suffix == "" {
+		return ""
+	}
+	return "\n" + g.end + "\n" + g.suffix
+}
+
+// Lines returns the number of lines above the snippet, to move cursor positions
+func (g SnippetGuard) Lines() int {
+	return strings.Count(g.above(), "\n")
+}
+
+// Wrap returns the temp file content for snippet code
+func (g SnippetGuard) Wrap(code string) string {
+	return g.above() + code + g.below()
+}
+
+// Unwrap returns the snippet code of temp file content. If the context was changed but
+// the guard comments are intact, the code between them is returned with repaired set, so
+// that the file is written again with the original context. If the guards were changed,
+// the code is only returned if the context is intact and no guard remains in the code.
+func (g SnippetGuard) Unwrap(content string) (string, bool, error) {
+	var begins, ends [][2]int
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		text := strings.TrimRight(line, " \t\r\n")
+		if g.begin != "" && text == g.begin {
+			begins = append(begins, [2]int{offset, offset + len(line)})
+		}
+		if g.end != "" && text == g.end {
+			ends = append(ends, [2]int{offset, offset + len(line)})
+		}
+		offset += len(line)
+	}
+	intact := (g.begin == "" || len(begins) == 1) && (g.end == "" || len(ends) == 1) &&
+		(g.begin == "" || g.end == "" || begins[0][1] <= ends[0][0])
+	if intact {
+		start, stop := 0, len(content)
+		if g.begin != "" {
+			start = begins[0][1]
+		}
+		if g.end != "" {
+			stop = ends[0][0]
+		}
+		code := content[start:stop]
+		if g.end != "" {
+			code = strings.TrimSuffix(code, "\n")
+		}
+		return code, g.Wrap(code) != content, nil
+	}
+
+	// Guards were changed or removed, accept the code only if nothing else was
+	above, below := g.above(), g.below()
+	if len(content) >= len(g.prefix)+len(g.suffix) && strings.HasPrefix(content, g.prefix) && strings.HasSuffix(content, g.suffix) {
+		code := content[len(g.prefix) : len(content)-len(g.suffix)]
+		code = strings.TrimPrefix(code, strings.TrimPrefix(above, g.prefix))
+		code = strings.TrimSuffix(code, strings.TrimSuffix(below, g.suffix))
+		if g.end != "" {
+			code = strings.TrimSuffix(code, "\n")
+		}
+		if !strings.Contains(code, guardMarker) {
+			return code, true, nil
+		}
+	}
+	return "", false, fmt.Errorf("the guard comments around the snippet were changed or removed; restore them, or open the snippet again from the browser")
+}
@@ -0,0 +1,131 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Guard Tests
+ * @tagline         Tests for the guarded read-only context of fragment snippets
+ * @description     Tests wrapping snippets in their context, and that saves with a changed
+ *                  context are repaired and saves with changed guards are rejected
+ * @file            desktop/bridge/guard_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSnippetContext(t *testing.T) {
+	ctx := ParseSnippetContext(map[string]interface{}{"contextPrefix": "function f() {\r\n", "contextSuffix": 42})
+	if ctx.Prefix != "function f() {\n" || ctx.Suffix != "" {
+		t.Errorf("unexpected context %+v", ctx)
+	}
+	if NewSnippetGuard(SnippetContext{}, "js") != nil {
+		t.Error("guard without context")
+	}
+}
+
+func TestSnippetGuardWrap(t *testing.T) {
+	g := NewSnippetGuard(SnippetContext{Prefix: "function f() {", Suffix: "}\n"}, "js")
+	content := g.Wrap("  return 1;")
+	want := "function f() {\n" +
+		"// ==== Web-IDE-Bridge: read-only context above, edit the snippet below ====\n" +
+		"  return 1;\n" +
+		"// ==== Web-IDE-Bridge: read-only context below, edit the snippet above ====\n" +
+		"}\n"
+	if content != want {
+		t.Errorf("wrapped content\n%s\nwant\n%s", content, want)
+	}
+	if g.Lines() != 2 {
+		t.Errorf("Lines() = %d, want 2", g.Lines())
+	}
+
+	// Comment delimiters by fileType
+	tests := map[string]string{
+		"py":   "# ==== Web-IDE-Bridge",
+		"SQL":  "-- ==== Web-IDE-Bridge",
+		"html": "<!-- ==== Web-IDE-Bridge",
+		"css":  "/* ==== Web-IDE-Bridge",
+		"hbs":  "{{!-- ==== Web-IDE-Bridge",
+		"go":   "// ==== Web-IDE-Bridge",
+	}
+	for fileType, begin := range tests {
+		g := NewSnippetGuard(SnippetContext{Prefix: "a\n"}, fileType)
+		if content := g.Wrap("b"); !strings.HasPrefix(content, "a\n"+begin) || content[len(content)-1] != 'b' {
+			t.Errorf("%s: %q", fileType, content)
+		}
+	}
+}
+
+func TestSnippetGuardUnwrap(t *testing.T) {
+	prefix := "function f() {\n"
+	suffix := "}\n"
+	begin := "// ==== Web-IDE-Bridge: read-only context above, edit the snippet below ===="
+	end := "// ==== Web-IDE-Bridge: read-only context below, edit the snippet above ===="
+	g := NewSnippetGuard(SnippetContext{Prefix: prefix, Suffix: suffix}, "js")
+	tests := []struct {
+		name     string
+		content  string
+		code     string
+		repaired bool
+		rejected bool
+	}{
+		{"unchanged", prefix + begin + "\n  return 1;\n" + end + "\n" + suffix, "  return 1;", false, false},
+		{"snippet edited", prefix + begin + "\n  return 2;\n  // more\n" + end + "\n" + suffix, "  return 2;\n  // more", false, false},
+		{"snippet with final newline", prefix + begin + "\n  return 1;\n\n" + end + "\n" + suffix, "  return 1;\n", false, false},
+		{"empty snippet", prefix + begin + "\n\n" + end + "\n" + suffix, "", false, false},
+		{"trailing spaces after guards", prefix + begin + "  \r\n  return 1;\n" + end + "\t\n" + suffix, "  return 1;", true, false},
+		// Changes to the context are repaired with the guards intact
+		{"prefix changed", "function g() {\n" + begin + "\n  return 1;\n" + end + "\n" + suffix, "  return 1;", true, false},
+		{"suffix changed", prefix + begin + "\n  return 1;\n" + end + "\n}\nf();\n", "  return 1;", true, false},
+		// Changed guards are accepted only if the context is intact and no guard remains
+		{"begin guard removed", prefix + "  return 1;\n" + end + "\n" + suffix, "  return 1;", true, false},
+		{"both guards edited", prefix + "// edited\n  return 1;\n// edited\n" + suffix, "// edited\n  return 1;\n// edited", true, false},
+		{"begin guard edited in place", prefix + "// ==== Web-IDE-Bridge: read-only context above, edit below\n  return 1;\n" + end + "\n" + suffix, "", false, true},
+		{"guard duplicated", prefix + begin + "\n" + begin + "\n  return 1;\n" + end + "\n" + suffix, "", false, true},
+		{"guards swapped", prefix + end + "\n  return 1;\n" + begin + "\n" + suffix, "", false, true},
+		{"guard and context removed", "  return 1;\n" + end + "\n" + suffix, "", false, true},
+		{"everything removed", "", "", false, true},
+	}
+	for _, tt := range tests {
+		code, repaired, err := g.Unwrap(tt.content)
+		if tt.rejected {
+			if err == nil {
+				t.Errorf("%s: accepted %q", tt.name, code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if code != tt.code || repaired != tt.repaired {
+			t.Errorf("%s: code %q, repaired %v, want %q, %v", tt.name, code, repaired, tt.code, tt.repaired)
+		}
+		// Repaired files are written again with the original context
+		if repaired && !strings.HasPrefix(g.Wrap(code), prefix+begin+"\n") {
+			t.Errorf("%s: context not restored", tt.name)
+		}
+	}
+}
+
+func TestSnippetGuardPrefixOrSuffixOnly(t *testing.T) {
+	above := NewSnippetGuard(SnippetContext{Prefix: "<?php\n"}, "php")
+	content := above.Wrap("echo 1;\n")
+	if code, repaired, err := above.Unwrap(content + "echo 2;\n"); err != nil || repaired || code != "echo 1;\necho 2;\n" {
+		t.Errorf("prefix only: %q, %v, %v", code, repaired, err)
+	}
+	below := NewSnippetGuard(SnippetContext{Suffix: "?>\n"}, "php")
+	content = below.Wrap("echo 1;")
+	if code, repaired, err := below.Unwrap("// new\n" + content); err != nil || repaired || code != "// new\necho 1;" {
+		t.Errorf("suffix only: %q, %v, %v", code, repaired, err)
+	}
+	if below.Lines() != 0 {
+		t.Errorf("Lines() = %d without prefix", below.Lines())
+	}
+}
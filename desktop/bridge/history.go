@@ -0,0 +1,491 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / History
+ * @tagline         Local version history store of snippets
+ * @description     Records versions of snippets in a folder per snippet with an index,
+ *                  optionally encrypted with AES-256-GCM, applies retention limits, and
+ *                  lists, searches and diffs versions
+ * @file            desktop/bridge/history.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// History modes: "on" records versions in plain files, "encrypted" encrypts them with
+// the key in history.key, "off" records nothing
+const (
+	HistoryOn        = "on"
+	HistoryEncrypted = "encrypted"
+	HistoryOff       = "off"
+)
+
+// Default retention of versions, per snippet and by age
+const (
+	DefaultHistoryMaxVersions = 100
+	DefaultHistoryMaxDays     = 30
+)
+
+// Directions of recorded versions
+const (
+	HistoryReceived = "received" // code of an edit_request from the browser
+	HistorySent     = "sent"     // code of a code_update sent to the browser
+)
+
+// diffContext is the number of unchanged lines shown around changes, and maxDiffEdits
+// the number of changed lines above which versions are shown in full
+const (
+	diffContext  = 3
+	maxDiffEdits = 5000
+)
+
+// NormalizeHistoryMode maps unknown or empty values to on
+func NormalizeHistoryMode(mode string) string {
+	switch mode {
+	case HistoryEncrypted, HistoryOff:
+		return mode
+	}
+	return HistoryOn
+}
+
+// HistoryLimits is the retention of versions; zero values mean no limit
+type HistoryLimits struct {
+	MaxVersions int // per snippet
+	MaxAge      time.Duration
+}
+
+// HistoryVersion is one recorded version of a snippet
+type HistoryVersion struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	FileType  string    `json:"file_type"`
+	Encoding  string    `json:"encoding,omitempty"` // binary encoding, empty for text
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+	Encrypted bool      `json:"encrypted,omitempty"`
+}
+
+// historyIndex lists the versions of one snippet, oldest first
+type historyIndex struct {
+	Server    string           `json:"server"`
+	Page      string           `json:"page"`
+	SnippetID string           `json:"snippet_id"`
+	NextID    int              `json:"next_id"`
+	Versions  []HistoryVersion `json:"versions"`
+}
+
+// HistoryEntry is a version of a snippet, as listed in the history view
+type HistoryEntry struct {
+	Key SessionKey
+	HistoryVersion
+}
+
+// String describes a version for the history view
+func (e HistoryEntry) String() string {
+	return fmt.Sprintf("%s  %-8s  %s  #%d, %s, %d bytes", e.Time.Format("2006-01-02 15:04:05"), e.Direction, e.Key, e.ID, e.FileType, e.Size)
+}
+
+// HistoryStore is the version history of all snippets: a folder per snippet with an
+// index and one file per version
+type HistoryStore struct {
+	mu      sync.Mutex
+	dir     string
+	keyPath string
+}
+
+// NewHistoryStore returns the store in a folder; the encryption key is kept next to
+// the folder, so that a copy of the folder cannot be read without it. The key file is
+// not protected by a passphrase or the OS keychain: anyone who can read the user's
+// files can read the history.
+func NewHistoryStore(dir string) *HistoryStore {
+	return &HistoryStore{dir: dir, keyPath: filepath.Join(filepath.Dir(dir), "history.key")}
+}
+
+// snippetDir returns the folder of a snippet: its name and a hash of the session
+func (h *HistoryStore) snippetDir(key SessionKey) string {
+	sum := sha256.Sum256([]byte(key.Server + "\n" + key.Page + "\n" + key.SnippetID))
+	return filepath.Join(h.dir, SanitizeFileName(key.SnippetID, 40)+"-"+hex.EncodeToString(sum[:6]))
+}
+
+// versionPath returns the file of a version
+func versionPath(dir string, v HistoryVersion) string {
+	if v.Encrypted {
+		return filepath.Join(dir, fmt.Sprintf("%06d.enc", v.ID))
+	}
+	return filepath.Join(dir, fmt.Sprintf("%06d.txt", v.ID))
+}
+
+// loadIndex reads the index of a snippet folder; the caller holds h.mu
+func loadIndex(dir string) (historyIndex, error) {
+	var index historyIndex
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return index, err
+	}
+	err = json.Unmarshal(data, &index)
+	return index, err
+}
+
+// saveIndex writes the index of a snippet folder atomically; the caller holds h.mu
+func saveIndex(dir string, index historyIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, "index.json.tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, "index.json"))
+}
+
+// cipherKey returns the encryption key, and creates it on first use; the caller holds h.mu
+func (h *HistoryStore) cipherKey() ([]byte, error) {
+	data, err := os.ReadFile(h.keyPath)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid history key in %s", h.keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.keyPath), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(h.keyPath, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// seal encrypts data with AES-256-GCM, with the nonce in front; the caller holds h.mu
+func (h *HistoryStore) seal(data []byte) ([]byte, error) {
+	gcm, err := h.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts data sealed by seal; the caller holds h.mu
+func (h *HistoryStore) open(data []byte) ([]byte, error) {
+	gcm, err := h.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted version is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt version, the history key was changed or the file was modified")
+	}
+	return plain, nil
+}
+
+func (h *HistoryStore) gcm() (cipher.AEAD, error) {
+	key, err := h.cipherKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Add records a version of a snippet, unless it has the code of the latest version,
+// and applies the retention limits to the snippet. Returns the new version, nil if
+// it was not recorded.
+func (h *HistoryStore) Add(key SessionKey, direction, fileType, encoding, code string, encrypt bool, limits HistoryLimits, now time.Time) (*HistoryVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dir := h.snippetDir(key)
+	index, err := loadIndex(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	index.Server, index.Page, index.SnippetID = key.Server, key.Page, key.SnippetID
+	hash := CodeHash(code)
+	if n := len(index.Versions); n > 0 && index.Versions[n-1].Hash == hash {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	index.NextID++
+	v := HistoryVersion{ID: index.NextID, Time: now, Direction: direction, FileType: fileType, Encoding: encoding,
+		Hash: hash, Size: len(code), Encrypted: encrypt}
+	data := []byte(code)
+	if encrypt {
+		if data, err = h.seal(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(versionPath(dir, v), data, 0600); err != nil {
+		return nil, err
+	}
+	index.Versions = append(index.Versions, v)
+	index.Versions = pruneVersions(dir, index.Versions, limits, now)
+	return &v, saveIndex(dir, index)
+}
+
+// pruneVersions removes the files of versions beyond the limits, and returns the
+// versions kept; the latest version is kept regardless of its age
+func pruneVersions(dir string, versions []HistoryVersion, limits HistoryLimits, now time.Time) []HistoryVersion {
+	keep := versions
+	if limits.MaxVersions > 0 && len(keep) > limits.MaxVersions {
+		keep = keep[len(keep)-limits.MaxVersions:]
+	}
+	if limits.MaxAge > 0 {
+		for len(keep) > 1 && now.Sub(keep[0].Time) > limits.MaxAge {
+			keep = keep[1:]
+		}
+	}
+	for _, v := range versions[:len(versions)-len(keep)] {
+		os.Remove(versionPath(dir, v))
+	}
+	return append([]HistoryVersion(nil), keep...)
+}
+
+// Prune applies the retention limits to all snippets, and removes snippets whose
+// latest version is older than the age limit. Returns the number of removed versions.
+func (h *HistoryStore) Prune(limits HistoryLimits, now time.Time) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dirs, err := os.ReadDir(h.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(h.dir, d.Name())
+		index, err := loadIndex(dir)
+		if err != nil {
+			continue
+		}
+		n := len(index.Versions)
+		if n == 0 || (limits.MaxAge > 0 && now.Sub(index.Versions[n-1].Time) > limits.MaxAge) {
+			if err := os.RemoveAll(dir); err == nil {
+				removed += n
+			}
+			continue
+		}
+		index.Versions = pruneVersions(dir, index.Versions, limits, now)
+		if len(index.Versions) < n {
+			removed += n - len(index.Versions)
+			if err := saveIndex(dir, index); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// List returns the versions of a snippet, or of all snippets for nil, newest first
+func (h *HistoryStore) List(key *SessionKey) ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var dirs []string
+	if key != nil {
+		dirs = []string{h.snippetDir(*key)}
+	} else {
+		entries, err := os.ReadDir(h.dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, d := range entries {
+			if d.IsDir() {
+				dirs = append(dirs, filepath.Join(h.dir, d.Name()))
+			}
+		}
+	}
+	var list []HistoryEntry
+	for _, dir := range dirs {
+		index, err := loadIndex(dir)
+		if err != nil {
+			continue
+		}
+		k := SessionKey{Server: index.Server, Page: index.Page, SnippetID: index.SnippetID}
+		for _, v := range index.Versions {
+			list = append(list, HistoryEntry{Key: k, HistoryVersion: v})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Time.Equal(list[j].Time) {
+			return list[i].Time.After(list[j].Time)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list, nil
+}
+
+// Read returns the code of a version
+func (h *HistoryStore) Read(e HistoryEntry) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := os.ReadFile(versionPath(h.snippetDir(e.Key), e.HistoryVersion))
+	if err != nil {
+		return "", err
+	}
+	if e.Encrypted {
+		if data, err = h.open(data); err != nil {
+			return "", err
+		}
+	}
+	return string(data), nil
+}
+
+// Previous returns the version of the same snippet before e
+func (h *HistoryStore) Previous(e HistoryEntry) (HistoryEntry, bool) {
+	list, err := h.List(&e.Key)
+	if err != nil {
+		return HistoryEntry{}, false
+	}
+	for _, p := range list {
+		if p.ID < e.ID {
+			return p, true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// Search returns the versions whose code or snippet ID contains the query, ignoring
+// case, newest first
+func (h *HistoryStore) Search(query string) ([]HistoryEntry, error) {
+	list, err := h.List(nil)
+	if err != nil || strings.TrimSpace(query) == "" {
+		return list, err
+	}
+	query = strings.ToLower(query)
+	var found []HistoryEntry
+	for _, e := range list {
+		if strings.Contains(strings.ToLower(e.Key.SnippetID), query) {
+			found = append(found, e)
+			continue
+		}
+		code, err := h.Read(e)
+		if err == nil && e.Encoding == "" && strings.Contains(strings.ToLower(code), query) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// DiffVersions returns a unified diff of two versions of a snippet, with diffContext
+// unchanged lines around each change. Binary versions are only compared by size.
+func DiffVersions(older, newer string, binary bool) string {
+	if older == newer {
+		return "The versions are identical."
+	}
+	if binary {
+		return fmt.Sprintf("Binary content differs: %d bytes, then %d bytes.", len(older), len(newer))
+	}
+	a, b := SplitLines(older), SplitLines(newer)
+	match, ok := MatchLinesWithin(a, b, maxDiffEdits)
+	if !ok {
+		match = make([]int, len(a))
+		for i := range match {
+			match[i] = -1
+		}
+	}
+
+	// Edit script: ' ' unchanged, '-' deleted from older, '+' inserted from newer
+	type diffLine struct {
+		op   byte
+		text string
+		a, b int // line numbers before this line, in older and newer
+	}
+	var lines []diffLine
+	j := 0
+	for i := range a {
+		if match[i] < 0 {
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			continue
+		}
+		for ; j < match[i]; j++ {
+			lines = append(lines, diffLine{'+', b[j], i, j})
+		}
+		lines = append(lines, diffLine{' ', a[i], i, j})
+		j++
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j], len(a), j})
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// A hunk runs from the context before a change to the context after the last
+		// change that is at most 2*diffContext unchanged lines from the next one
+		from := max(start-diffContext, 0)
+		to, unchanged := start, 0
+		for k := start; k < len(lines) && unchanged <= 2*diffContext; k++ {
+			if lines[k].op == ' ' {
+				unchanged++
+			} else {
+				unchanged, to = 0, k
+			}
+		}
+		to = min(to+diffContext+1, len(lines))
+		countA, countB := 0, 0
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				countA++
+			}
+			if l.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[from].a+1, countA, lines[from].b+1, countB)
+		for _, l := range lines[from:to] {
+			out.WriteByte(l.op)
+			out.WriteString(strings.TrimSuffix(l.text, "\n"))
+			out.WriteByte('\n')
+		}
+		start = to
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,221 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / History Tests
+ * @tagline         Tests for the local version history store of snippets
+ * @description     Tests recording versions, retention limits, encrypted versions, search,
+ *                  and unified diffs of versions
+ * @file            desktop/bridge/history_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"runtime"
+)
+
+var historyStart = time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func TestNormalizeHistoryMode(t *testing.T) {
+	for in, want := range map[string]string{"": HistoryOn, "on": HistoryOn, "encrypted": HistoryEncrypted, "off": HistoryOff, "OFF": HistoryOn} {
+		if got := NormalizeHistoryMode(in); got != want {
+			t.Errorf("NormalizeHistoryMode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestHistoryStoreAdd(t *testing.T) {
+	h := NewHistoryStore(filepath.Join(t.TempDir(), "history"))
+	key := SessionKey{Server: "wss://a", Page: "/p", SnippetID: "s1"}
+	other := SessionKey{Server: "wss://b", Page: "/p", SnippetID: "s1"}
+	limits := HistoryLimits{}
+	add := func(key SessionKey, direction, code string, minutes int) *HistoryVersion {
+		v, err := h.Add(key, direction, "js", "", code, false, limits, historyStart.Add(time.Duration(minutes)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	if v := add(key, HistoryReceived, "a", 0); v == nil || v.ID != 1 || v.Size != 1 || v.Hash != CodeHash("a") {
+		t.Fatalf("unexpected version %+v", v)
+	}
+	if v := add(key, HistorySent, "a", 1); v != nil {
+		t.Errorf("code of the latest version was recorded again: %+v", v)
+	}
+	add(key, HistorySent, "b", 2)
+	add(key, HistoryReceived, "a", 3)
+	add(other, HistoryReceived, "c", 4)
+
+	list, err := h.List(&key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range list {
+		code, err := h.Read(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, code)
+		if e.Key != key {
+			t.Errorf("unexpected key %v", e.Key)
+		}
+	}
+	if strings.Join(got, ",") != "a,b,a" || list[0].ID != 3 || list[2].Direction != HistoryReceived {
+		t.Errorf("unexpected versions %v %+v", got, list)
+	}
+	if all, _ := h.List(nil); len(all) != 4 || all[0].Key != other {
+		t.Errorf("unexpected versions of all snippets %+v", all)
+	}
+	if p, ok := h.Previous(list[0]); !ok || p.ID != 2 {
+		t.Errorf("unexpected previous version %+v %v", p, ok)
+	}
+	if _, ok := h.Previous(list[2]); ok {
+		t.Error("the first version has no previous version")
+	}
+	if s := list[0].String(); s != "2025-08-01 12:03:00  received  "+key.String()+"  #3, js, 1 bytes" {
+		t.Errorf("unexpected description %q", s)
+	}
+}
+
+func TestHistoryStoreLimits(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	h := NewHistoryStore(dir)
+	key := SessionKey{Server: "wss://a", Page: "/p", SnippetID: "s1"}
+	limits := HistoryLimits{MaxVersions: 3, MaxAge: 24 * time.Hour}
+	for i, code := range []string{"1", "2", "3", "4", "5"} {
+		if _, err := h.Add(key, HistorySent, "txt", "", code, false, limits, historyStart.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, _ := h.List(&key)
+	if len(list) != 3 || list[2].ID != 3 {
+		t.Fatalf("expected versions 3 to 5, got %+v", list)
+	}
+	files, _ := filepath.Glob(filepath.Join(h.snippetDir(key), "*.txt"))
+	if len(files) != 3 {
+		t.Errorf("expected the files of removed versions to be deleted, got %v", files)
+	}
+
+	// Old versions are removed, the latest is kept until the snippet is older than the limit
+	removed, err := h.Prune(limits, historyStart.Add(27*time.Hour+30*time.Minute))
+	if err != nil || removed != 2 {
+		t.Errorf("expected 2 removed versions, got %d %v", removed, err)
+	}
+	if list, _ := h.List(&key); len(list) != 1 || list[0].ID != 5 {
+		t.Errorf("expected the latest version to be kept, got %+v", list)
+	}
+	removed, err = h.Prune(limits, historyStart.Add(30*24*time.Hour))
+	if err != nil || removed != 1 {
+		t.Errorf("expected 1 removed version, got %d %v", removed, err)
+	}
+	if _, err := os.Stat(h.snippetDir(key)); !os.IsNotExist(err) {
+		t.Errorf("expected the snippet folder to be removed, got %v", err)
+	}
+	if removed, err := NewHistoryStore(filepath.Join(dir, "missing")).Prune(limits, historyStart); removed != 0 || err != nil {
+		t.Errorf("missing history: %d %v", removed, err)
+	}
+}
+
+func TestHistoryStoreEncrypted(t *testing.T) {
+	base := t.TempDir()
+	h := NewHistoryStore(filepath.Join(base, "history"))
+	key := SessionKey{Server: "wss://a", Page: "/p", SnippetID: "secret"}
+	code := "password = 'hunter2'"
+	v, err := h.Add(key, HistoryReceived, "py", "", code, true, HistoryLimits{}, historyStart)
+	if err != nil || v == nil || !v.Encrypted {
+		t.Fatalf("unexpected version %+v %v", v, err)
+	}
+	path := versionPath(h.snippetDir(key), *v)
+	if filepath.Ext(path) != ".enc" {
+		t.Errorf("unexpected version file %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Contains(string(data), "hunter2") {
+		t.Errorf("version is not encrypted: %q %v", data, err)
+	}
+	keyInfo, err := os.Stat(filepath.Join(base, "history.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && keyInfo.Mode().Perm()&0077 != 0 {
+		t.Errorf("key file is readable by others: %v", keyInfo.Mode())
+	}
+	e := HistoryEntry{Key: key, HistoryVersion: *v}
+	if got, err := h.Read(e); err != nil || got != code {
+		t.Errorf("got %q %v", got, err)
+	}
+	if found, _ := h.Search("HUNTER"); len(found) != 1 {
+		t.Errorf("expected encrypted versions to be searched, got %+v", found)
+	}
+
+	// A modified version or another key cannot be decrypted
+	data[len(data)-1] ^= 1
+	os.WriteFile(path, data, 0600)
+	if _, err := h.Read(e); err == nil {
+		t.Error("expected an error for a modified version")
+	}
+	os.WriteFile(filepath.Join(base, "history.key"), []byte("not hex\n"), 0600)
+	if _, err := h.Add(key, HistorySent, "py", "", "x", true, HistoryLimits{}, historyStart); err == nil {
+		t.Error("expected an error for an invalid key file")
+	}
+}
+
+func TestHistoryStoreSearch(t *testing.T) {
+	h := NewHistoryStore(filepath.Join(t.TempDir(), "history"))
+	add := func(key SessionKey, encoding, code string) {
+		if _, err := h.Add(key, HistoryReceived, "txt", encoding, code, false, HistoryLimits{}, historyStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(SessionKey{Server: "s", Page: "p", SnippetID: "invoice-total"}, "", "sum()")
+	add(SessionKey{Server: "s", Page: "p", SnippetID: "query"}, "", "SELECT total FROM t")
+	add(SessionKey{Server: "s", Page: "p", SnippetID: "logo"}, "base64", "dG90YWw=total")
+	found, err := h.Search("Total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range found {
+		ids = append(ids, e.Key.SnippetID)
+	}
+	if len(ids) != 2 || strings.Contains(strings.Join(ids, ","), "logo") {
+		t.Errorf("expected the snippet ID and text code to match, not binary code: %v", ids)
+	}
+	if all, _ := h.Search("  "); len(all) != 3 {
+		t.Errorf("expected an empty query to list all versions, got %d", len(all))
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	if got := DiffVersions("a\n", "a\n", false); got != "The versions are identical." {
+		t.Errorf("unexpected diff %q", got)
+	}
+	if got := DiffVersions("AAA", "AAAA", true); got != "Binary content differs: 3 bytes, then 4 bytes." {
+		t.Errorf("unexpected diff %q", got)
+	}
+	older := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	newer := "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n16\n17\n"
+	want := "@@ -1,5 +1,5 @@\n" +
+		" 1\n-2\n+two\n 3\n 4\n 5\n" +
+		"@@ -12,5 +12,5 @@\n" +
+		" 12\n 13\n 14\n-15\n 16\n+17"
+	if got := DiffVersions(older, newer, false); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	// Changes at most 2*diffContext unchanged lines apart are one hunk
+	if got := DiffVersions("a\nb\nc\nd\ne\nf\ng\nh\n", "A\nb\nc\nd\ne\nf\ng\nH\n", false); strings.Count(got, "@@ -") != 1 {
+		t.Errorf("expected one hunk, got\n%s", got)
+	}
+}
@@ -0,0 +1,397 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Launch
+ * @tagline         IDE launch templates with placeholders
+ * @description     Resolves launch templates such as `code --goto {file}:{line}` into a
+ *                  command, environment and working directory, and selects the IDE of a
+ *                  snippet by fileType or file name
+ * @file            desktop/bridge/launch.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LaunchProfile describes how to start an IDE for a snippet.
+// Template placeholders: {file}, {files}, {dir}, {workspace}, {line}, {column}, {endLine}, {endColumn},
+// {snippetId}, {fileType}, {ide}
+type LaunchProfile struct {
+	Template string            `json:"template,omitempty"` // e.g. `code --new-window --goto {file}:{line}:{column}`
+	Env      map[string]string `json:"env,omitempty"`      // extra environment variables, values may use placeholders
+	WorkDir  string            `json:"work_dir,omitempty"` // working directory, may use placeholders
+	Wait     bool              `json:"wait,omitempty"`     // the editor process lives until the file is closed, e.g. `code --wait`
+}
+
+// LaunchContext holds the placeholder values for one launch
+type LaunchContext struct {
+	File      string
+	Files     []string // all files of a batch, File is the first one
+	Workspace string   // workspace folder of the snippet, empty for loose temp files
+	Line      int      // cursor position or selection start, 1-based, 0 if unknown
+	Column    int
+	EndLine   int // selection end, 0 for a cursor position
+	EndColumn int
+	SnippetID string
+	FileType  string
+	IDE       string
+}
+
+// LaunchCommand is a fully resolved IDE launch
+type LaunchCommand struct {
+	Args []string
+	Env  []string // extra KEY=VALUE entries, added to the app environment
+	Dir  string
+	Wait bool // track the process: the edit session ends when it exits
+}
+
+// editorPositionArgs are the file arguments that open a file at a line and column, for
+// popular editors by command name (see EditorCommandName)
+var editorPositionArgs = map[string]string{
+	"code":         "--goto {file}:{line}:{column}",
+	"codium":       "--goto {file}:{line}:{column}",
+	"cursor":       "--goto {file}:{line}:{column}",
+	"windsurf":     "--goto {file}:{line}:{column}",
+	"zed":          "{file}:{line}:{column}",
+	"zeditor":      "{file}:{line}:{column}",
+	"subl":         "{file}:{line}:{column}",
+	"sublime_text": "{file}:{line}:{column}",
+	"idea":         "--line {line} --column {column} {file}",
+	"pycharm":      "--line {line} --column {column} {file}",
+	"webstorm":     "--line {line} --column {column} {file}",
+	"goland":       "--line {line} --column {column} {file}",
+	"kate":         "--line {line} --column {column} {file}",
+	"kwrite":       "--line {line} --column {column} {file}",
+	"geany":        "--line {line} --column {column} {file}",
+	"gedit":        "+{line}:{column} {file}",
+	"emacs":        "+{line}:{column} {file}",
+	"gvim":         "+{line} {file}",
+	"mvim":         "+{line} {file}",
+	"pluma":        "+{line} {file}",
+	"xed":          "+{line} {file}",
+	"bbedit":       "+{line} {file}",
+	"mate":         "-l {line}:{column} {file}",
+	"notepad++":    "-n{line} -c{column} {file}",
+}
+
+// EditorCommandName returns the lower-case command name of an IDE command, without
+// path and extension, e.g. C:\Tools\Code.exe -> code
+func EditorCommandName(ideCmd string) string {
+	name := strings.ToLower(ideCmd[strings.LastIndexAny(ideCmd, `/\`)+1:])
+	for _, ext := range []string{".app", ".exe", ".cmd", ".sh"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// DefaultLaunchTemplate returns the template used when no template is configured
+func DefaultLaunchTemplate(ideCmd string) string {
+	return LaunchTemplateFor(ideCmd, false, false)
+}
+
+// LaunchTemplateFor builds the default launch template of an IDE command. Popular
+// editors open the file at the cursor position; folder passes the workspace folder
+// first, and files passes all files of a batch instead of the file.
+func LaunchTemplateFor(ideCmd string, folder, files bool) string {
+	args := "{file}"
+	if files {
+		args = "{files}"
+	}
+	if folder {
+		args = "{workspace} " + args
+	}
+	if runtime.GOOS == "darwin" {
+		if strings.HasSuffix(ideCmd, ".app") {
+			// Extract app name from path, e.g., /Applications/TextEdit.app -> TextEdit
+			appName := strings.TrimSuffix(filepath.Base(ideCmd), ".app")
+			return "open -a " + QuoteArg(appName) + " " + args
+		} else if !strings.Contains(ideCmd, "/") {
+			// App name only (e.g., TextEdit, Cursor)
+			return "open -a " + QuoteArg(ideCmd) + " " + args
+		}
+	}
+	if position, ok := editorPositionArgs[EditorCommandName(ideCmd)]; ok && !files {
+		args = position
+		if folder {
+			args = "{workspace} " + args
+		}
+	}
+	// Path to binary, or command in PATH
+	return QuoteArg(ideCmd) + " " + args
+}
+
+// expandPlaceholders replaces {name} placeholders with launch context values
+func expandPlaceholders(s string, ctx LaunchContext) string {
+	line, column := ctx.Line, ctx.Column
+	if line < 1 {
+		line = 1
+	}
+	if column < 1 {
+		column = 1
+	}
+	endLine, endColumn := ctx.EndLine, ctx.EndColumn
+	if endLine < 1 {
+		endLine, endColumn = line, column
+	}
+	if endColumn < 1 {
+		endColumn = 1
+	}
+	workspace := ctx.Workspace
+	if workspace == "" {
+		workspace = filepath.Dir(ctx.File)
+	}
+	return strings.NewReplacer(
+		"{files}", strings.Join(ctx.files(), " "),
+		"{file}", ctx.File,
+		"{dir}", filepath.Dir(ctx.File),
+		"{workspace}", workspace,
+		"{line}", strconv.Itoa(line),
+		"{column}", strconv.Itoa(column),
+		"{endLine}", strconv.Itoa(endLine),
+		"{endColumn}", strconv.Itoa(endColumn),
+		"{snippetId}", ctx.SnippetID,
+		"{fileType}", ctx.FileType,
+		"{ide}", ctx.IDE,
+	).Replace(s)
+}
+
+// files returns the files of a launch: the batch files, or the single file
+func (ctx LaunchContext) files() []string {
+	if len(ctx.Files) > 0 {
+		return ctx.Files
+	}
+	return []string{ctx.File}
+}
+
+// SplitCommandLine splits a template into arguments. Double and single quotes group
+// words; inside double quotes \" is a literal quote. Other backslashes are kept as-is
+// so that Windows paths need no escaping.
+func SplitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	quote := rune(0)
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+				cur.WriteRune('"')
+				i++
+			} else if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in launch template", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// QuoteArg quotes an argument for display or for use in a template
+func QuoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// FormatCommandLine renders resolved arguments as a single command line
+func FormatCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = QuoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// ResolveLaunch turns a launch profile into a command for the given context.
+// Placeholders are expanded after splitting, so file paths with spaces stay one argument.
+func ResolveLaunch(profile LaunchProfile, ctx LaunchContext) (LaunchCommand, error) {
+	template := strings.TrimSpace(profile.Template)
+	if template == "" {
+		template = LaunchTemplateFor(ctx.IDE, ctx.Workspace != "" && OpensFolders(ctx.IDE), len(ctx.Files) > 1)
+	}
+	words, err := SplitCommandLine(template)
+	if err != nil {
+		return LaunchCommand{}, err
+	}
+	if len(words) == 0 {
+		return LaunchCommand{}, fmt.Errorf("launch template is empty")
+	}
+	cmd := LaunchCommand{Args: make([]string, 0, len(words))}
+	for _, word := range words {
+		if word == "{files}" {
+			// One argument per file, so that paths with spaces stay intact
+			cmd.Args = append(cmd.Args, ctx.files()...)
+			continue
+		}
+		cmd.Args = append(cmd.Args, expandPlaceholders(word, ctx))
+		if word == "--wait" || word == "-W" {
+			cmd.Wait = true
+		}
+	}
+	if profile.Wait {
+		cmd.Wait = true
+		// macOS `open` returns immediately unless told to wait for the app to quit
+		if cmd.Args[0] == "open" && !strings.Contains(" "+strings.Join(cmd.Args, " ")+" ", " -W ") {
+			cmd.Args = append([]string{"open", "-W"}, cmd.Args[1:]...)
+		}
+	}
+	keys := make([]string, 0, len(profile.Env))
+	for key := range profile.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+expandPlaceholders(profile.Env[key], ctx))
+	}
+	if profile.WorkDir != "" {
+		cmd.Dir = expandPlaceholders(profile.WorkDir, ctx)
+	}
+	return cmd, nil
+}
+
+// String renders the launch for logs and previews
+func (lc LaunchCommand) String() string {
+	s := FormatCommandLine(lc.Args)
+	if len(lc.Env) > 0 {
+		s = FormatCommandLine(lc.Env) + " " + s
+	}
+	if lc.Dir != "" {
+		s = "(cd " + QuoteArg(lc.Dir) + " && " + s + ")"
+	}
+	return s
+}
+
+// Cmd builds an exec.Cmd for the resolved launch
+func (lc LaunchCommand) Cmd() *exec.Cmd {
+	cmd := exec.Command(lc.Args[0], lc.Args[1:]...)
+	if len(lc.Env) > 0 {
+		cmd.Env = append(os.Environ(), lc.Env...)
+	}
+	cmd.Dir = lc.Dir
+	return cmd
+}
+
+// ParseEnvLines parses KEY=VALUE lines, as entered in the config dialog
+func ParseEnvLines(text string) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) != "" {
+			env[strings.TrimSpace(key)] = value
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// FormatEnvLines renders environment variables as sorted KEY=VALUE lines
+func FormatEnvLines(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + env[key]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IDEMapping selects an IDE and launch profile by fileType or file name glob
+type IDEMapping struct {
+	Pattern       string `json:"pattern"`       // fileTypes (e.g. "sql" or "js, ts") or globs on the file name (e.g. "*.py")
+	IDE           string `json:"ide,omitempty"` // IDE command, defaults to the global IDE command
+	LaunchProfile        // launch template, env and working directory for this mapping
+}
+
+// Matches reports whether the mapping applies to a fileType and temp file name
+func (m IDEMapping) Matches(fileType, fileName string) bool {
+	return MatchesFileType(m.Pattern, fileType, fileName)
+}
+
+// SelectLaunch picks the IDE and launch profile for a snippet: the first matching
+// mapping wins, otherwise the default IDE command and launch profile are used. A mapping
+// without IDE and template uses the default template. Returns where the choice came from.
+func SelectLaunch(mappings []IDEMapping, ideCmd string, launch LaunchProfile, fileType, fileName string) (string, LaunchProfile, string) {
+	for _, m := range mappings {
+		if !m.Matches(fileType, fileName) {
+			continue
+		}
+		ide := m.IDE
+		if ide == "" {
+			ide = ideCmd
+		}
+		profile := m.LaunchProfile
+		if profile.Template == "" && m.IDE == "" {
+			profile.Template = launch.Template
+		}
+		return ide, profile, "mapping " + QuoteArg(m.Pattern)
+	}
+	return ideCmd, launch, "default"
+}
+
+// MatchesFileType reports whether comma-separated fileTypes or file name globs match
+func MatchesFileType(patterns, fileType, fileName string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(fileName)); ok {
+				return true
+			}
+		} else if strings.EqualFold(strings.TrimPrefix(pattern, "."), fileType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Launch Tests
+ * @tagline         Tests for launch templates
+ * @description     Tests for splitting launch templates, placeholders, wait-for-close
+ *                  detection and the selection of IDE mappings
+ * @file            desktop/bridge/launch_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{"code --goto {file}:{line}", []string{"code", "--goto", "{file}:{line}"}},
+		{"  code \t{file}\n", []string{"code", "{file}"}},
+		{`"C:\Program Files\Code\Code.exe" {file}`, []string{`C:\Program Files\Code\Code.exe`, "{file}"}},
+		{`C:\Tools\edit.exe {file}`, []string{`C:\Tools\edit.exe`, "{file}"}},
+		{`open -a 'Sublime Text' {file}`, []string{"open", "-a", "Sublime Text", "{file}"}},
+		{`vim -c "echo \"it's\"" {file}`, []string{"vim", "-c", `echo "it's"`, "{file}"}},
+		{`vim -c 'echo "hi"' {file}`, []string{"vim", "-c", `echo "hi"`, "{file}"}},
+		{`'it'"'"'s'`, []string{"it's"}},
+		{`--title="{snippetId} {fileType}"`, []string{"--title={snippetId} {fileType}"}},
+		{`cmd "" {file}`, []string{"cmd", "", "{file}"}},
+		{`'a\"b'`, []string{`a\"b`}},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.template)
+		if err != nil {
+			t.Errorf("SplitCommandLine(%q) failed: %v", tt.template, err)
+			continue
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	for _, template := range []string{`code "{file}`, `code '{file}`, `code "it\"`} {
+		if _, err := SplitCommandLine(template); err == nil || !strings.Contains(err.Error(), "unterminated") {
+			t.Errorf("Expected an unterminated quote error for %q, got %v", template, err)
+		}
+	}
+}
+
+func TestSplitCommandLineQuoteRoundTrip(t *testing.T) {
+	args := []string{"code", "/tmp/my dir/a.js", `say "hi"`, "it's", "", `C:\a b\c`, "tab\there"}
+	got, err := SplitCommandLine(FormatCommandLine(args))
+	if err != nil {
+		t.Fatalf("Failed to split %s: %v", FormatCommandLine(args), err)
+	}
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", args) {
+		t.Errorf("Round trip of %q gave %q", args, got)
+	}
+}
+
+func TestResolveLaunchPlaceholders(t *testing.T) {
+	ctx := LaunchContext{
+		File:      "/tmp/my snippets/page-1 snippet.js",
+		SnippetID: "snippet-1",
+		FileType:  "js",
+		IDE:       "code",
+		Line:      12,
+		Column:    5,
+	}
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		// File paths with spaces stay one argument, also inside a word
+		{"code --goto {file}:{line}:{column}", []string{"code", "--goto", "/tmp/my snippets/page-1 snippet.js:12:5"}},
+		{"edit {dir} {workspace}", []string{"edit", "/tmp/my snippets", "/tmp/my snippets"}},
+		// The selection end defaults to the cursor position
+		{"edit --select {line},{column}-{endLine},{endColumn}", []string{"edit", "--select", "12,5-12,5"}},
+		{`edit "--title={snippetId} ({fileType})" --ide={ide}`, []string{"edit", "--title=snippet-1 (js)", "--ide=code"}},
+		// Unknown placeholders and lone braces are kept
+		{"edit {nope} {file", []string{"edit", "{nope}", "{file"}},
+		// Placeholder values are not split or unquoted again
+		{"edit '{file}'", []string{"edit", "/tmp/my snippets/page-1 snippet.js"}},
+	}
+	for _, tt := range tests {
+		cmd, err := ResolveLaunch(LaunchProfile{Template: tt.template}, ctx)
+		if err != nil {
+			t.Errorf("ResolveLaunch(%q) failed: %v", tt.template, err)
+			continue
+		}
+		if fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("ResolveLaunch(%q) = %q, want %q", tt.template, cmd.Args, tt.want)
+		}
+	}
+
+	if _, err := ResolveLaunch(LaunchProfile{Template: `code "{file}`}, ctx); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+}
+
+func TestResolveLaunchPositionDefaults(t *testing.T) {
+	ctx := LaunchContext{File: "/tmp/a.js", Line: 0, Column: 0, EndLine: 3, EndColumn: 0}
+	cmd, err := ResolveLaunch(LaunchProfile{Template: "edit {line}:{column} {endLine}:{endColumn}"}, ctx)
+	if err != nil {
+		t.Fatalf("ResolveLaunch failed: %v", err)
+	}
+	if want := []string{"edit", "1:1", "3:1"}; fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", want) {
+		t.Errorf("Expected %q, got %q", want, cmd.Args)
+	}
+}
+
+func TestResolveLaunchFiles(t *testing.T) {
+	ctx := LaunchContext{
+		File:      "/tmp/batch/a b.js",
+		Files:     []string{"/tmp/batch/a b.js", "/tmp/batch/c.css"},
+		Workspace: "/tmp/batch",
+	}
+	cmd, err := ResolveLaunch(LaunchProfile{Template: "edit {workspace} {files} --all={files}"}, ctx)
+	if err != nil {
+		t.Fatalf("ResolveLaunch failed: %v", err)
+	}
+	// {files} as a word gives one argument per file, inside a word the files are joined
+	want := []string{"edit", "/tmp/batch", "/tmp/batch/a b.js", "/tmp/batch/c.css", "--all=/tmp/batch/a b.js /tmp/batch/c.css"}
+	if fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", want) {
+		t.Errorf("Expected %q, got %q", want, cmd.Args)
+	}
+
+	// A single file without a batch
+	cmd, _ = ResolveLaunch(LaunchProfile{Template: "edit {files}"}, LaunchContext{File: "/tmp/a.js"})
+	if want := []string{"edit", "/tmp/a.js"}; fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", want) {
+		t.Errorf("Expected %q, got %q", want, cmd.Args)
+	}
+}
+
+func TestResolveLaunchEnvAndWorkDir(t *testing.T) {
+	profile := LaunchProfile{
+		Template: "edit {file}",
+		Env:      map[string]string{"SNIPPET": "{snippetId}", "A_FIRST": "1", "EDIT_LINE": "{line}"},
+		WorkDir:  "{dir}",
+	}
+	cmd, err := ResolveLaunch(profile, LaunchContext{File: "/tmp/dir x/a.js", SnippetID: "s-1", Line: 7})
+	if err != nil {
+		t.Fatalf("ResolveLaunch failed: %v", err)
+	}
+	if want := []string{"A_FIRST=1", "EDIT_LINE=7", "SNIPPET=s-1"}; fmt.Sprintf("%q", cmd.Env) != fmt.Sprintf("%q", want) {
+		t.Errorf("Expected sorted env %q, got %q", want, cmd.Env)
+	}
+	if cmd.Dir != "/tmp/dir x" {
+		t.Errorf("Expected the file folder as working directory, got %q", cmd.Dir)
+	}
+	if want := `(cd "/tmp/dir x" && A_FIRST=1 EDIT_LINE=7 SNIPPET=s-1 edit "/tmp/dir x/a.js")`; cmd.String() != want {
+		t.Errorf("Expected %s, got %s", want, cmd.String())
+	}
+}
+
+func TestResolveLaunchDefaultTemplate(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("default templates use open -a on macOS")
+	}
+	tests := []struct {
+		ctx  LaunchContext
+		want []string
+	}{
+		{LaunchContext{File: "/tmp/a b.js", IDE: "code", Line: 4, Column: 2}, []string{"code", "--goto", "/tmp/a b.js:4:2"}},
+		{LaunchContext{File: "/tmp/ws/a.js", Workspace: "/tmp/ws", IDE: "/usr/bin/code"}, []string{"/usr/bin/code", "/tmp/ws", "--goto", "/tmp/ws/a.js:1:1"}},
+		{LaunchContext{File: "/tmp/a.js", IDE: "/opt/My Editor/edit"}, []string{"/opt/My Editor/edit", "/tmp/a.js"}},
+		// Folders are only passed to editors that open them
+		{LaunchContext{File: "/tmp/ws/a.js", Workspace: "/tmp/ws", IDE: "gedit", Line: 3}, []string{"gedit", "+3:1", "/tmp/ws/a.js"}},
+		{LaunchContext{File: "/tmp/ws/a.js", Files: []string{"/tmp/ws/a.js", "/tmp/ws/b.js"}, Workspace: "/tmp/ws", IDE: "code"}, []string{"code", "/tmp/ws", "/tmp/ws/a.js", "/tmp/ws/b.js"}},
+	}
+	for _, tt := range tests {
+		cmd, err := ResolveLaunch(LaunchProfile{Template: "  "}, tt.ctx)
+		if err != nil {
+			t.Errorf("ResolveLaunch for %s failed: %v", tt.ctx.IDE, err)
+			continue
+		}
+		if fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("Default launch of %s = %q, want %q", tt.ctx.IDE, cmd.Args, tt.want)
+		}
+	}
+}
+
+func TestResolveLaunchWait(t *testing.T) {
+	ctx := LaunchContext{File: "/tmp/a.js"}
+	tests := []struct {
+		profile LaunchProfile
+		want    []string
+		wait    bool
+	}{
+		{LaunchProfile{Template: "code {file}"}, []string{"code", "/tmp/a.js"}, false},
+		// Editors told to wait are tracked without the wait option
+		{LaunchProfile{Template: "code --wait {file}"}, []string{"code", "--wait", "/tmp/a.js"}, true},
+		{LaunchProfile{Template: "open -W -a TextEdit {file}"}, []string{"open", "-W", "-a", "TextEdit", "/tmp/a.js"}, true},
+		{LaunchProfile{Template: "edit '--wait' {file}"}, []string{"edit", "--wait", "/tmp/a.js"}, true},
+		{LaunchProfile{Template: "edit --wait-for={file}"}, []string{"edit", "--wait-for=/tmp/a.js"}, false},
+		{LaunchProfile{Template: "gedit {file}", Wait: true}, []string{"gedit", "/tmp/a.js"}, true},
+		// open returns at once unless it waits for the app to quit, -W is added once
+		{LaunchProfile{Template: "open -a TextEdit {file}", Wait: true}, []string{"open", "-W", "-a", "TextEdit", "/tmp/a.js"}, true},
+		{LaunchProfile{Template: "open -a TextEdit -W {file}", Wait: true}, []string{"open", "-a", "TextEdit", "-W", "/tmp/a.js"}, true},
+	}
+	for _, tt := range tests {
+		cmd, err := ResolveLaunch(tt.profile, ctx)
+		if err != nil {
+			t.Errorf("ResolveLaunch(%q) failed: %v", tt.profile.Template, err)
+			continue
+		}
+		if fmt.Sprintf("%q", cmd.Args) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("ResolveLaunch(%q) = %q, want %q", tt.profile.Template, cmd.Args, tt.want)
+		}
+		if cmd.Wait != tt.wait {
+			t.Errorf("ResolveLaunch(%q) waits %v, want %v", tt.profile.Template, cmd.Wait, tt.wait)
+		}
+	}
+}
+
+func TestSelectLaunch(t *testing.T) {
+	launch := LaunchProfile{Template: "{ide} --goto {file}:{line}"}
+	mappings := []IDEMapping{
+		{Pattern: "sql", IDE: "dbeaver"},
+		{Pattern: "*.test.js, *.spec.js", LaunchProfile: LaunchProfile{Template: "webstorm {file}"}},
+		{Pattern: ".py, pyw", IDE: "pycharm"},
+		{Pattern: "js, ts", LaunchProfile: LaunchProfile{Env: map[string]string{"NODE_ENV": "dev"}}},
+		// Later mappings of the same fileType never win
+		{Pattern: "sql", IDE: "datagrip"},
+	}
+	tests := []struct {
+		fileType, fileName string
+		ide, template      string
+		source             string
+	}{
+		{"sql", "page-1.sql", "dbeaver", "", "mapping sql"},
+		{"SQL", "page-1.SQL", "dbeaver", "", "mapping sql"},
+		// File name globs match before the fileType mapping further down
+		{"js", "app.test.js", "code", "webstorm {file}", `mapping "*.test.js, *.spec.js"`},
+		{"js", "APP.SPEC.JS", "code", "webstorm {file}", `mapping "*.test.js, *.spec.js"`},
+		{"py", "page-1.py", "pycharm", "", `mapping ".py, pyw"`},
+		{"pyw", "page-1.pyw", "pycharm", "", `mapping ".py, pyw"`},
+		// A mapping without IDE and template keeps the default template
+		{"ts", "page-1.ts", "code", "{ide} --goto {file}:{line}", `mapping "js, ts"`},
+		{"css", "page-1.css", "code", "{ide} --goto {file}:{line}", "default"},
+		{"", "page-1.txt", "code", "{ide} --goto {file}:{line}", "default"},
+	}
+	for _, tt := range tests {
+		ide, profile, source := SelectLaunch(mappings, "code", launch, tt.fileType, tt.fileName)
+		if ide != tt.ide || profile.Template != tt.template || source != tt.source {
+			t.Errorf("SelectLaunch(%q, %q) = %q, %q, %q, want %q, %q, %q",
+				tt.fileType, tt.fileName, ide, profile.Template, source, tt.ide, tt.template, tt.source)
+		}
+	}
+	if _, profile, _ := SelectLaunch(mappings, "code", launch, "ts", "page-1.ts"); profile.Env["NODE_ENV"] != "dev" {
+		t.Errorf("mapping env not kept: %v", profile.Env)
+	}
+	if ide, profile, source := SelectLaunch(nil, "vim", launch, "js", "page-1.js"); ide != "vim" || profile.Template != launch.Template || source != "default" {
+		t.Errorf("no mappings: got %q, %q, %q", ide, profile.Template, source)
+	}
+}
@@ -0,0 +1,250 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Merge
+ * @tagline         Line diff and three-way merge
+ * @description     Matches the lines of two texts with a shortest edit script, and merges
+ *                  local and remote changes made to a common base
+ * @file            desktop/bridge/merge.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Conflict markers written into the temp file when a merge fails
+const (
+	conflictMarkerLocal  = "<<<<<<< local (desktop)"
+	conflictMarkerSep    = "======="
+	conflictMarkerRemote = ">>>>>>> browser"
+)
+
+// SplitLines splits text into lines, keeping the line endings
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLines returns, for each line of a, the index of the matching line of b in a
+// shortest edit script (Myers diff), or -1 if the line was deleted
+func MatchLines(a, b []string) []int {
+	match, _ := MatchLinesWithin(a, b, -1)
+	return match
+}
+
+// MatchLinesWithin is MatchLines with at most limit inserted and deleted lines, since
+// the trace grows with the square of the edits; ok is false if there are more. A
+// negative limit means no limit.
+func MatchLinesWithin(a, b []string, limit int) (match []int, ok bool) {
+	match = make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Common prefix and suffix need no diffing
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		match[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		match[len(a)-1-suf] = len(b) - 1 - suf
+		suf++
+	}
+	a, b = a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return match, limit < 0 || n+m <= limit
+	}
+
+	// Forward pass; trace[d] holds the furthest x per diagonal k in [-d, d] before step d
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		if limit >= 0 && d > limit {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Backtrack through the trace, recording the diagonal moves as matches
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		vd := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && vd[d+k-1] < vd[d+k+1]) {
+			prevK = k + 1
+		}
+		prevX := vd[d+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			match[pre+x] = pre + y
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		match[pre+x] = pre + y
+	}
+	return match, true
+}
+
+// Merge3 merges local and remote changes made to a common base, line by line.
+// Returns the merged text and the number of conflicts, which are written with
+// conflict markers into the merged text.
+func Merge3(base, local, remote string) (string, int) {
+	o, a, b := SplitLines(base), SplitLines(local), SplitLines(remote)
+	ma, mb := MatchLines(o, a), MatchLines(o, b)
+
+	var out strings.Builder
+	conflicts := 0
+	emit := func(lines []string) {
+		for _, line := range lines {
+			out.WriteString(line)
+		}
+	}
+	emitSide := func(lines []string, marker string) {
+		emit(lines)
+		if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString(marker + "\n")
+	}
+	chunk := func(oc, ac, bc []string) {
+		switch {
+		case equalLines(ac, bc), equalLines(oc, bc):
+			emit(ac)
+		case equalLines(oc, ac):
+			emit(bc)
+		default:
+			conflicts++
+			if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+				out.WriteString("\n")
+			}
+			out.WriteString(conflictMarkerLocal + "\n")
+			emitSide(ac, conflictMarkerSep)
+			emitSide(bc, conflictMarkerRemote)
+		}
+	}
+
+	// Walk the base, alternating between stable runs (lines unchanged on both
+	// sides) and unstable chunks up to the next base line kept on both sides
+	i, ai, bi := 0, 0, 0
+	for {
+		n := 0
+		for i+n < len(o) && ma[i+n] == ai+n && mb[i+n] == bi+n {
+			n++
+		}
+		if n > 0 {
+			emit(o[i : i+n])
+			i, ai, bi = i+n, ai+n, bi+n
+			continue
+		}
+		j := i
+		for j < len(o) && (ma[j] < 0 || mb[j] < 0) {
+			j++
+		}
+		if j == len(o) {
+			chunk(o[i:], a[ai:], b[bi:])
+			break
+		}
+		chunk(o[i:j], a[ai:ma[j]], b[bi:mb[j]])
+		i, ai, bi = j, ma[j], mb[j]
+	}
+	return out.String(), conflicts
+}
+
+// WriteMergeToolFiles writes the base, local and browser versions of a snippet to dir,
+// for a merge tool. Returns the file of each placeholder: {base}, {local} and {remote}.
+func WriteMergeToolFiles(dir, name, fileType, base, local, remote string) (map[string]string, error) {
+	files := map[string]string{
+		"{base}":   filepath.Join(dir, name+".base."+fileType),
+		"{local}":  filepath.Join(dir, name+".local."+fileType),
+		"{remote}": filepath.Join(dir, name+".browser."+fileType),
+	}
+	contents := map[string]string{"{base}": base, "{local}": local, "{remote}": remote}
+	for key, path := range files {
+		if err := os.WriteFile(path, []byte(contents[key]), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// MergeToolCommand expands the placeholders of a merge tool command line: the files of
+// WriteMergeToolFiles, {merged} (the temp file), {snippetId} and {fileType}
+func MergeToolCommand(tool string, files map[string]string, merged, snippetId, fileType string) (LaunchCommand, error) {
+	words, err := SplitCommandLine(tool)
+	if err != nil {
+		return LaunchCommand{}, err
+	}
+	if len(words) == 0 {
+		return LaunchCommand{}, fmt.Errorf("merge tool command is empty")
+	}
+	replacer := strings.NewReplacer(
+		"{base}", files["{base}"],
+		"{local}", files["{local}"],
+		"{remote}", files["{remote}"],
+		"{merged}", merged,
+		"{snippetId}", snippetId,
+		"{fileType}", fileType,
+	)
+	launch := LaunchCommand{Args: make([]string, len(words))}
+	for i, word := range words {
+		launch.Args[i] = replacer.Replace(word)
+	}
+	return launch, nil
+}
@@ -0,0 +1,151 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Merge Tests
+ * @tagline         Tests for the three-way merge
+ * @description     Tests for merging undelivered local edits with browser code, with and
+ *                  without conflicts
+ * @file            desktop/bridge/merge_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editSnippets returns realistic snippet edits as pairs of base and edited code
+func editSnippets() map[string][2]string {
+	var config strings.Builder
+	config.WriteString("{\n  \"services\": [\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&config, "    {\n      \"name\": \"service-%d\",\n      \"port\": %d,\n      \"replicas\": %d,\n      \"enabled\": true\n    },\n", i, 8000+i, 1+i%3)
+	}
+	config.WriteString("    {\n      \"name\": \"last\"\n    }\n  ]\n}\n")
+	base := config.String()
+	edited := strings.Replace(base, "\"port\": 8100,", "\"port\": 9100,", 1)
+
+	var script strings.Builder
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&script, "function handler%d(event) {\n  const value = event.detail.value%d;\n  if (!value) {\n    return null;\n  }\n  return transform(value, %d);\n}\n\n", i, i, i)
+	}
+	scriptBase := script.String()
+	scriptEdited := strings.Replace(scriptBase, "  return transform(value, 30);\n", "  console.log('handler30', value);\n  return transform(value, 30) || fallback;\n", 1)
+
+	var yamlDoc strings.Builder
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&yamlDoc, "- id: item-%d\n  title: Item number %d\n  tags: [alpha, beta]\n", i, i)
+	}
+	yamlBase := yamlDoc.String()
+	yamlEdited := strings.Replace(yamlBase, "- id: item-10\n", "", 1) + "- id: item-new\n  title: New item\n  tags: []\n"
+
+	return map[string][2]string{
+		"json-config": {base, edited},
+		"js-module":   {scriptBase, scriptEdited},
+		"yaml-list":   {yamlBase, yamlEdited},
+	}
+}
+
+func TestMerge3OneSideChanged(t *testing.T) {
+	for name, pair := range editSnippets() {
+		base, code := pair[0], pair[1]
+		for _, sides := range [][2]string{{base, code}, {code, base}, {code, code}} {
+			merged, conflicts := Merge3(base, sides[0], sides[1])
+			if merged != code || conflicts != 0 {
+				t.Errorf("%s: expected the changed side without conflicts, got %d conflicts:\n%s", name, conflicts, merged)
+			}
+		}
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	conflict := func(local, remote string) string {
+		return conflictMarkerLocal + "\n" + local + conflictMarkerSep + "\n" + remote + conflictMarkerRemote + "\n"
+	}
+	tests := []struct {
+		name                string
+		base, local, remote string
+		want                string
+		conflicts           int
+	}{
+		{"separate edits", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"insert and delete", "a\nb\nc\nd\n", "a\nx\nb\nc\nd\n", "a\nb\nc\n", "a\nx\nb\nc\n", 0},
+		{"same edit on both sides", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", "a\nB\nc\n", 0},
+		{"overlapping edits", "a\nb\nc\n", "a\nB\nc\n", "a\nX\nc\n", "a\n" + conflict("B\n", "X\n") + "c\n", 1},
+		{"adjacent edits", "a\nb\nc\nd\n", "a\nB\nc\nd\n", "a\nb\nC\nd\n", "a\n" + conflict("B\nc\n", "b\nC\n") + "d\n", 1},
+		{"edit of a deleted line", "a\nb\nc\n", "a\nc\n", "a\nB\nc\n", "a\n" + conflict("", "B\n") + "c\n", 1},
+		{"two conflicts", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\nE\n", "1\nb\nc\nd\n5\n", conflict("A\n", "1\n") + "b\nc\nd\n" + conflict("E\n", "5\n"), 2},
+		{"both append", "a\n", "a\nb\n", "a\nc\n", "a\n" + conflict("b\n", "c\n"), 1},
+		{"empty base", "", "x\n", "y\n", conflict("x\n", "y\n"), 1},
+		{"empty base, one side", "", "x\n", "", "x\n", 0},
+		// Lines without a trailing newline
+		{"append after a last line without newline", "a\nb\nc", "a\nb\nc\nd", "A\nb\nc", "A\nb\nc\nd", 0},
+		{"newline added on one side", "a\nb\nc", "a\nb\nc\n", "A\nb\nc", "A\nb\nc\n", 0},
+		{"newline added next to an edit", "a\nb", "a\nb\n", "A\nb", conflict("a\nb\n", "A\nb\n"), 1},
+		{"conflict without trailing newlines", "a\nb", "a\nB", "a\nC", "a\n" + conflict("B\n", "C\n"), 1},
+		{"conflict with one trailing newline", "a\nb\n", "a\nB", "a\nC\n", "a\n" + conflict("B\n", "C\n"), 1},
+		{"conflict with a deleted last line", "a\nb", "a\n", "a\nB", "a\n" + conflict("", "B\n"), 1},
+	}
+	for _, tt := range tests {
+		merged, conflicts := Merge3(tt.base, tt.local, tt.remote)
+		if merged != tt.want || conflicts != tt.conflicts {
+			t.Errorf("%s: expected %d conflicts and\n%q\ngot %d conflicts and\n%q", tt.name, tt.conflicts, tt.want, conflicts, merged)
+		}
+	}
+}
+
+func TestMergeToolFilesKeepBase(t *testing.T) {
+	dir := t.TempDir()
+	base, local, remote := "a\nb\nc\n", "a\nB\nc\n", "a\nX\nc\n"
+	merged, conflicts := Merge3(base, local, remote)
+	if conflicts != 1 {
+		t.Fatalf("Expected a conflict, got %d:\n%s", conflicts, merged)
+	}
+
+	// On re-open, the browser code becomes the new base version before the merge tool
+	// runs; the tool still gets the base version the conflict was computed from
+	baseFile := BaseVersionPath(filepath.Join(dir, "base"), filepath.Join(dir, "page-snippet-1.js"))
+	os.MkdirAll(filepath.Dir(baseFile), 0700)
+	os.WriteFile(baseFile, []byte(remote), 0600)
+
+	files, err := WriteMergeToolFiles(dir, "page-snippet-1", "js", base, local, remote)
+	if err != nil {
+		t.Fatalf("Failed to write merge tool files: %v", err)
+	}
+	for placeholder, want := range map[string]string{"{base}": base, "{local}": local, "{remote}": remote} {
+		data, err := os.ReadFile(files[placeholder])
+		if err != nil {
+			t.Fatalf("Failed to read %s file: %v", placeholder, err)
+		}
+		if string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q", placeholder, want, data)
+		}
+	}
+	if files["{base}"] == baseFile {
+		t.Error("Expected the merge tool to get a copy of the base version")
+	}
+}
+
+func TestMergeToolCommand(t *testing.T) {
+	files := map[string]string{"{base}": "/tmp/m/s.base.js", "{local}": "/tmp/m/s.local.js", "{remote}": "/tmp/m/s.browser.js"}
+	launch, err := MergeToolCommand("meld {local} {base} {remote} -o {merged} --label={snippetId}.{fileType}", files, "/tmp/page-s.js", "s", "js")
+	if err != nil {
+		t.Fatalf("MergeToolCommand failed: %v", err)
+	}
+	want := []string{"meld", "/tmp/m/s.local.js", "/tmp/m/s.base.js", "/tmp/m/s.browser.js", "-o", "/tmp/page-s.js", "--label=s.js"}
+	if fmt.Sprint(launch.Args) != fmt.Sprint(want) {
+		t.Errorf("Expected %q, got %q", want, launch.Args)
+	}
+	if _, err := MergeToolCommand("  ", files, "/tmp/page-s.js", "s", "js"); err == nil {
+		t.Error("Expected an error for an empty merge tool command")
+	}
+}
@@ -0,0 +1,104 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Session
+ * @tagline         Edit session identity by server, page and snippet
+ * @description     Identifies edit sessions by server URL, page URL and snippet ID, and
+ *                  derives readable, unique temp file names from them
+ * @file            desktop/bridge/session.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SessionKey identifies an edit session. Two pages with the same textarea ID are
+// different sessions. Page is empty for browsers that do not send a page URL.
+type SessionKey struct {
+	Server    string // WebSocket URL of the server
+	Page      string // page URL (origin, path and query) of the browser
+	SnippetID string
+}
+
+// String renders the session for logs, e.g. "code (example.com/demo)"
+func (k SessionKey) String() string {
+	if page := DisplayPage(k.Page); page != "" {
+		return k.SnippetID + " (" + page + ")"
+	}
+	return k.SnippetID
+}
+
+// SessionFields adds the message fields that identify a session to the server
+func (k SessionKey) SessionFields(msg map[string]interface{}) map[string]interface{} {
+	msg["snippetId"] = k.SnippetID
+	if k.Page != "" {
+		msg["pageUrl"] = k.Page
+	}
+	return msg
+}
+
+// DisplayPage shortens a page URL for display, without scheme
+func DisplayPage(pageURL string) string {
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		return strings.TrimSuffix(u.Host+u.Path, "/")
+	}
+	return pageURL
+}
+
+// SanitizeFileName replaces characters that are unsafe in file names, and limits the length
+func SanitizeFileName(s string, max int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if len(name) > max {
+		name = name[:max]
+	}
+	return name
+}
+
+// PageName returns a short readable name for a page URL: the last path segment
+// without extension, or the host name for the root page
+func PageName(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || pageURL == "" {
+		return ""
+	}
+	name := path.Base(strings.TrimSuffix(u.Path, "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if name == "" || name == "." || name == "/" || name == "index" {
+		name = u.Hostname()
+	}
+	return SanitizeFileName(name, 32)
+}
+
+// TempFilePath returns a readable, unique temp file name for a session, e.g.
+// web-code-demo-1a2b3c4d.js for snippet "code" on page https://example.com/demo.
+// The hash of server, page and snippet ID keeps names of different sessions apart.
+func TempFilePath(key SessionKey, fileType string) string {
+	name := "web-" + SanitizeFileName(key.SnippetID, 64)
+	if page := PageName(key.Page); page != "" {
+		name += "-" + page
+	}
+	sum := sha256.Sum256([]byte(key.Server + "\n" + key.Page + "\n" + key.SnippetID))
+	name += "-" + hex.EncodeToString(sum[:4])
+	return filepath.Join(os.TempDir(), name+"."+SanitizeFileName(fileType, 16))
+}
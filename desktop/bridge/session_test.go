@@ -0,0 +1,133 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Session Tests
+ * @tagline         Tests for edit session identity and temp file names
+ * @description     Tests that sessions of different servers, pages and snippets get
+ *                  different, readable and safe temp file names
+ * @file            desktop/bridge/session_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSessionKeyString(t *testing.T) {
+	tests := []struct {
+		key  SessionKey
+		want string
+	}{
+		{SessionKey{Server: "ws://localhost:8071/web-ide-bridge/ws", SnippetID: "code"}, "code"},
+		{SessionKey{Page: "https://example.com/demo/", SnippetID: "code"}, "code (example.com/demo)"},
+		{SessionKey{Page: "https://example.com/demo?id=1", SnippetID: "code"}, "code (example.com/demo)"},
+		{SessionKey{Page: "file-page", SnippetID: "code"}, "code (file-page)"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSessionFields(t *testing.T) {
+	msg := SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: "code"}.SessionFields(map[string]interface{}{"type": "code_update"})
+	if msg["snippetId"] != "code" || msg["pageUrl"] != "https://example.com/demo" || msg["type"] != "code_update" {
+		t.Errorf("unexpected fields %v", msg)
+	}
+	if _, ok := msg["server"]; ok {
+		t.Error("server URL sent to the server")
+	}
+	// Browsers without page URL get the fields they sent
+	msg = SessionKey{Server: "ws://a", SnippetID: "code"}.SessionFields(map[string]interface{}{})
+	if _, ok := msg["pageUrl"]; ok || msg["snippetId"] != "code" {
+		t.Errorf("unexpected fields %v", msg)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"code-1_a", 64, "code-1_a"},
+		{"../../etc/passwd", 64, "______etc_passwd"},
+		{"a b\\c:d", 64, "a_b_c_d"},
+		{"über", 64, "_ber"},
+		{"abcdefgh", 4, "abcd"},
+		{"", 16, ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeFileName(tt.in, tt.max); got != tt.want {
+			t.Errorf("SanitizeFileName(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestPageName(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "",
+		"https://example.com/":               "example_com",
+		"https://example.com/index.html":     "example_com",
+		"https://example.com/wiki/Main.html": "Main",
+		"https://example.com/edit/page-1/":   "page-1",
+		"https://example.com/a b?x=1":        "a_b",
+	}
+	for in, want := range tests {
+		if got := PageName(in); got != want {
+			t.Errorf("PageName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTempFilePath(t *testing.T) {
+	key := SessionKey{Server: "ws://localhost:8071/web-ide-bridge/ws", Page: "https://example.com/demo", SnippetID: "code"}
+	path := TempFilePath(key, "js")
+	if filepath.Dir(path) != filepath.Clean(os.TempDir()) {
+		t.Errorf("temp file %s not in the temp dir", path)
+	}
+	if ok, _ := regexp.MatchString(`^web-code-demo-[0-9a-f]{8}\.js$`, filepath.Base(path)); !ok {
+		t.Errorf("unexpected temp file name %s", filepath.Base(path))
+	}
+	if TempFilePath(key, "js") != path {
+		t.Error("temp file name not stable")
+	}
+
+	// Sessions that differ in any part get different names
+	others := []SessionKey{
+		{Server: "ws://other:8071/web-ide-bridge/ws", Page: key.Page, SnippetID: key.SnippetID},
+		{Server: key.Server, Page: "https://example.com/demo?id=2", SnippetID: key.SnippetID},
+		{Server: key.Server, Page: "", SnippetID: key.SnippetID},
+		{Server: key.Server, Page: key.Page, SnippetID: "code2"},
+		// Snippet IDs that sanitize to the same name
+		{Server: key.Server, Page: key.Page, SnippetID: "code?"},
+	}
+	seen := map[string]SessionKey{path: key}
+	for _, other := range others {
+		p := TempFilePath(other, "js")
+		if prev, ok := seen[p]; ok {
+			t.Errorf("sessions %+v and %+v share temp file %s", prev, other, p)
+		}
+		seen[p] = other
+	}
+
+	// Unsafe snippet IDs and fileTypes stay in the temp dir
+	path = TempFilePath(SessionKey{SnippetID: "../../x", Page: "https://example.com/"}, "../sh")
+	if filepath.Dir(path) != filepath.Clean(os.TempDir()) || strings.Contains(filepath.Base(path), "/") {
+		t.Errorf("unsafe temp file %s", path)
+	}
+	if !strings.HasSuffix(path, ".___sh") {
+		t.Errorf("fileType not sanitized in %s", path)
+	}
+}
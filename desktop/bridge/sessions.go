@@ -0,0 +1,92 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Sessions
+ * @tagline         Rows of the active sessions panel
+ * @description     Sync states of edit sessions, and the sorted rows and cells of the
+ *                  sessions panel
+ * @file            desktop/bridge/sessions.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"sort"
+	"time"
+)
+
+// Sync states of an edit session, as shown in the sessions panel
+const (
+	SyncStateSynced   = "synced"         // the browser has the content of the temp file
+	SyncStateUnsent   = "unsent changes" // saved in the IDE, but not delivered to the browser
+	SyncStateConflict = "conflict"       // the temp file has merge conflict markers
+	SyncStateInvalid  = "invalid"        // not sent, validation errors block the sync
+)
+
+// SessionInfo is a read-only view of an active edit session for the UI
+type SessionInfo struct {
+	Key       SessionKey
+	TmpFile   string
+	FileType  string
+	Mode      string
+	Editor    string
+	SyncState string
+	LastSave  time.Time
+	Batch     string
+	Transform string // type and detection, transform or binary encoding for the sessions panel, empty for plain text
+}
+
+// SessionColumns are the column titles of the sessions panel, see SessionInfo.Cell
+var SessionColumns = []string{"Snippet", "Page", "Type", "Editor", "Watch", "Sync", "Last Save", "File"}
+
+// Cell returns the text of a column of the sessions panel
+func (s SessionInfo) Cell(col int) string {
+	switch col {
+	case 0:
+		return s.Key.SnippetID
+	case 1:
+		return DisplayPage(s.Key.Page)
+	case 2:
+		if s.Transform != "" {
+			return s.Transform
+		}
+		return s.FileType
+	case 3:
+		return s.Editor
+	case 4:
+		return s.Mode
+	case 5:
+		return s.SyncState
+	case 6:
+		if s.LastSave.IsZero() {
+			return "-"
+		}
+		return s.LastSave.Format("15:04:05")
+	}
+	return s.TmpFile
+}
+
+// SortSessions sorts sessions by snippet ID and page
+func SortSessions(infos []SessionInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Key.SnippetID != infos[j].Key.SnippetID {
+			return infos[i].Key.SnippetID < infos[j].Key.SnippetID
+		}
+		return infos[i].Key.Page < infos[j].Key.Page
+	})
+}
+
+// SessionRow returns the row of a session, or -1 if it ended
+func SessionRow(infos []SessionInfo, key SessionKey) int {
+	for i, info := range infos {
+		if info.Key == key {
+			return i
+		}
+	}
+	return -1
+}
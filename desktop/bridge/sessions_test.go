@@ -0,0 +1,79 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Sessions Tests
+ * @tagline         Tests for the active sessions panel
+ * @description     Tests the cells, order and selected row of the sessions panel
+ * @file            desktop/bridge/sessions_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionInfoCells(t *testing.T) {
+	info := SessionInfo{
+		Key:       SessionKey{Server: "ws://a", Page: "https://example.com/demo?id=1", SnippetID: "code"},
+		TmpFile:   "/tmp/web-code-demo-1a2b3c4d.js",
+		FileType:  "js",
+		Mode:      WatchModePoll,
+		Editor:    "code",
+		SyncState: SyncStateUnsent,
+		LastSave:  time.Date(2025, 8, 23, 14, 5, 9, 0, time.Local),
+	}
+	want := []string{"code", "example.com/demo", "js", "code", "poll", "unsent changes", "14:05:09", "/tmp/web-code-demo-1a2b3c4d.js"}
+	if len(want) != len(SessionColumns) {
+		t.Fatalf("%d columns, want %d", len(SessionColumns), len(want))
+	}
+	for col := range SessionColumns {
+		if got := info.Cell(col); got != want[col] {
+			t.Errorf("%s = %q, want %q", SessionColumns[col], got, want[col])
+		}
+	}
+
+	// Not saved yet, and a transform shown instead of the fileType
+	info.LastSave = time.Time{}
+	info.Transform = "json → yaml"
+	if got := info.Cell(6); got != "-" {
+		t.Errorf("Last Save before the first save = %q, want -", got)
+	}
+	if got := info.Cell(2); got != "json → yaml" {
+		t.Errorf("Type with transform = %q", got)
+	}
+}
+
+func TestSortSessionsAndSessionRow(t *testing.T) {
+	infos := []SessionInfo{
+		{Key: SessionKey{Page: "https://example.com/b", SnippetID: "style"}},
+		{Key: SessionKey{Page: "https://example.com/b", SnippetID: "code"}},
+		{Key: SessionKey{Page: "https://example.com/a", SnippetID: "code"}},
+		{Key: SessionKey{SnippetID: "code"}},
+	}
+	SortSessions(infos)
+	var got []string
+	for _, info := range infos {
+		got = append(got, info.Key.String())
+	}
+	want := "[code code (example.com/a) code (example.com/b) style (example.com/b)]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("sorted sessions %v, want %s", got, want)
+	}
+
+	// The selection follows its session to its new row, and is lost when the session ends
+	selected := SessionKey{Page: "https://example.com/b", SnippetID: "code"}
+	if row := SessionRow(infos, selected); row != 2 {
+		t.Errorf("selected row %d, want 2", row)
+	}
+	if row := SessionRow(infos[:2], selected); row != -1 {
+		t.Errorf("ended session in row %d, want -1", row)
+	}
+}
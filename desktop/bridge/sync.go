@@ -0,0 +1,62 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Sync
+ * @tagline         Echo suppression of browser updates
+ * @description     Remembers the content the browser already has, so that updates written
+ *                  from the browser are not sent back, and decides how a browser update applies
+ * @file            desktop/bridge/sync.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// SyncedHash holds the hash of the temp file content the browser already has
+type SyncedHash struct {
+	mu   sync.Mutex
+	hash [sha256.Size]byte
+	set  bool
+}
+
+// Set records content that the browser already has
+func (s *SyncedHash) Set(hash [sha256.Size]byte) {
+	s.mu.Lock()
+	s.hash, s.set = hash, true
+	s.mu.Unlock()
+}
+
+// Is reports whether content is what the browser already has
+func (s *SyncedHash) Is(hash [sha256.Size]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set && s.hash == hash
+}
+
+// How a browser update applies to the temp file
+const (
+	UpdateApply    = "apply"    // write the update to the temp file
+	UpdateSynced   = "synced"   // the temp file already has the update
+	UpdateRejected = "rejected" // the temp file has local changes that were not sent yet
+)
+
+// BrowserUpdateAction decides how a browser update applies to the temp file: the local
+// content, or an error if it is not valid text, and the last synced base version, or an
+// error if there is none. The update only replaces content that matches the base version.
+func BrowserUpdateAction(update, local string, errLocal error, base string, errBase error) string {
+	if errLocal == nil && local == update {
+		return UpdateSynced
+	}
+	if errLocal != nil || errBase != nil || local != base {
+		return UpdateRejected
+	}
+	return UpdateApply
+}
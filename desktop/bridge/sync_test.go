@@ -0,0 +1,88 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Sync Tests
+ * @tagline         Tests for echo suppression of browser updates
+ * @description     Tests that updates written from the browser are not sent back, and
+ *                  when a browser update applies to the temp file
+ * @file            desktop/bridge/sync_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncedHashSuppressesEcho(t *testing.T) {
+	var synced SyncedHash
+	path := filepath.Join(t.TempDir(), "snippet.js")
+	if synced.Is(sha256.Sum256(nil)) {
+		t.Fatal("nothing is synced before the first update")
+	}
+
+	// The browser update is recorded before it is written, so the watcher sees it as synced
+	update := []byte("let a = 2;\n")
+	synced.Set(sha256.Sum256(update))
+	if err := os.WriteFile(path, update, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, snap, err := ReadFileSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synced.Is(snap.Hash) {
+		t.Error("browser update not recognized as echo")
+	}
+
+	// A save in the IDE is a new change
+	if err := os.WriteFile(path, []byte("let a = 3;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, snap, _ = ReadFileSnapshot(path)
+	if synced.Is(snap.Hash) {
+		t.Error("IDE save suppressed as echo")
+	}
+
+	// Once sent, the IDE content is what the browser has
+	synced.Set(snap.Hash)
+	if !synced.Is(snap.Hash) || synced.Is(sha256.Sum256(update)) {
+		t.Error("synced hash not replaced")
+	}
+}
+
+func TestBrowserUpdateAction(t *testing.T) {
+	invalid := errors.New("invalid UTF-8")
+	missing := errors.New("no base version")
+	tests := []struct {
+		name     string
+		update   string
+		local    string
+		errLocal error
+		base     string
+		errBase  error
+		want     string
+	}{
+		{"unchanged temp file", "b\n", "a\n", nil, "a\n", nil, UpdateApply},
+		{"temp file has the update", "b\n", "b\n", nil, "a\n", nil, UpdateSynced},
+		{"temp file has the update, no base", "b\n", "b\n", nil, "", missing, UpdateSynced},
+		{"local changes not sent", "b\n", "c\n", nil, "a\n", nil, UpdateRejected},
+		{"no base version", "b\n", "a\n", nil, "", missing, UpdateRejected},
+		{"local content not valid text", "b\n", "", invalid, "", nil, UpdateRejected},
+		{"empty update of empty file", "", "", nil, "", nil, UpdateSynced},
+	}
+	for _, tt := range tests {
+		if got := BrowserUpdateAction(tt.update, tt.local, tt.errLocal, tt.base, tt.errBase); got != tt.want {
+			t.Errorf("%s: BrowserUpdateAction = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
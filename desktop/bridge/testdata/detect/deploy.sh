@@ -0,0 +1,6 @@
+export ENV=prod
+if [ -z "$TARGET" ]; then
+  echo "missing target"
+  exit 1
+fi
+ls build | grep -v tmp | xargs -I{} cp build/{} "$TARGET"
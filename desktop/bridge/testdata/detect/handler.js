@@ -0,0 +1,6 @@
+const button = document.querySelector('#save');
+button.addEventListener('click', (event) => {
+  if (event.detail === 2) {
+    console.log('double click');
+  }
+});
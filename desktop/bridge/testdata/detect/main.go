@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+func main() {
+	msg, err := greet("world")
+	if err != nil {
+		return
+	}
+	fmt.Println(msg)
+}
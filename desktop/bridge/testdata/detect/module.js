@@ -0,0 +1,6 @@
+import { render } from './view.js';
+
+export function update(state) {
+  let count = state.items.length;
+  return render(state, count);
+}
@@ -0,0 +1,5 @@
+-- active users
+SELECT id, name
+  FROM users
+ WHERE active = 1
+ ORDER BY name;
@@ -0,0 +1,8 @@
+import json
+
+def load(path):
+    with open(path) as f:
+        return json.load(f)
+
+if __name__ == "__main__":
+    print(load("data.json"))
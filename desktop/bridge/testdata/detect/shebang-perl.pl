@@ -0,0 +1,3 @@
+#!/usr/bin/perl -w
+use strict;
+print "ok\n";
@@ -0,0 +1,7 @@
+require 'json'
+
+def summarize(items)
+  items.each do |item|
+    puts item['name']
+  end
+end
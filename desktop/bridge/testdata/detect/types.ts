@@ -0,0 +1,9 @@
+interface User {
+  id: number;
+  name: string;
+}
+
+export function greet(user: User): string {
+  const prefix = 'Hello';
+  return `${prefix}, ${user.name}`;
+}
@@ -0,0 +1,303 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Transform
+ * @tagline         Built-in content transform codecs
+ * @description     Lossless conversions between the form of a snippet in the browser and
+ *                  the form edited in the IDE: pretty JSON, JSON as YAML, unescaped strings
+ * @file            desktop/bridge/transform.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codec is a built-in pair of conversions; FileType is the type of the decoded code,
+// empty to keep the type of the snippet
+type Codec struct {
+	FileType string
+	Decode   func(string) (string, error)
+	Encode   func(string) (string, error)
+}
+
+// Codecs are the built-in transforms by name
+var Codecs = map[string]Codec{
+	"json-pretty": {FileType: "json", Decode: prettyJSON, Encode: compactJSON},
+	"json-yaml":   {FileType: "yaml", Decode: jsonToYAML, Encode: yamlToJSON},
+	"unescape":    {Decode: unescapeString, Encode: escapeString},
+}
+
+// DecodeLossless decodes browser code, and verifies that encoding the result gives the
+// code back unchanged, so that a transform never alters a snippet that is not edited
+func DecodeLossless(code string, decode, encode func(string) (string, error)) (string, error) {
+	decoded, err := decode(code)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := encode(decoded)
+	if err != nil {
+		return "", fmt.Errorf("decoded code does not encode back: %v", err)
+	}
+	if encoded != code {
+		return "", fmt.Errorf("the code does not convert back to the same form")
+	}
+	return decoded, nil
+}
+
+// prettyJSON indents minified JSON with two spaces, preserving numbers and string escapes
+func prettyJSON(code string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(code), "", "  "); err != nil {
+		return "", err
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
+
+// compactJSON removes insignificant whitespace from JSON
+func compactJSON(code string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(code)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jsonToYAML converts JSON to YAML, preserving the order of keys and the literal form
+// of numbers
+func jsonToYAML(code string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(code))
+	decoder.UseNumber()
+	node, err := jsonNode(decoder)
+	if err != nil {
+		return "", err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return "", fmt.Errorf("unexpected data after the JSON value")
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jsonNode reads the next JSON value as a YAML node
+func jsonNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := jsonNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		// Closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(v), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(v)}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// yamlToJSON converts one YAML document to minified JSON in the form of JSON.stringify
+func yamlToJSON(code string) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(code))
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return "", fmt.Errorf("expected one YAML document")
+		}
+		return "", err
+	}
+	var next yaml.Node
+	if err := decoder.Decode(&next); err != io.EOF {
+		return "", fmt.Errorf("expected one YAML document")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 {
+		return "", fmt.Errorf("expected one YAML document")
+	}
+	var buf strings.Builder
+	if err := writeJSONNode(&buf, doc.Content[0]); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeJSONNode writes a YAML node as JSON
+func writeJSONNode(buf *strings.Builder, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeJSONNode(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(quoteJSON(node.Content[i].Value))
+			buf.WriteByte(':')
+			if err := writeJSONNode(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		value, err := jsonScalar(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(value)
+	default:
+		return fmt.Errorf("line %d: unsupported YAML node", node.Line)
+	}
+	return nil
+}
+
+// jsonScalar converts a YAML scalar to a JSON literal; numbers that are valid JSON keep
+// their literal form
+func jsonScalar(node *yaml.Node) (string, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return "null", nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case "!!int":
+		if json.Valid([]byte(node.Value)) {
+			return node.Value, nil
+		}
+		n, ok := new(big.Int).SetString(strings.ReplaceAll(node.Value, "_", ""), 0)
+		if !ok {
+			return "", fmt.Errorf("line %d: invalid integer %q", node.Line, node.Value)
+		}
+		return n.String(), nil
+	case "!!float":
+		if json.Valid([]byte(node.Value)) {
+			return node.Value, nil
+		}
+		f, err := strconv.ParseFloat(node.Value, 64)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %q is not a JSON number", node.Line, node.Value)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	return quoteJSON(node.Value), nil
+}
+
+// quoteJSON quotes a string as JSON.stringify does: only quotes, backslashes and control
+// characters are escaped
+func quoteJSON(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	buf.WriteString(escapeJSON(s))
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// escapeJSON escapes a string for a JSON string literal, as JSON.stringify does
+func escapeJSON(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// unescapeString turns a JSON-escaped string without quotes into text. A final newline
+// is added, which editors expect and escapeString removes again.
+func unescapeString(code string) (string, error) {
+	var text string
+	if err := json.Unmarshal([]byte(`"`+code+`"`), &text); err != nil {
+		return "", fmt.Errorf("not an escaped string: %v", err)
+	}
+	return text + "\n", nil
+}
+
+// escapeString turns text into a JSON-escaped string without quotes
+func escapeString(code string) (string, error) {
+	return escapeJSON(strings.TrimSuffix(code, "\n")), nil
+}
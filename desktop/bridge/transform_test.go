@@ -0,0 +1,140 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Transform Tests
+ * @tagline         Tests for the built-in transform codecs
+ * @description     Tests that the built-in codecs round-trip JSON.stringify output and
+ *                  reject code they cannot convert back unchanged
+ * @file            desktop/bridge/transform_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"strings"
+	"testing"
+)
+
+// transformJSONCorpus is minified JSON in the form of JSON.stringify
+var transformJSONCorpus = []string{
+	`{}`,
+	`[]`,
+	`null`,
+	`"text"`,
+	`{"a":1,"b":[1,2,3],"c":{"d":null,"e":true,"f":false}}`,
+	`{"z":1,"a":2,"m":3}`,
+	`{"big":12345678901234567890123,"neg":-42,"zero":0}`,
+	`{"f":1.0,"g":1e5,"h":-2.5E-3,"i":0.1}`,
+	`{"s":["true","123","null","1.0","yes","no","~",""]}`,
+	`{"unicode":"héllo wörld ✓ 日本語 😀"}`,
+	`{"escapes":"line1\nline2\ttab \"quoted\" back\\slash"}`,
+	`{"control":"\u0001\u001f"}`,
+	`{"key with spaces":{"nested":[[],{},[{}]]}}`,
+	`{"":"empty key"}`,
+	`[{"id":1,"tags":["a","b"]},{"id":2,"tags":[]}]`,
+	`{"colon":"a: b","dash":"- item","hash":"# comment","multi":"a\nb\n"}`,
+}
+
+func TestTransformJSONRoundTrip(t *testing.T) {
+	for _, name := range []string{"json-pretty", "json-yaml"} {
+		codec := Codecs[name]
+		for _, code := range transformJSONCorpus {
+			decoded, err := codec.Decode(code)
+			if err != nil {
+				t.Errorf("%s: decode %s: %v", name, code, err)
+				continue
+			}
+			encoded, err := codec.Encode(decoded)
+			if err != nil {
+				t.Errorf("%s: encode %q: %v", name, decoded, err)
+				continue
+			}
+			if encoded != code {
+				t.Errorf("%s: round trip changed code:\n  got:  %s\n  want: %s", name, encoded, code)
+			}
+		}
+	}
+}
+
+func TestTransformJSONYAMLKeepsKeyOrder(t *testing.T) {
+	decoded, err := jsonToYAML(`{"z":1,"a":{"y":true,"b":"x"}}`)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := "z: 1\na:\n  y: true\n  b: x\n"
+	if decoded != want {
+		t.Errorf("Expected YAML %q, got %q", want, decoded)
+	}
+
+	// Edits in the IDE are encoded back to minified JSON
+	encoded, err := yamlToJSON("z: 2\na:\n  y: false\n  b: \"x\"\n  c: [1, 2]\n")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if encoded != `{"z":2,"a":{"y":false,"b":"x","c":[1,2]}}` {
+		t.Errorf("Unexpected JSON %s", encoded)
+	}
+}
+
+func TestTransformUnescapeRoundTrip(t *testing.T) {
+	corpus := []string{
+		``,
+		`plain text`,
+		`function f() {\n  return \"ok\";\n}`,
+		`tab\there`,
+		`back\\slash`,
+		`unicode ✓ 😀`,
+		`control \u0001`,
+		`trailing newline\n`,
+	}
+	codec := Codecs["unescape"]
+	for _, code := range corpus {
+		decoded, err := DecodeLossless(code, codec.Decode, codec.Encode)
+		if err != nil {
+			t.Errorf("unescape %q: %v", code, err)
+			continue
+		}
+		if !strings.HasSuffix(decoded, "\n") {
+			t.Errorf("Expected decoded text of %q to end with a newline", code)
+		}
+	}
+
+	decoded, _ := unescapeString(`a\nb`)
+	if decoded != "a\nb\n" {
+		t.Errorf("Expected unescaped text %q, got %q", "a\nb\n", decoded)
+	}
+}
+
+func TestTransformRejectsLossyCode(t *testing.T) {
+	cases := []struct {
+		codec string
+		code  string
+	}{
+		{"json-pretty", `{"a": 1}`},      // not minified
+		{"json-pretty", `{"a":1`},        // invalid
+		{"json-yaml", `{"a":"\u00e9"}`},  // escape that JSON.stringify does not produce
+		{"json-yaml", `{"a":1} {"b":2}`}, // several values
+		{"unescape", `say \/ hello`},     // escape that JSON.stringify does not produce
+		{"unescape", `unterminated \`},   // invalid
+		{"unescape", `bad \x41 escape`},  // invalid
+		{"unescape", `raw "quote"`},      // unescaped quote
+	}
+	for _, tc := range cases {
+		if _, err := DecodeLossless(tc.code, Codecs[tc.codec].Decode, Codecs[tc.codec].Encode); err == nil {
+			t.Errorf("%s: expected %s to be rejected", tc.codec, tc.code)
+		}
+	}
+}
+
+func TestTransformYAMLEncodeErrors(t *testing.T) {
+	for _, code := range []string{"a: [1, 2\n", "a: 1\n---\nb: 2\n", "a: .nan\n", ""} {
+		if _, err := yamlToJSON(code); err == nil {
+			t.Errorf("Expected YAML %q not to encode to JSON", code)
+		}
+	}
+}
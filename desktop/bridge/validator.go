@@ -0,0 +1,325 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Validator
+ * @tagline         Validation pipeline with diagnostics for the browser
+ * @description     Checks saved snippets with built-in parsers for JSON, XML and YAML, or
+ *                  external linters, and turns their output into diagnostics
+ * @file            desktop/bridge/validator.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validation policies: send code with its diagnostics, or keep code with errors local
+const (
+	ValidationWarn  = "warn"
+	ValidationBlock = "block"
+)
+
+// Severities of diagnostics
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// MaxDiagnostics is the number of diagnostics sent per save
+const MaxDiagnostics = 100
+
+// Diagnostic is one problem found in a snippet, sent to the browser with code_update
+type Diagnostic struct {
+	Line     int    `json:"line"`   // 1-based, 0 if unknown
+	Column   int    `json:"column"` // 1-based, 0 if unknown
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Source   string `json:"source"` // validator that reported it, e.g. json or eslint
+}
+
+// String formats a diagnostic for the activity log
+func (d Diagnostic) String() string {
+	position := ""
+	if d.Line > 0 {
+		position = fmt.Sprintf("line %d, ", d.Line)
+		if d.Column > 0 {
+			position = fmt.Sprintf("line %d, column %d, ", d.Line, d.Column)
+		}
+	}
+	return fmt.Sprintf("%s%s: %s (%s)", position, d.Severity, d.Message, d.Source)
+}
+
+// Validator is one step of the validation pipeline: a built-in parser, or an external
+// linter that reads the code on stdin. Linter output lines are matched with Match, a
+// regular expression with the named groups line, column, severity and message.
+type Validator struct {
+	Pattern   string `json:"pattern"`              // fileTypes or file name globs, as in IDE mappings
+	Builtin   string `json:"builtin,omitempty"`    // json, xml or yaml
+	Command   string `json:"command,omitempty"`    // e.g. `eslint --stdin --stdin-filename {file} --format unix`
+	Match     string `json:"match,omitempty"`      // defaults to file:line:column: severity: message
+	TimeoutMs int    `json:"timeout_ms,omitempty"` // defaults to 10 seconds
+}
+
+// DefaultValidators check data formats with the built-in parsers when no validators are configured
+var DefaultValidators = []Validator{
+	{Pattern: "json", Builtin: "json"},
+	{Pattern: "xml, svg, xsd, xsl, xslt", Builtin: "xml"},
+	{Pattern: "yaml, yml", Builtin: "yaml"},
+}
+
+// defaultDiagnosticMatch matches the file:line:column: severity: message format of gcc,
+// eslint --format unix, shellcheck -f gcc and yamllint -f parsable; file, column and
+// severity are optional
+var defaultDiagnosticMatch = regexp.MustCompile(`(?i)^(?:.*?:)?(?P<line>\d+):(?:(?P<column>\d+):)?\s*(?:\[?(?P<severity>error|warning|warn|info|note)\]?:?\s+)?(?P<message>\S.*)$`)
+
+// NormalizeValidationPolicy maps unknown or empty values to warn
+func NormalizeValidationPolicy(policy string) string {
+	if policy == ValidationBlock {
+		return policy
+	}
+	return ValidationWarn
+}
+
+// Name returns the source name of a validator for diagnostics
+func (v Validator) Name() string {
+	if v.Builtin != "" {
+		return v.Builtin
+	}
+	words, err := SplitCommandLine(v.Command)
+	if err != nil || len(words) == 0 {
+		return "validator"
+	}
+	return EditorCommandName(words[0])
+}
+
+// Run validates code and returns its diagnostics
+func (v Validator) Run(code []byte, key SessionKey, tmpFile, fileType string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	switch v.Builtin {
+	case "":
+		return v.runCommand(code, key, tmpFile, fileType)
+	case "json":
+		diagnostics = validateJSON(code)
+	case "xml":
+		diagnostics = validateXML(code)
+	case "yaml":
+		diagnostics = validateYAML(code)
+	default:
+		return nil, fmt.Errorf("unknown built-in validator %q", v.Builtin)
+	}
+	for i := range diagnostics {
+		diagnostics[i].Source = v.Name()
+	}
+	return diagnostics, nil
+}
+
+// runCommand runs an external linter and parses its output. A linter that exits with
+// an error without any matching output line is reported as one error.
+func (v Validator) runCommand(code []byte, key SessionKey, tmpFile, fileType string) ([]Diagnostic, error) {
+	match := defaultDiagnosticMatch
+	if v.Match != "" {
+		var err error
+		if match, err = regexp.Compile(v.Match); err != nil {
+			return nil, fmt.Errorf("invalid match expression: %v", err)
+		}
+	}
+	stdout, stderr, err := RunTool(v.Command, ToolTimeout(v.TimeoutMs), code, key, tmpFile, fileType)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	defaultSeverity := SeverityWarning
+	if err != nil {
+		defaultSeverity = SeverityError
+	}
+	output := strings.TrimSpace(string(stdout) + "\n" + string(stderr))
+	diagnostics := ParseDiagnostics(output, match, defaultSeverity, v.Name())
+	if err != nil && len(diagnostics) == 0 {
+		message := err.Error()
+		if output != "" {
+			message = strings.SplitN(output, "\n", 2)[0]
+		}
+		diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, Message: message, Source: v.Name()})
+	}
+	return diagnostics, nil
+}
+
+// ParseDiagnostics turns linter output lines into diagnostics; lines that do not match
+// are ignored
+func ParseDiagnostics(output string, match *regexp.Regexp, defaultSeverity, source string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		groups := match.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if groups == nil {
+			continue
+		}
+		d := Diagnostic{Severity: defaultSeverity, Source: source}
+		for i, name := range match.SubexpNames() {
+			switch name {
+			case "line":
+				d.Line, _ = strconv.Atoi(groups[i])
+			case "column":
+				d.Column, _ = strconv.Atoi(groups[i])
+			case "severity":
+				if groups[i] != "" {
+					d.Severity = normalizeSeverity(groups[i])
+				}
+			case "message":
+				d.Message = strings.TrimSpace(groups[i])
+			}
+		}
+		if d.Message != "" {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// normalizeSeverity maps linter severities to error, warning or info
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "fatal", "e":
+		return SeverityError
+	case "warning", "warn", "w":
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// LineColumn returns the 1-based line and column of a byte offset
+func LineColumn(code []byte, offset int64) (int, int) {
+	if offset > int64(len(code)) {
+		offset = int64(len(code))
+	}
+	before := code[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// validateJSON checks that code is one JSON value
+func validateJSON(code []byte) []Diagnostic {
+	var value interface{}
+	err := json.Unmarshal(code, &value)
+	if err == nil {
+		return nil
+	}
+	d := Diagnostic{Severity: SeverityError, Message: err.Error()}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset is after the invalid character
+		offset := syntaxErr.Offset
+		if offset > 0 {
+			offset--
+		}
+		d.Line, d.Column = LineColumn(code, offset)
+	}
+	return []Diagnostic{d}
+}
+
+// validateXML checks that code is well-formed XML
+func validateXML(code []byte) []Diagnostic {
+	decoder := xml.NewDecoder(bytes.NewReader(code))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			d := Diagnostic{Severity: SeverityError, Message: err.Error()}
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				d.Message = syntaxErr.Msg
+				d.Line = syntaxErr.Line
+			}
+			if line, column := decoder.InputPos(); line == d.Line || d.Line == 0 {
+				d.Line, d.Column = line, column
+			}
+			return []Diagnostic{d}
+		}
+	}
+}
+
+// yamlErrorLine matches the line number in yaml error messages
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// validateYAML checks that code is a stream of valid YAML documents
+func validateYAML(code []byte) []Diagnostic {
+	decoder := yaml.NewDecoder(bytes.NewReader(code))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			d := Diagnostic{Severity: SeverityError, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+			if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+				d.Line, _ = strconv.Atoi(m[1])
+				d.Message = strings.TrimPrefix(err.Error(), m[0])
+			}
+			return []Diagnostic{d}
+		}
+	}
+}
+
+// Validate runs the validators that match a snippet and returns their diagnostics, at
+// most MaxDiagnostics. A validator that cannot run is skipped and passed to failed, so it
+// never blocks the sync.
+func Validate(validators []Validator, code []byte, key SessionKey, tmpFile, fileType string, failed func(Validator, error)) []Diagnostic {
+	var diagnostics []Diagnostic
+	fileName := filepath.Base(tmpFile)
+	for _, v := range validators {
+		if !MatchesFileType(v.Pattern, fileType, fileName) {
+			continue
+		}
+		found, err := v.Run(code, key, tmpFile, fileType)
+		if err != nil {
+			failed(v, err)
+			continue
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+	if len(diagnostics) > MaxDiagnostics {
+		diagnostics = diagnostics[:MaxDiagnostics]
+	}
+	return diagnostics
+}
+
+// BlocksSync reports whether diagnostics keep code from being sent under a policy
+func BlocksSync(policy string, diagnostics []Diagnostic) bool {
+	if NormalizeValidationPolicy(policy) != ValidationBlock {
+		return false
+	}
+	return CountSeverity(diagnostics, SeverityError) > 0
+}
+
+// CountSeverity counts the diagnostics of a severity
+func CountSeverity(diagnostics []Diagnostic, severity string) int {
+	n := 0
+	for _, d := range diagnostics {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
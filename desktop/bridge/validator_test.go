@@ -0,0 +1,177 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Validator Tests
+ * @tagline         Tests for the validation pipeline
+ * @description     Tests the built-in parsers, parsing of linter output, external linters,
+ *                  and whether diagnostics block the sync under a policy
+ * @file            desktop/bridge/validator_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinValidators(t *testing.T) {
+	tests := []struct {
+		builtin, code string
+		line, column  int // of the error, -1 for valid code
+	}{
+		{"json", `{"a": [1, 2]}`, -1, -1},
+		{"json", "{\n  \"a\": 1,\n  \"b\" 2\n}", 3, 7},
+		{"json", `{"a": 1`, 1, 7},
+		{"xml", `<a><b x="1"/></a>`, -1, -1},
+		{"xml", "<a>\n  <b>\n</a>", 3, 5},
+		{"yaml", "a: 1\n---\nb: [1, 2]\n", -1, -1},
+		{"yaml", "a: 1\n---\nb: 1\n  c: 2\n", 4, 0},
+	}
+	for _, tt := range tests {
+		v := Validator{Pattern: tt.builtin, Builtin: tt.builtin}
+		diagnostics, err := v.Run([]byte(tt.code), SessionKey{}, "snippet", tt.builtin)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.builtin, tt.code, err)
+			continue
+		}
+		if tt.line < 0 {
+			if len(diagnostics) != 0 {
+				t.Errorf("%s %q: unexpected %v", tt.builtin, tt.code, diagnostics)
+			}
+			continue
+		}
+		if len(diagnostics) != 1 {
+			t.Errorf("%s %q: diagnostics %v, want one", tt.builtin, tt.code, diagnostics)
+			continue
+		}
+		d := diagnostics[0]
+		if d.Line != tt.line || d.Column != tt.column || d.Severity != SeverityError || d.Source != tt.builtin || d.Message == "" {
+			t.Errorf("%s %q: %+v, want line %d, column %d", tt.builtin, tt.code, d, tt.line, tt.column)
+		}
+	}
+	if _, err := (Validator{Builtin: "toml"}).Run(nil, SessionKey{}, "snippet", "toml"); err == nil {
+		t.Error("unknown built-in validator accepted")
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	output := strings.Join([]string{
+		"/tmp/code.js:3:7: error: 'a' is not defined",
+		"/tmp/code.js:5: warning: unused variable\r",
+		"code.sh:12:1: note: double quote to prevent globbing",
+		"code.js:7:2: [Warn] missing semicolon",
+		"9: plain message",
+		"not a diagnostic",
+		"",
+		"2 problems",
+	}, "\n")
+	got := ParseDiagnostics(output, defaultDiagnosticMatch, SeverityWarning, "lint")
+	want := []Diagnostic{
+		{Line: 3, Column: 7, Severity: SeverityError, Message: "'a' is not defined", Source: "lint"},
+		{Line: 5, Severity: SeverityWarning, Message: "unused variable", Source: "lint"},
+		{Line: 12, Column: 1, Severity: SeverityInfo, Message: "double quote to prevent globbing", Source: "lint"},
+		{Line: 7, Column: 2, Severity: SeverityWarning, Message: "missing semicolon", Source: "lint"},
+		{Line: 9, Severity: SeverityWarning, Message: "plain message", Source: "lint"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("diagnostics\n%v\nwant\n%v", got, want)
+	}
+
+	// Custom expressions name the groups they have
+	match := regexp.MustCompile(`^(?P<severity>[EW])(?P<line>\d+) (?P<message>.*)$`)
+	got = ParseDiagnostics("E12 syntax error\nW3 too long\n", match, SeverityInfo, "custom")
+	if len(got) != 2 || got[0].Severity != SeverityError || got[0].Line != 12 || got[1].Severity != SeverityWarning || got[1].Message != "too long" {
+		t.Errorf("custom diagnostics %v", got)
+	}
+}
+
+func TestValidatePipeline(t *testing.T) {
+	skipWithoutShell(t)
+	tmpFile := filepath.Join(t.TempDir(), "config.json")
+	validators := []Validator{
+		{Pattern: "json", Builtin: "json"},
+		{Pattern: "yaml", Builtin: "yaml"}, // does not match
+		// A linter that reports a warning and exits with success
+		{Pattern: "*.json", Command: "sh -c 'echo \"{file}:1:2: warning: style\"'"},
+		// A linter that fails without matching output is one error
+		{Pattern: "json", Command: "sh -c 'echo crashed >&2; exit 3'"},
+		// Lines without severity get warning, or error if the linter failed
+		{Pattern: "json", Command: "sh -c 'echo \"4: bad\"; exit 1'"},
+		{Pattern: "json", Command: "no-such-linter-web-ide-bridge"},
+		{Pattern: "json", Command: "true", Match: "("},
+	}
+	var failed []string
+	diagnostics := Validate(validators, []byte(`{"a" 1}`), SessionKey{SnippetID: "config"}, tmpFile, "json", func(v Validator, err error) {
+		failed = append(failed, v.Name())
+	})
+	want := []string{
+		"line 1, column 6, error: invalid character '1' after object key (json)",
+		"line 1, column 2, warning: style (sh)",
+		"error: crashed (sh)",
+		"line 4, error: bad (sh)",
+	}
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, d.String())
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("diagnostics\n%q\nwant\n%q", got, want)
+	}
+	if fmt.Sprint(failed) != "[no-such-linter-web-ide-bridge true]" {
+		t.Errorf("failed validators %v", failed)
+	}
+
+	// At most MaxDiagnostics are sent
+	many := []Validator{{Pattern: "txt", Command: fmt.Sprintf("seq -f '%%g: issue' %d", MaxDiagnostics+20)}}
+	if diagnostics := Validate(many, nil, SessionKey{}, filepath.Join(t.TempDir(), "a.txt"), "txt", func(Validator, error) {}); len(diagnostics) != MaxDiagnostics {
+		t.Errorf("%d diagnostics, want %d", len(diagnostics), MaxDiagnostics)
+	}
+}
+
+func TestBlocksSync(t *testing.T) {
+	warning := []Diagnostic{{Severity: SeverityWarning}, {Severity: SeverityInfo}}
+	errs := append([]Diagnostic{{Severity: SeverityError}}, warning...)
+	tests := []struct {
+		policy      string
+		diagnostics []Diagnostic
+		want        bool
+	}{
+		{ValidationBlock, errs, true},
+		{ValidationBlock, warning, false},
+		{ValidationBlock, nil, false},
+		{ValidationWarn, errs, false},
+		{"", errs, false},
+		{"strict", errs, false},
+	}
+	for _, tt := range tests {
+		if got := BlocksSync(tt.policy, tt.diagnostics); got != tt.want {
+			t.Errorf("BlocksSync(%q, %v) = %v, want %v", tt.policy, tt.diagnostics, got, tt.want)
+		}
+	}
+	if n := CountSeverity(errs, SeverityWarning); n != 1 {
+		t.Errorf("CountSeverity = %d", n)
+	}
+}
+
+func TestValidatorName(t *testing.T) {
+	tests := map[Validator]string{
+		{Builtin: "json"}:                                   "json",
+		{Command: "/usr/local/bin/eslint --stdin"}:          "eslint",
+		{Command: "'C:/Program Files/lint/lint.exe' --fix"}: "lint",
+		{Command: ""}:                                       "validator",
+	}
+	for v, want := range tests {
+		if got := v.Name(); got != want {
+			t.Errorf("%+v.Name() = %q, want %q", v, got, want)
+		}
+	}
+}
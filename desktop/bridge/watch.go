@@ -0,0 +1,136 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Watch
+ * @tagline         Watch modes and polling of temp files
+ * @description     Picks fsnotify or polling for a temp file, and detects changes by
+ *                  mtime, size and content hash when file system events are missing
+ * @file            desktop/bridge/watch.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Watch modes: "auto" uses fsnotify and switches to polling if events are not delivered
+const (
+	WatchModeAuto     = "auto"
+	WatchModeFsnotify = "fsnotify"
+	WatchModePoll     = "poll"
+)
+
+// DefaultPollIntervalMs is the polling interval if none is configured
+const DefaultPollIntervalMs = 1000
+
+// FileSnapshot identifies a version of a file by mtime, size and content hash
+type FileSnapshot struct {
+	ModTime time.Time
+	Size    int64
+	Hash    [sha256.Size]byte
+}
+
+// StatChanged reports whether mtime or size differ from another snapshot
+func (s FileSnapshot) StatChanged(info os.FileInfo) bool {
+	return !info.ModTime().Equal(s.ModTime) || info.Size() != s.Size
+}
+
+// ReadFileSnapshot reads a file and returns its content and snapshot
+func ReadFileSnapshot(path string) ([]byte, FileSnapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, FileSnapshot{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, FileSnapshot{}, err
+	}
+	return content, FileSnapshot{
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Hash:    sha256.Sum256(content),
+	}, nil
+}
+
+// NormalizeWatchMode maps unknown or empty values to auto
+func NormalizeWatchMode(mode string) string {
+	switch mode {
+	case WatchModeFsnotify, WatchModePoll:
+		return mode
+	}
+	return WatchModeAuto
+}
+
+// PollInterval returns the configured polling interval
+func PollInterval(ms int) time.Duration {
+	if ms <= 0 {
+		ms = DefaultPollIntervalMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ResolveWatchMode picks the effective watch mode for a file, and why
+func ResolveWatchMode(mode, path string) (string, string) {
+	switch NormalizeWatchMode(mode) {
+	case WatchModePoll:
+		return WatchModePoll, "configured"
+	case WatchModeFsnotify:
+		return WatchModeFsnotify, "configured"
+	}
+	if remote, fsName := isNetworkFilesystem(filepath.Dir(path)); remote {
+		return WatchModePoll, "detected " + fsName + " filesystem"
+	}
+	return WatchModeFsnotify, "auto"
+}
+
+// PollChanged reports whether a poll tick found the file changed since the last
+// snapshot. With file system events, the event gets one interval to arrive before the
+// change is reported; missed is then true, and the watcher switches to polling.
+func PollChanged(last FileSnapshot, info os.FileInfo, events bool, interval time.Duration, now time.Time) (changed, missed bool) {
+	if !last.StatChanged(info) {
+		return false, false
+	}
+	if events {
+		if now.Sub(info.ModTime()) < interval {
+			return false, false
+		}
+		return true, true
+	}
+	return true, false
+}
+
+// WriteFileAtomic replaces a file by writing a temp file in the same directory and
+// renaming it, so that the IDE never reads a partially written file
+func WriteFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".web-sync-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp, info.Mode().Perm())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Watch Tests
+ * @tagline         Tests for the polling fallback of the file watcher
+ * @description     Tests watch mode selection, file snapshots, and the switch to polling
+ *                  when file system events are not delivered
+ * @file            desktop/bridge/watch_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNormalizeWatchMode(t *testing.T) {
+	cases := map[string]string{
+		"":         WatchModeAuto,
+		"auto":     WatchModeAuto,
+		"fsnotify": WatchModeFsnotify,
+		"poll":     WatchModePoll,
+		"Poll":     WatchModeAuto,
+		"inotify":  WatchModeAuto,
+	}
+	for in, want := range cases {
+		if got := NormalizeWatchMode(in); got != want {
+			t.Errorf("NormalizeWatchMode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	if got := PollInterval(0); got != DefaultPollIntervalMs*time.Millisecond {
+		t.Errorf("PollInterval(0) = %v, want the default", got)
+	}
+	if got := PollInterval(-5); got != DefaultPollIntervalMs*time.Millisecond {
+		t.Errorf("PollInterval(-5) = %v, want the default", got)
+	}
+	if got := PollInterval(250); got != 250*time.Millisecond {
+		t.Errorf("PollInterval(250) = %v, want 250ms", got)
+	}
+}
+
+func TestResolveWatchMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippet.js")
+	cases := []struct {
+		mode, want, reason string
+	}{
+		{"poll", WatchModePoll, "configured"},
+		{"fsnotify", WatchModeFsnotify, "configured"},
+		// A local temp dir keeps fsnotify in auto mode
+		{"auto", WatchModeFsnotify, "auto"},
+		{"", WatchModeFsnotify, "auto"},
+	}
+	for _, c := range cases {
+		mode, reason := ResolveWatchMode(c.mode, path)
+		if mode != c.want || reason != c.reason {
+			t.Errorf("ResolveWatchMode(%q) = %q, %q, want %q, %q", c.mode, mode, reason, c.want, c.reason)
+		}
+	}
+}
+
+func TestFileSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippet.js")
+	if _, _, err := ReadFileSnapshot(path); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeSnapshotFile(t, path, "let a = 1;\n", mtime)
+	content, snap, err := ReadFileSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "let a = 1;\n" || snap.Size != int64(len(content)) || !snap.ModTime.Equal(mtime) {
+		t.Fatalf("unexpected snapshot %+v of %q", snap, content)
+	}
+	info, _ := os.Stat(path)
+	if snap.StatChanged(info) {
+		t.Error("unchanged file reported as changed")
+	}
+
+	// Same size and mtime, other content: stat does not see it, the hash does
+	writeSnapshotFile(t, path, "let b = 1;\n", mtime)
+	info, _ = os.Stat(path)
+	if snap.StatChanged(info) {
+		t.Error("stat changed although size and mtime are equal")
+	}
+	_, other, _ := ReadFileSnapshot(path)
+	if other.Hash == snap.Hash {
+		t.Error("hash did not change with the content")
+	}
+
+	writeSnapshotFile(t, path, "let a = 1;\n", mtime.Add(time.Second))
+	info, _ = os.Stat(path)
+	if !snap.StatChanged(info) {
+		t.Error("newer mtime not reported as changed")
+	}
+	writeSnapshotFile(t, path, "let a = 12;\n", mtime)
+	info, _ = os.Stat(path)
+	if !snap.StatChanged(info) {
+		t.Error("other size not reported as changed")
+	}
+}
+
+func TestPollChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippet.js")
+	saved := time.Now().Add(-time.Minute).Truncate(time.Second)
+	writeSnapshotFile(t, path, "let a = 1;\n", saved)
+	_, last, err := ReadFileSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := time.Second
+	info, _ := os.Stat(path)
+	for _, events := range []bool{false, true} {
+		if changed, missed := PollChanged(last, info, events, interval, saved.Add(time.Hour)); changed || missed {
+			t.Errorf("events %v: unchanged file reported as changed", events)
+		}
+	}
+
+	writeSnapshotFile(t, path, "let a = 2; // saved in the IDE\n", saved.Add(10*time.Second))
+	info, _ = os.Stat(path)
+	// Polling reports the change on the next tick
+	if changed, missed := PollChanged(last, info, false, interval, saved.Add(10*time.Second)); !changed || missed {
+		t.Errorf("polling: changed %v, missed %v, want changed", changed, missed)
+	}
+	// fsnotify gets one interval to deliver the event
+	if changed, missed := PollChanged(last, info, true, interval, saved.Add(10*time.Second+interval/2)); changed || missed {
+		t.Errorf("fsnotify within interval: changed %v, missed %v, want neither", changed, missed)
+	}
+	// After that the event is missed, and the watcher switches to polling
+	if changed, missed := PollChanged(last, info, true, interval, saved.Add(10*time.Second+interval)); !changed || !missed {
+		t.Errorf("fsnotify after interval: changed %v, missed %v, want both", changed, missed)
+	}
+}
+
+// writeSnapshotFile writes content to a file with the given mtime
+func writeSnapshotFile(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
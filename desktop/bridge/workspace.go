@@ -0,0 +1,160 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Workspace
+ * @tagline         Per-page and per-session workspace folders with project scaffolding
+ * @description     Places snippets in workspace folders instead of loose temp files, and
+ *                  scaffolds them with per-language templates such as .editorconfig,
+ *                  tsconfig.json or pyproject.toml, so that IDEs get project context
+ * @file            desktop/bridge/workspace.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Workspace modes: loose temp files, one folder per page, or one folder per edit session
+const (
+	WorkspaceOff     = "off"
+	WorkspacePage    = "page"
+	WorkspaceSession = "session"
+)
+
+// DefaultWorkspaceTemplates scaffold workspace folders when the app config has no
+// workspace_templates. Keys are fileTypes or file name globs as in IDE mappings, values
+// map relative paths to file content with placeholders {snippetId} {fileType} {fileName}.
+var DefaultWorkspaceTemplates = map[string]map[string]string{
+	"*": {
+		".editorconfig": "root = true\n\n[*]\ncharset = utf-8\nend_of_line = lf\ninsert_final_newline = true\ntrim_trailing_whitespace = true\n",
+	},
+	"js, jsx, mjs, cjs": {
+		"jsconfig.json": "{\n  \"compilerOptions\": {\n    \"target\": \"ES2020\",\n    \"checkJs\": true\n  },\n  \"include\": [\"**/*\"]\n}\n",
+	},
+	"ts, tsx": {
+		"tsconfig.json": "{\n  \"compilerOptions\": {\n    \"target\": \"ES2020\",\n    \"module\": \"ESNext\",\n    \"strict\": true,\n    \"noEmit\": true\n  },\n  \"include\": [\"**/*\"]\n}\n",
+	},
+	"py, python": {
+		"pyproject.toml": "[project]\nname = \"web-ide-bridge-snippet\"\nversion = \"0.0.0\"\nrequires-python = \">=3.8\"\n",
+	},
+}
+
+// NormalizeWorkspaceMode maps unknown or empty values to off
+func NormalizeWorkspaceMode(mode string) string {
+	switch mode {
+	case WorkspacePage, WorkspaceSession:
+		return mode
+	}
+	return WorkspaceOff
+}
+
+// SessionFilePath returns the file a snippet is edited in: a loose temp file, or a file
+// named after the snippet in the workspace folder of its page or session
+func SessionFilePath(mode string, key SessionKey, fileType string) string {
+	var dir string
+	switch NormalizeWorkspaceMode(mode) {
+	case WorkspacePage:
+		name := "web-" + PageName(key.Page)
+		if key.Page == "" {
+			name = "web-snippets"
+		}
+		sum := sha256.Sum256([]byte(key.Server + "\n" + key.Page))
+		dir = filepath.Join(os.TempDir(), name+"-"+hex.EncodeToString(sum[:4]))
+	case WorkspaceSession:
+		dir = strings.TrimSuffix(TempFilePath(key, fileType), "."+SanitizeFileName(fileType, 16))
+	default:
+		return TempFilePath(key, fileType)
+	}
+	return filepath.Join(dir, SanitizeFileName(key.SnippetID, 64)+"."+SanitizeFileName(fileType, 16))
+}
+
+// ScaffoldFiles collects the scaffolding files for a fileType: configured templates
+// (or the defaults), then the user template folders in templatesDir: _all/ for all
+// languages, <fileType>/ per language. Later sources win.
+func ScaffoldFiles(templates map[string]map[string]string, templatesDir, fileType, fileName string) map[string]string {
+	if templates == nil {
+		templates = DefaultWorkspaceTemplates
+	}
+	patterns := make([]string, 0, len(templates))
+	for pattern := range templates {
+		patterns = append(patterns, pattern)
+	}
+	// Templates for all languages first, so that language templates can override them
+	sort.Slice(patterns, func(i, j int) bool {
+		if (patterns[i] == "*") != (patterns[j] == "*") {
+			return patterns[i] == "*"
+		}
+		return patterns[i] < patterns[j]
+	})
+	files := map[string]string{}
+	for _, pattern := range patterns {
+		if MatchesFileType(pattern, fileType, fileName) {
+			for path, content := range templates[pattern] {
+				files[path] = content
+			}
+		}
+	}
+
+	for _, sub := range []string{"_all", SanitizeFileName(fileType, 16)} {
+		root := filepath.Join(templatesDir, sub)
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				files[filepath.ToSlash(rel)] = string(data)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// ScaffoldWorkspace creates a workspace folder with its scaffolding files, see
+// ScaffoldFiles. Existing files are kept, so that changes made in the IDE survive
+// re-opening a snippet. Returns the number of files created.
+func ScaffoldWorkspace(dir, templatesDir string, key SessionKey, fileType, fileName string, templates map[string]map[string]string) (int, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, err
+	}
+	replacer := strings.NewReplacer(
+		"{snippetId}", key.SnippetID,
+		"{fileType}", fileType,
+		"{fileName}", fileName,
+	)
+	created := 0
+	for rel, content := range ScaffoldFiles(templates, templatesDir, fileType, fileName) {
+		rel = filepath.FromSlash(rel)
+		if !filepath.IsLocal(rel) {
+			return created, fmt.Errorf("workspace template path %q is outside of the workspace", rel)
+		}
+		path := filepath.Join(dir, rel)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(path, []byte(replacer.Replace(content)), 0644); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
@@ -0,0 +1,166 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Bridge / Workspace Tests
+ * @tagline         Tests for workspace folders and their scaffolding
+ * @description     Tests where snippets are placed per workspace mode, which scaffolding
+ *                  files are collected, and that existing files and the folder are kept safe
+ * @file            desktop/bridge/workspace_test.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSessionFilePath(t *testing.T) {
+	key := SessionKey{Server: "ws://a", Page: "https://example.com/demo", SnippetID: "code"}
+	temp := filepath.Clean(os.TempDir())
+
+	if got, want := SessionFilePath("off", key, "js"), TempFilePath(key, "js"); got != want {
+		t.Errorf("off: %s, want %s", got, want)
+	}
+	if got, want := SessionFilePath("bogus", key, "js"), TempFilePath(key, "js"); got != want {
+		t.Errorf("unknown mode: %s, want %s", got, want)
+	}
+
+	// Snippets of a page share its folder, other pages and servers get their own
+	page := SessionFilePath(WorkspacePage, key, "js")
+	style := SessionFilePath(WorkspacePage, SessionKey{Server: key.Server, Page: key.Page, SnippetID: "style"}, "css")
+	if filepath.Dir(page) != filepath.Dir(style) || filepath.Base(page) != "code.js" || filepath.Base(style) != "style.css" {
+		t.Errorf("page folder: %s and %s", page, style)
+	}
+	if filepath.Dir(filepath.Dir(page)) != temp || !strings.HasPrefix(filepath.Base(filepath.Dir(page)), "web-demo-") {
+		t.Errorf("page folder %s not named after the page in the temp dir", filepath.Dir(page))
+	}
+	for _, other := range []SessionKey{
+		{Server: "ws://b", Page: key.Page, SnippetID: "code"},
+		{Server: key.Server, Page: "https://example.com/other", SnippetID: "code"},
+	} {
+		if filepath.Dir(SessionFilePath(WorkspacePage, other, "js")) == filepath.Dir(page) {
+			t.Errorf("%+v shares the page folder", other)
+		}
+	}
+	if got := filepath.Base(filepath.Dir(SessionFilePath(WorkspacePage, SessionKey{SnippetID: "code"}, "js"))); !strings.HasPrefix(got, "web-snippets-") {
+		t.Errorf("folder without page URL: %s", got)
+	}
+
+	// Each session gets a folder named like its temp file
+	session := SessionFilePath(WorkspaceSession, key, "js")
+	if filepath.Dir(session)+".js" != TempFilePath(key, "js") || filepath.Base(session) != "code.js" {
+		t.Errorf("session folder: %s", session)
+	}
+	if WorkspaceOf(session) != filepath.Dir(session) || WorkspaceOf(TempFilePath(key, "js")) != "" {
+		t.Errorf("WorkspaceOf does not match the workspace modes")
+	}
+}
+
+func TestScaffoldFiles(t *testing.T) {
+	templates := map[string]map[string]string{
+		"*":       {".editorconfig": "all", "README.md": "all"},
+		"js, ts":  {"jsconfig.json": "js", "README.md": "js"},
+		"*.py":    {"pyproject.toml": "py"},
+		"sql":     {"sqlfluff.cfg": "sql"},
+		"js":      {"package.json": "js only"},
+		"unknown": {"x": "x"},
+	}
+	dir := t.TempDir()
+	writeScaffoldTemplate(t, filepath.Join(dir, "_all", "LICENSE"), "user all")
+	writeScaffoldTemplate(t, filepath.Join(dir, "js", ".eslintrc.json"), "user js")
+	writeScaffoldTemplate(t, filepath.Join(dir, "js", "jsconfig.json"), "user jsconfig")
+	writeScaffoldTemplate(t, filepath.Join(dir, "css", "x.css"), "user css")
+
+	files := ScaffoldFiles(templates, dir, "js", "code.js")
+	want := map[string]string{
+		".editorconfig":  "all",
+		"README.md":      "js", // language templates override templates for all languages
+		"jsconfig.json":  "user jsconfig",
+		"package.json":   "js only",
+		"LICENSE":        "user all",
+		".eslintrc.json": "user js",
+	}
+	if len(files) != len(want) {
+		t.Errorf("files %v, want %v", scaffoldNames(files), scaffoldNames(want))
+	}
+	for path, content := range want {
+		if files[path] != content {
+			t.Errorf("%s = %q, want %q", path, files[path], content)
+		}
+	}
+	if files := ScaffoldFiles(templates, dir, "py", "code.py"); files["pyproject.toml"] != "py" {
+		t.Errorf("glob on the file name not matched: %v", scaffoldNames(files))
+	}
+
+	// Without configured templates, the defaults are used
+	files = ScaffoldFiles(nil, filepath.Join(dir, "missing"), "ts", "code.ts")
+	if _, ok := files["tsconfig.json"]; !ok || len(files) != 2 {
+		t.Errorf("default templates for ts: %v", scaffoldNames(files))
+	}
+}
+
+func TestScaffoldWorkspace(t *testing.T) {
+	workspace := filepath.Join(t.TempDir(), "web-demo")
+	templates := map[string]map[string]string{
+		"js": {"jsconfig.json": "{\"name\": \"{snippetId}.{fileType} in {fileName}\"}", "src/.keep": ""},
+	}
+	key := SessionKey{SnippetID: "code"}
+	created, err := ScaffoldWorkspace(workspace, t.TempDir(), key, "js", "code.js", templates)
+	if err != nil || created != 2 {
+		t.Fatalf("created %d files: %v", created, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(workspace, "jsconfig.json"))
+	if string(data) != `{"name": "code.js in code.js"}` {
+		t.Errorf("placeholders not replaced: %s", data)
+	}
+
+	// Files changed in the IDE are kept
+	os.WriteFile(filepath.Join(workspace, "jsconfig.json"), []byte("edited"), 0644)
+	if created, err = ScaffoldWorkspace(workspace, t.TempDir(), key, "js", "code.js", templates); err != nil || created != 0 {
+		t.Errorf("re-open created %d files: %v", created, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "jsconfig.json")); string(data) != "edited" {
+		t.Errorf("edited file replaced: %s", data)
+	}
+
+	// Template paths cannot leave the workspace
+	for _, path := range []string{"../outside.txt", "/etc/outside.txt", "a/../../outside.txt"} {
+		bad := map[string]map[string]string{"js": {path: "x"}}
+		if _, err := ScaffoldWorkspace(workspace, t.TempDir(), key, "js", "code.js", bad); err == nil {
+			t.Errorf("template path %q accepted", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(workspace), "outside.txt")); err == nil {
+		t.Error("file written outside of the workspace")
+	}
+}
+
+// writeScaffoldTemplate writes a user template file
+func writeScaffoldTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scaffoldNames returns the sorted paths of scaffolding files, for messages
+func scaffoldNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
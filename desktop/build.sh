@@ -53,7 +53,7 @@ build_for_platform() {
     # Check if we're building for the current platform
     if [ "$os" = "$CURRENT_OS" ] && [ "$arch" = "$CURRENT_ARCH" ]; then
         echo "✅ Building for current platform"
-        if (go build $build_flags -o ../bin/${os}_${arch}/$output_name web-ide-bridge.go); then
+        if (go build $build_flags -o ../bin/${os}_${arch}/$output_name .); then
             echo "✅ Successfully built for $platform_name"
             return 0
         else
@@ -64,7 +64,7 @@ build_for_platform() {
         echo "⚠️  Cross-compilation attempted for $platform_name"
         echo "   Note: GUI applications with native dependencies (like Fyne) have cross-compilation constraints."
         echo "   This build will likely fail due to platform-specific GUI libraries."
-        if (GOOS=$os GOARCH=$arch go build $build_flags -o ../bin/${os}_${arch}/$output_name web-ide-bridge.go 2>/dev/null); then
+        if (GOOS=$os GOARCH=$arch go build $build_flags -o ../bin/${os}_${arch}/$output_name . 2>/dev/null); then
             echo "✅ Successfully built for $platform_name (unexpected success!)"
             return 0
         else
@@ -0,0 +1,54 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Cleanup
+ * @tagline         Periodic cleanup of temp files
+ * @description     Runs the cleanup of the temp files owned by the app, skipping files
+ *                  of snippets that are being edited
+ * @file            desktop/cleanup.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// tempManifestPath returns the manifest file path, next to the user config
+func tempManifestPath() string {
+	return filepath.Join(filepath.Dir(configPath()), "temp-files.json")
+}
+
+// activeTempFiles returns the temp files and workspace folders of snippets that are being edited
+func (c *WebSocketClient) activeTempFiles() map[string]bool {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	active := make(map[string]bool, len(c.watchers))
+	for _, w := range c.watchers {
+		active[w.tmpFile] = true
+		if workspace := bridge.WorkspaceOf(w.tmpFile); workspace != "" {
+			active[workspace] = true
+		}
+	}
+	return active
+}
+
+// cleanupTempFiles runs one cleanup of owned temp files and logs a summary
+func (c *WebSocketClient) cleanupTempFiles(policy bridge.CleanupPolicy) {
+	summary := c.tempFiles.Cleanup(policy, c.activeTempFiles(), time.Now())
+	if summary.Removed > 0 || summary.Failed > 0 || summary.Missing > 0 {
+		c.log("Temp file cleanup: " + summary.String())
+	} else {
+		// Debug log (not shown in activity log)
+		fmt.Printf("[DEBUG] Temp file cleanup: %s\n", summary)
+	}
+}
@@ -0,0 +1,138 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Delta
+ * @tagline         Delta sync of code updates with line patches
+ * @description     Sends code updates as line patches against the last version the server
+ *                  acknowledged, identified by content hash, with a fallback to the full
+ *                  code when the server does not have the base version
+ * @file            desktop/delta.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// Code smaller than minDeltaSize bytes is always sent in full, and so is code with more
+// than bridge.MaxDeltaEdits inserted and deleted lines, where the patch saves little
+const minDeltaSize = 1024
+
+// deltaState is the version of a snippet that the server has, and the last code sent
+type deltaState struct {
+	base        string // code acknowledged by the server, or received from it
+	baseHash    string
+	pendingHash string // hash of the last code sent, until the server acknowledges it
+	pending     string
+	pendingFull []byte // last code_update with the full code, sent again on code_resync
+}
+
+// setDeltaSupported records whether the server accepts patches, from its connection_ack
+func (c *WebSocketClient) setDeltaSupported(supported bool) {
+	c.statusMu.Lock()
+	c.deltaSupported = supported
+	c.statusMu.Unlock()
+}
+
+// setDeltaBase records code that the server has for a snippet, because it sent it
+func (c *WebSocketClient) setDeltaBase(key bridge.SessionKey, code string) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	st, ok := c.deltas[key]
+	if !ok {
+		st = &deltaState{}
+		c.deltas[key] = st
+	}
+	st.base, st.baseHash = code, bridge.CodeHash(code)
+}
+
+// forgetDelta removes the delta state of a session that ended
+func (c *WebSocketClient) forgetDelta(key bridge.SessionKey) {
+	c.watchersMu.Lock()
+	delete(c.deltas, key)
+	c.watchersMu.Unlock()
+}
+
+// deltaMessage returns the JSON of a code_update: with a line patch against the base
+// version of the server if that is known and the patch is much smaller, else with the
+// full code. Returns the size of the full message too, for the log.
+func (c *WebSocketClient) deltaMessage(key bridge.SessionKey, msg map[string]interface{}, code string) ([]byte, int) {
+	full, _ := json.Marshal(msg)
+	hash := bridge.CodeHash(code)
+	c.statusMu.Lock()
+	supported := c.deltaSupported
+	c.statusMu.Unlock()
+
+	c.watchersMu.Lock()
+	st, ok := c.deltas[key]
+	if !ok {
+		st = &deltaState{}
+		c.deltas[key] = st
+	}
+	st.pendingHash, st.pending, st.pendingFull = hash, code, full
+	base, baseHash := st.base, st.baseHash
+	c.watchersMu.Unlock()
+
+	if !supported || baseHash == "" || len(code) < minDeltaSize {
+		return full, len(full)
+	}
+	ops, ok := bridge.LineDiff(base, code)
+	if !ok {
+		return full, len(full)
+	}
+	patched := make(map[string]interface{}, len(msg)+2)
+	for k, v := range msg {
+		patched[k] = v
+	}
+	delete(patched, "code")
+	patched["patch"] = ops
+	patched["baseHash"] = baseHash
+	patched["hash"] = hash
+	data, err := json.Marshal(patched)
+	if err != nil || len(data) >= len(full)*3/4 {
+		return full, len(full)
+	}
+	log.Printf("[deltaMessage] snippetId=%s, patch=%d bytes, full=%d bytes", key.SnippetID, len(data), len(full))
+	return data, len(full)
+}
+
+// handleCodeAck records the code the server acknowledged as the base of the next patch
+func (c *WebSocketClient) handleCodeAck(key bridge.SessionKey, hash string) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	if st, ok := c.deltas[key]; ok && hash != "" && st.pendingHash == hash {
+		st.base, st.baseHash = st.pending, st.pendingHash
+	}
+}
+
+// handleCodeResync sends the full code of a patch that the server could not apply,
+// because it does not have the base version
+func (c *WebSocketClient) handleCodeResync(key bridge.SessionKey, hash string) {
+	c.watchersMu.Lock()
+	var data []byte
+	if st, ok := c.deltas[key]; ok && st.pendingHash == hash {
+		data = st.pendingFull
+		st.base, st.baseHash = "", ""
+	}
+	c.watchersMu.Unlock()
+	if data == nil {
+		return
+	}
+	c.log(fmt.Sprintf("Server does not have the base version of snippet %s, sending the full code", key))
+	if c.conn == nil {
+		return
+	}
+	if err := c.sendLarge(key, data); err != nil {
+		c.log(fmt.Sprintf("Failed to send code snippet %s: %s", key, err.Error()))
+	}
+}
@@ -0,0 +1,65 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Detect
+ * @tagline         Language detection of edit sessions
+ * @description     Applies the configured detection mode to snippets whose fileType is
+ *                  missing or generic, and records the detected language of each session
+ * @file            desktop/detect.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// languageDetection is the language detected for a session, for the sessions panel
+type languageDetection struct {
+	From   string // fileType of the browser
+	Reason string
+}
+
+// label describes a detected language for the sessions panel
+func (d languageDetection) label(fileType string) string {
+	from := d.From
+	if from == "" {
+		from = "none"
+	}
+	return fmt.Sprintf("%s (detected from %s, %s)", fileType, from, d.Reason)
+}
+
+// detectFileType returns the fileType of a snippet, detected from its code if the
+// configured mode applies to its fileType, and records the detection in the session
+func (c *WebSocketClient) detectFileType(mode string, key bridge.SessionKey, code, fileType string) string {
+	if !bridge.ShouldDetect(mode, fileType) {
+		c.setDetection(key, nil)
+		return fileType
+	}
+	detected, reason := bridge.DetectLanguage(code)
+	if detected == "" {
+		c.setDetection(key, nil)
+		return fileType
+	}
+	c.log(fmt.Sprintf("Detected language of snippet %s: %s (%s)", key, detected, reason))
+	c.setDetection(key, &languageDetection{From: fileType, Reason: reason})
+	return detected
+}
+
+// setDetection records the detected language of an edit session, or removes it for nil
+func (c *WebSocketClient) setDetection(key bridge.SessionKey, d *languageDetection) {
+	c.watchersMu.Lock()
+	if d == nil {
+		delete(c.detections, key)
+	} else {
+		c.detections[key] = *d
+	}
+	c.watchersMu.Unlock()
+}
@@ -0,0 +1,401 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Editor Discovery
+ * @tagline         Cross-platform discovery of installed editors and IDEs
+ * @description     Scans PATH, XDG .desktop entries, Flatpak/Snap exports, macOS application
+ *                  folders and common install directories for editors
+ * @file            desktop/discovery.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// discoveredEditor is an editor found on this machine
+type discoveredEditor struct {
+	Name     string // display name, e.g. "Visual Studio Code"
+	Command  string // value for the IDE command, e.g. "code" or "/usr/bin/gedit"
+	Template string // launch template if the editor needs more than `command {file}`
+	Source   string // where it was found: PATH, desktop entry, install dir, fallback
+}
+
+// editorDiscovery runs editor discovery once in the background and keeps the result
+type editorDiscovery struct {
+	done    chan struct{}
+	editors []discoveredEditor
+}
+
+// startEditorDiscovery discovers the editors of this machine in the background
+func startEditorDiscovery() *editorDiscovery {
+	d := &editorDiscovery{done: make(chan struct{})}
+	go func() {
+		d.editors = discoverEditors()
+		close(d.done)
+	}()
+	return d
+}
+
+// wait returns the discovered editors, waiting for discovery to finish
+func (d *editorDiscovery) wait() []discoveredEditor {
+	<-d.done
+	return d.editors
+}
+
+// ready returns the discovered editors without waiting; ok is false while discovery runs
+func (d *editorDiscovery) ready() (editors []discoveredEditor, ok bool) {
+	select {
+	case <-d.done:
+		return d.editors, true
+	default:
+		return nil, false
+	}
+}
+
+// discoverEditors returns all editors found, best candidates first, ending with the
+// platform fallback (TextEdit, notepad.exe or xdg-open)
+func discoverEditors() []discoveredEditor {
+	var found []discoveredEditor
+	seen := map[string]bool{}
+	add := func(e discoveredEditor) {
+		// Flatpak entries share the flatpak command, so templates identify them
+		launch := e.Command
+		if e.Template != "" {
+			launch = e.Template
+		}
+		name, launch := strings.ToLower(e.Name), strings.ToLower(launch)
+		if seen[name] || seen[launch] {
+			return
+		}
+		seen[name] = true
+		seen[launch] = true
+		found = append(found, e)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		for _, e := range discoverMacApps() {
+			add(e)
+		}
+		for _, e := range discoverInPath() {
+			add(e)
+		}
+		add(discoveredEditor{Name: "TextEdit", Command: "TextEdit", Source: "fallback"})
+	case "windows":
+		for _, e := range discoverInPath() {
+			add(e)
+		}
+		for _, e := range discoverWindowsInstallDirs() {
+			add(e)
+		}
+		add(discoveredEditor{Name: "Notepad", Command: "notepad.exe", Source: "fallback"})
+	default:
+		for _, e := range discoverInPath() {
+			add(e)
+		}
+		for _, e := range discoverDesktopEntries() {
+			add(e)
+		}
+		for _, e := range discoverLinuxInstallDirs() {
+			add(e)
+		}
+		add(discoveredEditor{Name: "System default (xdg-open)", Command: "xdg-open", Source: "fallback"})
+	}
+	return found
+}
+
+// isEditorInstalled reports whether an IDE command refers to an installed editor
+func isEditorInstalled(ideCmd string) bool {
+	if ideCmd == "" {
+		return false
+	}
+	if strings.HasSuffix(ideCmd, ".app") || strings.ContainsAny(ideCmd, `/\`) {
+		_, err := os.Stat(ideCmd)
+		return err == nil
+	}
+	if runtime.GOOS == "darwin" {
+		for _, dir := range macAppDirs() {
+			if _, err := os.Stat(filepath.Join(dir, ideCmd+".app")); err == nil {
+				return true
+			}
+		}
+	}
+	_, err := exec.LookPath(ideCmd)
+	return err == nil
+}
+
+// macAppDirs returns the macOS application folders
+func macAppDirs() []string {
+	dirs := []string{"/Applications", "/System/Applications"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "Applications"))
+	}
+	return dirs
+}
+
+// discoverMacApps finds known editors in the macOS application folders
+func discoverMacApps() []discoveredEditor {
+	var found []discoveredEditor
+	for _, k := range bridge.KnownEditors {
+		if k.MacApp == "" {
+			continue
+		}
+		for _, dir := range macAppDirs() {
+			if _, err := os.Stat(filepath.Join(dir, k.MacApp+".app")); err == nil {
+				found = append(found, discoveredEditor{Name: k.Name, Command: k.MacApp, Source: dir})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// discoverInPath finds known editor commands in PATH, Snap and Flatpak export dirs
+func discoverInPath() []discoveredEditor {
+	extraDirs := []string{"/snap/bin", "/var/lib/flatpak/exports/bin"}
+	if home, err := os.UserHomeDir(); err == nil {
+		extraDirs = append(extraDirs,
+			filepath.Join(home, ".local", "share", "flatpak", "exports", "bin"),
+			filepath.Join(home, ".local", "share", "JetBrains", "Toolbox", "scripts"))
+	}
+	var found []discoveredEditor
+	for _, k := range bridge.KnownEditors {
+		for _, name := range k.Commands {
+			if _, err := exec.LookPath(name); err == nil {
+				found = append(found, discoveredEditor{Name: k.Name, Command: name, Source: "PATH"})
+				break
+			}
+			if runtime.GOOS == "windows" {
+				continue
+			}
+			located := false
+			for _, dir := range extraDirs {
+				path := filepath.Join(dir, name)
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					found = append(found, discoveredEditor{Name: k.Name, Command: path, Source: dir})
+					located = true
+					break
+				}
+			}
+			if located {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// discoverWindowsInstallDirs checks common Windows install locations
+func discoverWindowsInstallDirs() []discoveredEditor {
+	localAppData := os.Getenv("LOCALAPPDATA")
+	programFiles := os.Getenv("ProgramFiles")
+	programFilesX86 := os.Getenv("ProgramFiles(x86)")
+	candidates := []struct {
+		name  string
+		paths []string
+	}{
+		{"Visual Studio Code", []string{
+			filepath.Join(localAppData, "Programs", "Microsoft VS Code", "Code.exe"),
+			filepath.Join(programFiles, "Microsoft VS Code", "Code.exe"),
+		}},
+		{"Cursor", []string{filepath.Join(localAppData, "Programs", "cursor", "Cursor.exe")}},
+		{"VSCodium", []string{
+			filepath.Join(localAppData, "Programs", "VSCodium", "VSCodium.exe"),
+			filepath.Join(programFiles, "VSCodium", "VSCodium.exe"),
+		}},
+		{"Sublime Text", []string{
+			filepath.Join(programFiles, "Sublime Text", "sublime_text.exe"),
+			filepath.Join(programFiles, "Sublime Text 3", "sublime_text.exe"),
+		}},
+		{"Notepad++", []string{
+			filepath.Join(programFiles, "Notepad++", "notepad++.exe"),
+			filepath.Join(programFilesX86, "Notepad++", "notepad++.exe"),
+		}},
+	}
+	var found []discoveredEditor
+	for _, c := range candidates {
+		for _, path := range c.paths {
+			if !filepath.IsAbs(path) {
+				continue // environment variable not set
+			}
+			if _, err := os.Stat(path); err == nil {
+				found = append(found, discoveredEditor{Name: c.name, Command: path, Source: filepath.Dir(path)})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// discoverLinuxInstallDirs checks common Linux install locations outside PATH
+func discoverLinuxInstallDirs() []discoveredEditor {
+	candidates := []struct {
+		name string
+		path string
+	}{
+		{"Visual Studio Code", "/usr/share/code/code"},
+		{"Sublime Text", "/opt/sublime_text/sublime_text"},
+		{"Cursor", "/opt/cursor/cursor"},
+		{"Zed", filepath.Join(os.Getenv("HOME"), ".local", "bin", "zed")},
+	}
+	var found []discoveredEditor
+	for _, c := range candidates {
+		if _, err := os.Stat(c.path); err == nil {
+			found = append(found, discoveredEditor{Name: c.name, Command: c.path, Source: filepath.Dir(c.path)})
+		}
+	}
+	return found
+}
+
+// desktopEntryDirs returns XDG application dirs, including Flatpak and Snap exports
+func desktopEntryDirs() []string {
+	var dirs []string
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if home, err := os.UserHomeDir(); err == nil {
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		dirs = append(dirs, filepath.Join(home, ".local", "share", "flatpak", "exports", "share", "applications"))
+	}
+	if dataHome != "" {
+		dirs = append([]string{filepath.Join(dataHome, "applications")}, dirs...)
+	}
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		dirs = append(dirs, filepath.Join(dir, "applications"))
+	}
+	return append(dirs, "/var/lib/flatpak/exports/share/applications", "/var/lib/snapd/desktop/applications")
+}
+
+// discoverDesktopEntries finds text editors and IDEs in XDG .desktop files
+func discoverDesktopEntries() []discoveredEditor {
+	var found []discoveredEditor
+	seen := map[string]bool{}
+	for _, dir := range desktopEntryDirs() {
+		files, err := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			// Earlier dirs take precedence for the same desktop file ID
+			id := filepath.Base(file)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if e, ok := parseDesktopEntry(file); ok {
+				e.Source = dir
+				found = append(found, e)
+			}
+		}
+	}
+	return found
+}
+
+// parseDesktopEntry reads an editor from a .desktop file
+func parseDesktopEntry(path string) (discoveredEditor, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return discoveredEditor{}, false
+	}
+	defer f.Close()
+
+	fields := map[string]string{}
+	inEntry := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if !inEntry || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			key = strings.TrimSpace(key)
+			if _, exists := fields[key]; !exists {
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	if fields["Type"] != "Application" || fields["NoDisplay"] == "true" || fields["Hidden"] == "true" {
+		return discoveredEditor{}, false
+	}
+	categories := ";" + fields["Categories"] + ";"
+	isEditor := strings.Contains(categories, ";TextEditor;") || strings.Contains(categories, ";IDE;") ||
+		(strings.Contains(categories, ";Development;") && strings.Contains(fields["MimeType"], "text/plain"))
+	if !isEditor || fields["Name"] == "" || fields["Exec"] == "" {
+		return discoveredEditor{}, false
+	}
+	command, template, ok := desktopExecToTemplate(fields["Exec"])
+	if !ok {
+		return discoveredEditor{}, false
+	}
+	return discoveredEditor{Name: fields["Name"], Command: command, Template: template}, true
+}
+
+// desktopExecToTemplate converts a desktop entry Exec line into a launch template,
+// replacing the file field codes with {file} and dropping the other field codes
+func desktopExecToTemplate(execLine string) (string, string, bool) {
+	words, err := bridge.SplitCommandLine(execLine)
+	if err != nil || len(words) == 0 {
+		return "", "", false
+	}
+	var args []string
+	hasFile := false
+	for _, word := range words {
+		switch word {
+		case "%f", "%F", "%u", "%U":
+			if !hasFile {
+				args = append(args, "{file}")
+				hasFile = true
+			}
+		case "%i", "%c", "%k", "%d", "%D", "%n", "%N", "%v", "%m":
+			// Deprecated or icon/name field codes are not used
+		default:
+			args = append(args, strings.ReplaceAll(word, "%%", "%"))
+		}
+	}
+	if !hasFile {
+		args = append(args, "{file}")
+	}
+	template := bridge.FormatCommandLine(args)
+	// Editors started as `command {file}` need no template
+	if len(args) == 2 {
+		template = ""
+	}
+	return args[0], template, true
+}
+
+// selectInstalledIDE picks the first installed candidate, then the first discovered
+// editor; the editor is returned whole, since Flatpak editors need their template
+func selectInstalledIDE(candidates []string, discovered []discoveredEditor) discoveredEditor {
+	for _, candidate := range candidates {
+		if isEditorInstalled(candidate) {
+			return discoveredEditor{Name: candidate, Command: candidate, Source: "config"}
+		}
+	}
+	if len(discovered) > 0 {
+		return discovered[0]
+	}
+	return discoveredEditor{}
+}
@@ -0,0 +1,94 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Encoding
+ * @tagline         Text style of the temp files of edit sessions
+ * @description     Tracks the encoding and line endings of each temp file, see
+ *                  bridge/encoding.go, so that temp files are written in the style of the
+ *                  configured policy or of the editor
+ * @file            desktop/encoding.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// setTextStyle records the text style of the temp file of an edit session, or removes it
+func (c *WebSocketClient) setTextStyle(key bridge.SessionKey, style *bridge.TextStyle) {
+	c.watchersMu.Lock()
+	if style == nil {
+		delete(c.styles, key)
+	} else {
+		c.styles[key] = *style
+	}
+	c.watchersMu.Unlock()
+}
+
+// textStyleOf returns the text style of the temp file of an edit session, by default
+// the style of the configured policies
+func (c *WebSocketClient) textStyleOf(key bridge.SessionKey) bridge.TextStyle {
+	c.watchersMu.Lock()
+	style, ok := c.styles[key]
+	c.watchersMu.Unlock()
+	if ok {
+		return style
+	}
+	c.statusMu.Lock()
+	cfg := c.cfg
+	c.statusMu.Unlock()
+	return bridge.InitialTextStyle(cfg.TextEncoding, cfg.LineEndings)
+}
+
+// encodeTemp returns the temp file content of an edit session for UTF-8 text
+func (c *WebSocketClient) encodeTemp(key bridge.SessionKey, text []byte) []byte {
+	style := c.textStyleOf(key)
+	data, used := bridge.EncodeText(text, style)
+	if used != style {
+		c.log(fmt.Sprintf("Snippet %s has characters that %s cannot represent, writing it as %s", key, style.Encoding, used.Encoding))
+		c.setTextStyle(key, &used)
+	}
+	return data
+}
+
+// decodeTemp converts the content of the temp file of an edit session to UTF-8 text
+// with LF line endings, without recording its style
+func (c *WebSocketClient) decodeTemp(key bridge.SessionKey, raw []byte) ([]byte, error) {
+	text, _, err := bridge.DecodeText(raw, c.textStyleOf(key).Encoding)
+	return text, err
+}
+
+// decodeSaved converts a temp file saved by the editor to UTF-8 text with LF line
+// endings. With the auto policies, the byte order mark and line endings of the save
+// become the style of later writes, so that files are written in the editor's style.
+// Content that is not valid in its encoding is reported to the browser.
+func (c *WebSocketClient) decodeSaved(key bridge.SessionKey, raw []byte) ([]byte, error) {
+	c.statusMu.Lock()
+	cfg := c.cfg
+	c.statusMu.Unlock()
+	current := c.textStyleOf(key)
+	text, saved, err := bridge.DecodeText(raw, current.Encoding)
+	if err != nil {
+		var textErr *bridge.TextError
+		if errors.As(err, &textErr) {
+			c.log(fmt.Sprintf("Not sending snippet %s to the browser: %s", key, err.Error()))
+			c.sendValidationFailed(key, []bridge.Diagnostic{textErr.Diagnostic()})
+		}
+		return nil, err
+	}
+	style := bridge.SavedTextStyle(current, saved, raw, cfg.TextEncoding, cfg.LineEndings)
+	if style != current {
+		c.log(fmt.Sprintf("Snippet %s was saved as %s, writing it in the same style", key, style))
+		c.setTextStyle(key, &style)
+	}
+	return text, nil
+}
@@ -0,0 +1,46 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Formatter
+ * @tagline         Outbound formatter pipeline for code sent to the browser
+ * @description     Runs configured external formatters, such as prettier, gofmt, black or
+ *                  sqlfluff, on saved snippets before they are sent to the browser
+ * @file            desktop/formatter.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// formatOutbound runs the formatters that match a snippet, see bridge.Format; failing
+// formatters are reported to the browser. Returns the code to send, and whether it was
+// written back to the temp file, with the read-only context, so that the editor shows
+// the formatted code.
+func (c *WebSocketClient) formatOutbound(key bridge.SessionKey, tmpFile, fileType string, content []byte, formatters []bridge.Formatter, writeBack bool) ([]byte, bool) {
+	code := bridge.Format(formatters, content, key, tmpFile, fileType, func(f bridge.Formatter, err error) {
+		c.log(fmt.Sprintf("Formatter %q failed for snippet %s, sending code unformatted by it: %s", f.Command, key, err.Error()))
+		c.sendEditEvent(key, "format_failed", err.Error())
+	})
+	if bytes.Equal(code, content) {
+		return code, false
+	}
+	c.log(fmt.Sprintf("Formatted snippet %s, codeLength: %d -> %d", key, len(content), len(code)))
+	if !writeBack {
+		return code, false
+	}
+	if err := bridge.WriteFileAtomic(tmpFile, c.encodeTemp(key, c.guardContent(key, code))); err != nil {
+		c.log("Failed to write formatted code to temp file: " + err.Error())
+		return code, false
+	}
+	return code, true
+}
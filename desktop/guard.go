@@ -0,0 +1,78 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Guard
+ * @tagline         Read-only context of edit sessions
+ * @description     Keeps the guarded read-only context of each edit session, and reverts
+ *                  or rejects saves that changed the context or the guards
+ * @file            desktop/guard.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// setGuard records the read-only context of an edit session, or removes it for nil
+func (c *WebSocketClient) setGuard(key bridge.SessionKey, g *bridge.SnippetGuard) {
+	c.watchersMu.Lock()
+	if g == nil {
+		delete(c.guards, key)
+	} else {
+		c.guards[key] = *g
+	}
+	c.watchersMu.Unlock()
+}
+
+// guardOf returns the read-only context of an edit session
+func (c *WebSocketClient) guardOf(key bridge.SessionKey) (bridge.SnippetGuard, bool) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	g, ok := c.guards[key]
+	return g, ok
+}
+
+// guardContent returns the temp file content for the code of a session
+func (c *WebSocketClient) guardContent(key bridge.SessionKey, code []byte) []byte {
+	g, ok := c.guardOf(key)
+	if !ok {
+		return code
+	}
+	return []byte(g.Wrap(string(code)))
+}
+
+// unguardSaved returns the snippet code of a saved temp file, and the file content.
+// Changes to the read-only context are reverted by writing the file again, and the
+// browser is told with a context_repaired event. Saves with changed guards are rejected
+// with a context_rejected event.
+func (c *WebSocketClient) unguardSaved(key bridge.SessionKey, tmpFile string, content []byte) ([]byte, []byte, error) {
+	g, ok := c.guardOf(key)
+	if !ok {
+		return content, content, nil
+	}
+	code, repaired, err := g.Unwrap(string(content))
+	if err != nil {
+		c.log(fmt.Sprintf("Not sending snippet %s to the browser: %s", key, err.Error()))
+		c.sendEditEvent(key, "context_rejected", err.Error())
+		return nil, nil, err
+	}
+	if !repaired {
+		return []byte(code), content, nil
+	}
+	content = []byte(g.Wrap(code))
+	if err := bridge.WriteFileAtomic(tmpFile, c.encodeTemp(key, content)); err != nil {
+		c.log("Failed to restore the read-only context in the temp file: " + err.Error())
+	} else {
+		c.log(fmt.Sprintf("Reverted changes to the read-only context of snippet %s", key))
+	}
+	c.sendEditEvent(key, "context_repaired", "changes outside the snippet were reverted")
+	return []byte(code), content, nil
+}
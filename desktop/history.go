@@ -0,0 +1,138 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / History
+ * @tagline         Local version history of snippets with diff, search and restore
+ * @description     Records the code of received edit requests and sent code updates in the
+ *                  local history store, see bridge/history.go, with the retention limits of
+ *                  the config, and restores earlier versions into the temp file of an open
+ *                  snippet
+ * @file            desktop/history.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// historyLimitsOf returns the retention of a config, with defaults for unset values
+func historyLimitsOf(cfg Config) bridge.HistoryLimits {
+	limits := bridge.HistoryLimits{MaxVersions: cfg.HistoryMaxVersions, MaxAge: time.Duration(cfg.HistoryMaxDays) * 24 * time.Hour}
+	if limits.MaxVersions <= 0 {
+		limits.MaxVersions = bridge.DefaultHistoryMaxVersions
+	}
+	if limits.MaxAge <= 0 {
+		limits.MaxAge = bridge.DefaultHistoryMaxDays * 24 * time.Hour
+	}
+	return limits
+}
+
+// historyPath returns the history folder, next to the user config
+func historyPath() string {
+	return filepath.Join(filepath.Dir(configPath()), "history")
+}
+
+// recordHistory records the code of an edit_request or code_update in the history,
+// in the form of the browser, if the history is on. encoding is the binary encoding
+// of the code, empty for text.
+func (c *WebSocketClient) recordHistory(key bridge.SessionKey, direction, fileType, encoding, code string) {
+	c.statusMu.Lock()
+	cfg := c.cfg
+	c.statusMu.Unlock()
+	mode := bridge.NormalizeHistoryMode(cfg.History)
+	if mode == bridge.HistoryOff || key.SnippetID == "" {
+		return
+	}
+	if _, err := c.history.Add(key, direction, fileType, encoding, code, mode == bridge.HistoryEncrypted, historyLimitsOf(cfg), time.Now()); err != nil {
+		c.log(fmt.Sprintf("Failed to record version of snippet %s in history: %s", key, err.Error()))
+	}
+}
+
+// pruneHistory applies the retention limits to the history and logs a summary
+func (c *WebSocketClient) pruneHistory() {
+	c.statusMu.Lock()
+	cfg := c.cfg
+	c.statusMu.Unlock()
+	removed, err := c.history.Prune(historyLimitsOf(cfg), time.Now())
+	if err != nil {
+		c.log("History cleanup failed: " + err.Error())
+	} else if removed > 0 {
+		c.log(fmt.Sprintf("History cleanup: removed %d old versions", removed))
+	}
+}
+
+// currentCode returns the code of the temp file of an open snippet in the form of the
+// browser, to compare it with versions in the history
+func (c *WebSocketClient) currentCode(key bridge.SessionKey) (string, error) {
+	w, ok := c.watcher(key)
+	if !ok {
+		return "", fmt.Errorf("snippet %s is not open", key)
+	}
+	raw, err := os.ReadFile(w.tmpFile)
+	if err != nil {
+		return "", err
+	}
+	if f, ok := c.binaryOf(key); ok {
+		return f.Encode(raw), nil
+	}
+	text, err := c.decodeTemp(key, raw)
+	if err != nil {
+		return "", err
+	}
+	code := string(text)
+	if g, ok := c.guardOf(key); ok {
+		if code, _, err = g.Unwrap(code); err != nil {
+			return "", err
+		}
+	}
+	code, _, err = c.encodeForBrowser(key, w.tmpFile, code, w.fileType)
+	return code, err
+}
+
+// restoreVersion writes a version from the history to the temp file of its snippet,
+// which must be open, and sends it to the browser
+func (c *WebSocketClient) restoreVersion(e bridge.HistoryEntry) error {
+	w, ok := c.watcher(e.Key)
+	if !ok {
+		return fmt.Errorf("snippet %s is not open, open it from the browser to restore a version", e.Key)
+	}
+	code, err := c.history.Read(e)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if f, ok := c.binaryOf(e.Key); ok {
+		if data, err = f.Decode(code); err != nil {
+			return err
+		}
+	} else {
+		text, err := c.decodeFromBrowser(e.Key, w.tmpFile, bridge.NormalizeText(code))
+		if err != nil {
+			return err
+		}
+		data = c.encodeTemp(e.Key, c.guardContent(e.Key, []byte(text)))
+	}
+	// The watcher must not send the write, resendSession does
+	w.setSynced(sha256.Sum256(data))
+	if err := bridge.WriteFileAtomic(w.tmpFile, data); err != nil {
+		return err
+	}
+	c.log(fmt.Sprintf("Restored version #%d of snippet %s from %s", e.ID, e.Key, e.Time.Format("2006-01-02 15:04:05")))
+	if c.getStatus() != "connected" {
+		c.setSyncState(w, bridge.SyncStateUnsent, false)
+		return fmt.Errorf("not connected to the server, use Resend Now after reconnect")
+	}
+	return c.resendSession(e.Key)
+}
@@ -0,0 +1,229 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Launcher
+ * @tagline         IDE selection and process tracking
+ * @description     Selects the IDE and launch profile of a snippet, starts the IDE with the
+ *                  resolved launch template, and tracks the editor process
+ * @file            desktop/launcher.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// editPosition is the cursor position or selection to open a snippet at, 1-based;
+// zero values mean unknown
+type editPosition struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// parseEditPosition reads the optional line, column, endLine and endColumn fields of an edit request
+func parseEditPosition(m map[string]interface{}) editPosition {
+	number := func(name string) int {
+		if v, ok := m[name].(float64); ok && v >= 1 && v <= 1e7 {
+			return int(v)
+		}
+		return 0
+	}
+	pos := editPosition{Line: number("line"), Column: number("column"), EndLine: number("endLine"), EndColumn: number("endColumn")}
+	if pos.EndLine < pos.Line || (pos.EndLine == pos.Line && pos.EndColumn <= pos.Column) {
+		pos.EndLine, pos.EndColumn = 0, 0
+	}
+	return pos
+}
+
+// selectLaunch picks the IDE and launch profile for a snippet, see bridge.SelectLaunch
+func selectLaunch(cfg Config, fileType, fileName string) (string, bridge.LaunchProfile, string) {
+	return bridge.SelectLaunch(cfg.IDEMappings, cfg.IDECommand, cfg.Launch, fileType, fileName)
+}
+
+// formatIDEMappings summarizes mappings for display, e.g. "sql → DBeaver, *.py → pycharm"
+func formatIDEMappings(cfg Config) string {
+	if len(cfg.IDEMappings) == 0 {
+		return "(none, " + cfg.IDECommand + " for all)"
+	}
+	parts := make([]string, 0, len(cfg.IDEMappings))
+	for _, m := range cfg.IDEMappings {
+		target := m.IDE
+		if target == "" {
+			target = m.Template
+		}
+		if target == "" {
+			target = cfg.IDECommand
+		}
+		parts = append(parts, m.Pattern+" → "+target)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Launches that fail within this time are reported as launch failures
+const launchFailureWindow = 3 * time.Second
+
+// editorProcess is a started IDE process for a snippet
+type editorProcess struct {
+	cmd     *exec.Cmd
+	wait    bool
+	started time.Time
+	stderr  *tailBuffer
+	reopen  bool // reopened from the sessions panel: a failed launch keeps the session, guarded by c.watchersMu
+}
+
+// tailBuffer keeps the last bytes written to it, for capturing stderr
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
+
+// launchIDE starts the IDE and tracks the process in the background
+func (c *WebSocketClient) launchIDE(key bridge.SessionKey, launch bridge.LaunchCommand) error {
+	cmd := launch.Cmd()
+	stderr := &tailBuffer{max: 4096}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p := &editorProcess{cmd: cmd, wait: launch.Wait, started: time.Now(), stderr: stderr}
+	c.watchersMu.Lock()
+	c.editors[key] = p
+	c.watchersMu.Unlock()
+	go c.trackEditor(key, p)
+	return nil
+}
+
+// trackEditor waits for the IDE process to exit, reports failures, and ends
+// wait-for-close sessions with a final sync
+func (c *WebSocketClient) trackEditor(key bridge.SessionKey, p *editorProcess) {
+	err := p.cmd.Wait()
+	elapsed := time.Since(p.started)
+	exitCode := -1
+	if p.cmd.ProcessState != nil {
+		exitCode = p.cmd.ProcessState.ExitCode()
+	}
+
+	// A newer launch for the same snippet takes over the session
+	c.watchersMu.Lock()
+	current := c.editors[key] == p
+	if current {
+		delete(c.editors, key)
+	}
+	reopen := p.reopen
+	c.watchersMu.Unlock()
+
+	reason := fmt.Sprintf("exit code %d", exitCode)
+	if stderr := p.stderr.String(); stderr != "" {
+		reason += ": " + stderr
+	}
+	if err != nil && elapsed < launchFailureWindow {
+		c.log(fmt.Sprintf("IDE launch failed for snippet %s (%s)", key, reason))
+		if reopen {
+			// The session is still being watched, the user can edit the file otherwise
+			return
+		}
+		if !current {
+			c.sendEditEvent(key, "launch_failed", reason)
+			return
+		}
+		// Sessions opened together with one launch fail together
+		for _, k := range c.sessionGroup(key) {
+			c.sendEditEvent(k, "launch_failed", reason)
+			c.endEditSession(k, false, c.hasLocalEdits(k))
+		}
+		return
+	}
+	if !p.wait {
+		if err != nil {
+			c.log(fmt.Sprintf("IDE process for snippet %s exited (%s)", key, reason))
+		}
+		return
+	}
+	if !current {
+		return
+	}
+	c.log(fmt.Sprintf("Editor closed for snippet %s (%s), ending edit session", key, reason))
+	for _, k := range c.sessionGroup(key) {
+		c.endEditSession(k, true, false)
+		c.sendEditEvent(k, "editor_closed", reason)
+	}
+}
+
+// endEditSession stops the watcher of a snippet, optionally after a final sync, and
+// removes its temp file. The file and its manifest entry are kept if keepFile is set, or
+// if the final sync did not deliver the last save, and a file_kept event is sent.
+func (c *WebSocketClient) endEditSession(key bridge.SessionKey, finalSync, keepFile bool) {
+	c.watchersMu.Lock()
+	w, ok := c.watchers[key]
+	delete(c.watchers, key)
+	c.watchersMu.Unlock()
+	if !ok {
+		return
+	}
+	w.stop(finalSync)
+	kept := ""
+	if keepFile {
+		kept = "it has local edits the browser does not have"
+	}
+	select {
+	case <-w.doneCh:
+		c.watchersMu.Lock()
+		state := w.syncState
+		c.watchersMu.Unlock()
+		if finalSync && kept == "" && state != bridge.SyncStateSynced {
+			kept = "the last save was not sent (" + state + ")"
+		}
+	case <-time.After(5 * time.Second):
+		c.log("Timed out waiting for final sync of snippet " + key.String())
+		if finalSync && kept == "" {
+			kept = "the final sync did not finish"
+		}
+	}
+	c.setTransform(key, nil)
+	c.setGuard(key, nil)
+	c.setTextStyle(key, nil)
+	c.setBinary(key, nil)
+	c.setDetection(key, nil)
+	c.forgetDelta(key)
+	if kept != "" {
+		c.log(fmt.Sprintf("Keeping temp file of snippet %s, %s: %s", key, kept, w.tmpFile))
+		c.sendEditEvent(key, "file_kept", "temp file kept, "+kept)
+	} else if err := os.Remove(w.tmpFile); err != nil && !os.IsNotExist(err) {
+		c.log("Failed to remove temp file: " + err.Error())
+	} else {
+		c.tempFiles.Forget(w.tmpFile)
+	}
+	c.notifyWatchersChanged()
+}
@@ -0,0 +1,131 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Merge
+ * @tagline         Three-way merge of local edits with browser code
+ * @description     Keeps the last synced base version of each temp file, and merges
+ *                  undelivered local edits with incoming browser code on re-open
+ * @file            desktop/merge.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// baseVersionsDir returns the folder of the base versions, next to the user config
+func baseVersionsDir() string {
+	return filepath.Join(filepath.Dir(configPath()), "base")
+}
+
+// baseVersionPath returns where the last synced version of a temp file is kept
+func baseVersionPath(tmpFile string) string {
+	return bridge.BaseVersionPath(baseVersionsDir(), tmpFile)
+}
+
+// saveBaseVersion records the content last synced between browser and temp file
+func saveBaseVersion(tmpFile, content string) error {
+	path := baseVersionPath(tmpFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0600)
+}
+
+// editMerge holds the versions of a snippet merged with browser code on re-open
+type editMerge struct {
+	base      string // base version before the merge
+	local     string // undelivered local content, empty if there was nothing to merge
+	conflicts int
+}
+
+// writeEditFile writes incoming browser code to the temp file. If the temp file has
+// local edits that were never delivered, they are merged with the browser code using
+// the last synced base version, which is then replaced by the browser code or the
+// merged code. Returns the versions that were merged, for a merge tool.
+func (c *WebSocketClient) writeEditFile(key bridge.SessionKey, tmpFile, fileType, code string) (editMerge, error) {
+	local, errLocal := os.ReadFile(tmpFile)
+	if errLocal == nil {
+		// Local edits that are not valid text cannot be merged, the browser code replaces them
+		if local, errLocal = c.decodeTemp(key, local); errLocal != nil {
+			c.log(fmt.Sprintf("Replacing temp file of snippet %s with the browser code: %s", key, errLocal.Error()))
+		}
+	}
+	base, errBase := os.ReadFile(baseVersionPath(tmpFile))
+	if errLocal != nil || errBase != nil || string(local) == string(base) || string(local) == code {
+		if err := os.WriteFile(tmpFile, c.encodeTemp(key, []byte(code)), 0644); err != nil {
+			return editMerge{}, err
+		}
+		if err := saveBaseVersion(tmpFile, code); err != nil {
+			c.log("Failed to save base version: " + err.Error())
+		}
+		return editMerge{}, nil
+	}
+
+	merged, conflicts := bridge.Merge3(string(base), string(local), code)
+	if err := os.WriteFile(tmpFile, c.encodeTemp(key, []byte(merged)), 0644); err != nil {
+		return editMerge{}, err
+	}
+	if conflicts > 0 {
+		// The browser code is the new base; the user resolves the markers and saves
+		c.log(fmt.Sprintf("Snippet %s has undelivered local edits that conflict with the browser code: %d conflicts", key, conflicts))
+		if err := saveBaseVersion(tmpFile, code); err != nil {
+			c.log("Failed to save base version: " + err.Error())
+		}
+		c.sendEditEvent(key, "merge_conflict", fmt.Sprintf("%d conflicts", conflicts))
+		return editMerge{base: string(base), local: string(local), conflicts: conflicts}, nil
+	}
+
+	c.log(fmt.Sprintf("Merged undelivered local edits of snippet %s with the browser code", key))
+	if merged != code && c.getStatus() == "connected" {
+		// Send the merged snippet without its read-only context
+		snippet, err := merged, error(nil)
+		if g, ok := c.guardOf(key); ok {
+			snippet, _, err = g.Unwrap(merged)
+		}
+		if err != nil {
+			c.log(fmt.Sprintf("Not sending merged snippet %s to the browser: %s", key, err.Error()))
+		} else {
+			c.sendCodeUpdate(key, snippet, fileType)
+		}
+	}
+	if err := saveBaseVersion(tmpFile, merged); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	return editMerge{base: string(base), local: string(local)}, nil
+}
+
+// launchMergeTool opens the configured merge tool for a conflicting snippet. The base
+// version before the merge, the local and the browser versions are written to a new temp
+// dir; the tool saves to the temp file.
+func (c *WebSocketClient) launchMergeTool(key bridge.SessionKey, tmpFile, fileType, tool string, m editMerge, remote string) error {
+	dir, err := os.MkdirTemp("", "web-merge-")
+	if err != nil {
+		return err
+	}
+	if err := c.tempFiles.Record(dir, key.String()); err != nil {
+		c.log("Failed to update temp file manifest: " + err.Error())
+	}
+	name := strings.TrimSuffix(filepath.Base(tmpFile), filepath.Ext(tmpFile))
+	files, err := bridge.WriteMergeToolFiles(dir, name, fileType, m.base, m.local, remote)
+	if err != nil {
+		return err
+	}
+	launch, err := bridge.MergeToolCommand(tool, files, tmpFile, key.SnippetID, fileType)
+	if err != nil {
+		return err
+	}
+	c.log("Launching merge tool: " + launch.String())
+	return c.launchIDE(key, launch)
+}
@@ -0,0 +1,248 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Sessions
+ * @tagline         Actions on active edit sessions
+ * @description     Tracks the editor and sync state of active edit sessions, and implements
+ *                  the actions of the sessions panel: reopen, reveal, resend, stop and discard
+ * @file            desktop/sessions.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// watcher returns the active watcher of a session
+func (c *WebSocketClient) watcher(key bridge.SessionKey) (*fileWatch, bool) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	w, ok := c.watchers[key]
+	return w, ok
+}
+
+// setSyncState records the sync state of a session, and the time of an IDE save
+func (c *WebSocketClient) setSyncState(w *fileWatch, state string, saved bool) {
+	c.watchersMu.Lock()
+	w.syncState = state
+	if saved {
+		w.lastSave = time.Now()
+	}
+	c.watchersMu.Unlock()
+	c.notifyWatchersChanged()
+}
+
+// setLocalEdits records that undelivered local edits were merged into the temp file of
+// a session when it was opened
+func (c *WebSocketClient) setLocalEdits(key bridge.SessionKey) {
+	c.watchersMu.Lock()
+	if w, ok := c.watchers[key]; ok {
+		w.localEdits = true
+	}
+	c.watchersMu.Unlock()
+}
+
+// hasLocalEdits reports whether the temp file of a session has edits the browser does
+// not have: merged local edits, unresolved conflicts, or saves that were not sent
+func (c *WebSocketClient) hasLocalEdits(key bridge.SessionKey) bool {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	w, ok := c.watchers[key]
+	return ok && (w.localEdits || w.syncState != bridge.SyncStateSynced)
+}
+
+// setEditor records the IDE or merge tool a session was opened in
+func (c *WebSocketClient) setEditor(key bridge.SessionKey, editor string) {
+	c.watchersMu.Lock()
+	if w, ok := c.watchers[key]; ok {
+		w.editor = editor
+	}
+	c.watchersMu.Unlock()
+	c.notifyWatchersChanged()
+}
+
+// forgetEditor stops tracking the IDE process of a session, so that closing the
+// IDE later does not end or report on a session the user already ended
+func (c *WebSocketClient) forgetEditor(key bridge.SessionKey) {
+	c.watchersMu.Lock()
+	delete(c.editors, key)
+	c.watchersMu.Unlock()
+}
+
+// reopenSession launches the IDE again for the temp file of a session, or for all
+// files of its batch, without rewriting them. A failed launch keeps the session and
+// its local edits.
+func (c *WebSocketClient) reopenSession(key bridge.SessionKey) error {
+	w, ok := c.watcher(key)
+	if !ok {
+		return fmt.Errorf("snippet %s is not open", key)
+	}
+	c.statusMu.Lock()
+	currentCfg := c.cfg
+	c.statusMu.Unlock()
+
+	var files []string
+	for _, k := range c.sessionGroup(key) {
+		if other, ok := c.watcher(k); ok {
+			files = append(files, other.tmpFile)
+		}
+	}
+	ideCmd, profile, _ := selectLaunch(currentCfg, w.fileType, filepath.Base(w.tmpFile))
+	launch, err := bridge.ResolveLaunch(profile, bridge.LaunchContext{
+		File:      w.tmpFile,
+		Files:     files,
+		Workspace: bridge.WorkspaceOf(w.tmpFile),
+		SnippetID: key.SnippetID,
+		FileType:  w.fileType,
+		IDE:       ideCmd,
+	})
+	if err != nil {
+		return err
+	}
+	c.log(fmt.Sprintf("Reopening snippet %s in IDE: %s", key, launch.String()))
+	if err := c.launchIDE(key, launch); err != nil {
+		return err
+	}
+	c.watchersMu.Lock()
+	if p, ok := c.editors[key]; ok {
+		p.reopen = true
+	}
+	c.watchersMu.Unlock()
+	for _, k := range c.sessionGroup(key) {
+		c.setEditor(k, filepath.Base(ideCmd))
+		c.sendEditEvent(k, "edit_started", "reopened in "+ideCmd)
+	}
+	return nil
+}
+
+// revealSession shows the temp file of a session in the file manager
+func (c *WebSocketClient) revealSession(key bridge.SessionKey) error {
+	w, ok := c.watcher(key)
+	if !ok {
+		return fmt.Errorf("snippet %s is not open", key)
+	}
+	return revealInFileManager(w.tmpFile)
+}
+
+// revealInFileManager opens the file manager at a file; on Linux the folder is opened,
+// since there is no common way to select a file
+func revealInFileManager(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	case "windows":
+		cmd = exec.Command("explorer", "/select,"+path)
+	default:
+		cmd = exec.Command("xdg-open", filepath.Dir(path))
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// resendSession sends the current content of the temp file of a session to the
+// browser, for example after saving while disconnected
+func (c *WebSocketClient) resendSession(key bridge.SessionKey) error {
+	w, ok := c.watcher(key)
+	if !ok {
+		return fmt.Errorf("snippet %s is not open", key)
+	}
+	if c.getStatus() != "connected" {
+		return fmt.Errorf("not connected to the server")
+	}
+	raw, err := os.ReadFile(w.tmpFile)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.binaryOf(key); ok {
+		c.log(fmt.Sprintf("Resending binary snippet %s to server, %d bytes", key, len(raw)))
+		if !c.sendCodeUpdate(key, string(raw), w.fileType) {
+			return fmt.Errorf("snippet %s was not sent", key)
+		}
+		if err := saveBaseVersion(w.tmpFile, string(raw)); err != nil {
+			c.log("Failed to save base version: " + err.Error())
+		}
+		w.setSynced(sha256.Sum256(raw))
+		c.setSyncState(w, bridge.SyncStateSynced, false)
+		return nil
+	}
+	content, err := c.decodeSaved(key, raw)
+	if err != nil {
+		c.setSyncState(w, bridge.SyncStateInvalid, false)
+		return err
+	}
+	c.statusMu.Lock()
+	currentCfg := c.cfg
+	c.statusMu.Unlock()
+	code, content, err := c.unguardSaved(key, w.tmpFile, content)
+	if err != nil {
+		c.setSyncState(w, bridge.SyncStateInvalid, false)
+		return err
+	}
+	code, written := c.formatOutbound(key, w.tmpFile, w.fileType, code, currentCfg.Formatters, currentCfg.FormatWriteBack)
+	if written {
+		content = c.guardContent(key, code)
+	}
+	diagnostics := c.validateOutbound(key, w.tmpFile, w.fileType, code, currentCfg.Validators)
+	if bridge.BlocksSync(currentCfg.ValidationPolicy, diagnostics) {
+		c.sendValidationFailed(key, diagnostics)
+		c.setSyncState(w, bridge.SyncStateInvalid, false)
+		return fmt.Errorf("snippet %s has %d validation errors", key, bridge.CountSeverity(diagnostics, bridge.SeverityError))
+	}
+	c.log(fmt.Sprintf("Resending snippet %s to server, fileType: %s, codeLength: %d", key, w.fileType, len(code)))
+	if !c.sendCodeUpdate(key, string(code), w.fileType, diagnostics...) {
+		return fmt.Errorf("snippet %s was not sent", key)
+	}
+	if err := saveBaseVersion(w.tmpFile, string(content)); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	if raw, err := os.ReadFile(w.tmpFile); err == nil {
+		w.setSynced(sha256.Sum256(raw))
+	}
+	c.setSyncState(w, bridge.SyncStateSynced, false)
+	return nil
+}
+
+// stopSession stops watching the temp file of a session, and of the other sessions
+// of its batch; the files are kept
+func (c *WebSocketClient) stopSession(key bridge.SessionKey) {
+	for _, k := range c.sessionGroup(key) {
+		c.forgetEditor(k)
+		c.stopFileWatcher(k)
+		c.setTransform(k, nil)
+		c.setGuard(k, nil)
+		c.setTextStyle(k, nil)
+		c.setBinary(k, nil)
+		c.setDetection(k, nil)
+		c.forgetDelta(k)
+		c.sendEditEvent(k, "watch_stopped", "stopped by user")
+	}
+}
+
+// discardSession stops watching a session and the other sessions of its batch, and
+// removes their temp files, without sending unsaved or unsent changes
+func (c *WebSocketClient) discardSession(key bridge.SessionKey) {
+	for _, k := range c.sessionGroup(key) {
+		c.forgetEditor(k)
+		c.log("Discarding edit session of snippet " + k.String())
+		c.endEditSession(k, false, false)
+		c.sendEditEvent(k, "watch_stopped", "discarded by user")
+	}
+}
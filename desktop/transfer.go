@@ -0,0 +1,268 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Transfer
+ * @tagline         Chunked transfer of large messages with integrity checks
+ * @description     Splits messages larger than the largest WebSocket message of the peer
+ *                  into chunks, reassembles and verifies chunked messages from the server,
+ *                  and reports the progress of large transfers to the UI
+ * @file            desktop/transfer.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// Largest WebSocket message in KB, by default and at least
+const (
+	defaultMaxMessageKB = 1024
+	minMaxMessageKB     = 64
+)
+
+// maxTransferSize is the largest message reassembled from chunks
+const maxTransferSize = 64 << 20
+
+// legacyMaxMessage is the largest message of servers that do not tell their limit
+const legacyMaxMessage = 10 << 20
+
+// Chunked messages that do not complete within transferTimeout are dropped
+const transferTimeout = 2 * time.Minute
+
+// normalizeMaxMessageKB maps unset values to the default, and raises small values to the minimum
+func normalizeMaxMessageKB(kb int) int {
+	if kb <= 0 {
+		return defaultMaxMessageKB
+	}
+	if kb < minMaxMessageKB {
+		return minMaxMessageKB
+	}
+	return kb
+}
+
+// chunkMessage is one part of a message that is larger than the largest WebSocket
+// message of the receiver: the data of all chunks, in index order, has size bytes
+// and the hex SHA-256 hash of the message.
+type chunkMessage struct {
+	Type         string `json:"type"`
+	ConnectionID string `json:"connectionId,omitempty"`
+	TransferID   string `json:"transferId"`
+	Index        int    `json:"index"`
+	Count        int    `json:"count"`
+	Size         int    `json:"size"`
+	Hash         string `json:"hash"`
+	Data         []byte `json:"data"` // base64 in JSON
+}
+
+// splitMessage returns the chunks of a message, each of which encodes to at most
+// maxSize bytes
+func splitMessage(connectionID, transferID string, data []byte, maxSize int) []chunkMessage {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	// Base64 grows data by 4/3, and the chunk fields need some room
+	chunkSize := (maxSize - 1024) * 3 / 4
+	if chunkSize < 1024 {
+		chunkSize = 1024
+	}
+	count := (len(data) + chunkSize - 1) / chunkSize
+	chunks := make([]chunkMessage, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, chunkMessage{Type: "chunk", ConnectionID: connectionID, TransferID: transferID, Index: i, Count: count,
+			Size: len(data), Hash: hash, Data: data[i*chunkSize : end]})
+	}
+	return chunks
+}
+
+// incomingTransfer is a chunked message being received
+type incomingTransfer struct {
+	first   chunkMessage
+	data    bytes.Buffer
+	next    int
+	started time.Time
+}
+
+// add appends a chunk to the transfer; done is true once the message is complete and
+// its size and hash were verified
+func (t *incomingTransfer) add(chunk chunkMessage) (done bool, err error) {
+	if chunk.Index != t.next || chunk.Count != t.first.Count || chunk.Size != t.first.Size || chunk.Hash != t.first.Hash {
+		return false, fmt.Errorf("chunk %d of %d does not fit the transfer", chunk.Index+1, chunk.Count)
+	}
+	if t.data.Len()+len(chunk.Data) > t.first.Size {
+		return false, fmt.Errorf("more data than announced")
+	}
+	t.data.Write(chunk.Data)
+	t.next++
+	if t.next < t.first.Count {
+		return false, nil
+	}
+	sum := sha256.Sum256(t.data.Bytes())
+	if t.data.Len() != t.first.Size || hex.EncodeToString(sum[:]) != t.first.Hash {
+		return false, fmt.Errorf("integrity check failed")
+	}
+	return true, nil
+}
+
+// transferProgress is the progress of a large transfer, for the UI
+type transferProgress struct {
+	Label string // for example "Sending snippet abc"
+	Done  int    // bytes transferred
+	Total int
+}
+
+// setTransferProgress records the progress of a transfer, or removes it once done
+func (c *WebSocketClient) setTransferProgress(id string, p transferProgress) {
+	c.statusMu.Lock()
+	if p.Done >= p.Total {
+		delete(c.transfers, id)
+	} else {
+		c.transfers[id] = p
+	}
+	c.statusMu.Unlock()
+	select {
+	case c.transfersCh <- struct{}{}:
+	default:
+	}
+}
+
+// transferStatus returns the combined progress of the running transfers, with ok false if none
+func (c *WebSocketClient) transferStatus() (label string, fraction float64, ok bool) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	done, total := 0, 0
+	for _, p := range c.transfers {
+		label = p.Label
+		done += p.Done
+		total += p.Total
+	}
+	if total == 0 {
+		return "", 0, false
+	}
+	if len(c.transfers) > 1 {
+		label = fmt.Sprintf("%d transfers", len(c.transfers))
+	}
+	return fmt.Sprintf("%s: %.1f of %.1f MB", label, float64(done)/(1<<20), float64(total)/(1<<20)), float64(done) / float64(total), true
+}
+
+// setChunkSupported records whether the server accepts chunked messages, and its
+// largest message in bytes, from its connection_ack
+func (c *WebSocketClient) setChunkSupported(supported bool, maxMessage int) {
+	c.statusMu.Lock()
+	c.chunkSupported = supported
+	c.serverMaxMessage = maxMessage
+	c.statusMu.Unlock()
+}
+
+// maxMessageSize returns the largest WebSocket message that both the app and the
+// server accept, in bytes
+func (c *WebSocketClient) maxMessageSize() int {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	size := normalizeMaxMessageKB(c.cfg.MaxMessageKB) * 1024
+	if c.serverMaxMessage > 0 && c.serverMaxMessage < size {
+		size = c.serverMaxMessage
+	}
+	return size
+}
+
+// sendLarge sends a message of a session, in chunks if it is larger than the largest
+// message of the server, and shows the progress of chunked messages in the UI. Messages
+// too large for servers without chunk support are not sent, and an edit event is sent.
+func (c *WebSocketClient) sendLarge(key bridge.SessionKey, data []byte) error {
+	label := "snippet " + key.SnippetID
+	maxSize := c.maxMessageSize()
+	c.statusMu.Lock()
+	chunked := c.chunkSupported
+	serverMax := c.serverMaxMessage
+	connectionID := c.cfg.ConnectionID
+	c.statusMu.Unlock()
+	if !chunked {
+		if serverMax <= 0 {
+			serverMax = legacyMaxMessage
+		}
+		if len(data) > serverMax {
+			reason := fmt.Sprintf("%d bytes is more than the largest message of the server, %d bytes, which does not accept chunked messages", len(data), serverMax)
+			c.sendEditEvent(key, "send_failed", reason)
+			return fmt.Errorf("not sent, %s", reason)
+		}
+		return c.writeMessage(websocket.TextMessage, data)
+	}
+	if len(data) <= maxSize {
+		return c.writeMessage(websocket.TextMessage, data)
+	}
+	id := generateUUID()
+	chunks := splitMessage(connectionID, id, data, maxSize)
+	c.log(fmt.Sprintf("Sending %s in %d chunks, %d bytes", label, len(chunks), len(data)))
+	done := 0
+	for _, chunk := range chunks {
+		msg, _ := json.Marshal(chunk)
+		if err := c.writeMessage(websocket.TextMessage, msg); err != nil {
+			c.setTransferProgress(id, transferProgress{})
+			return err
+		}
+		done += len(chunk.Data)
+		c.setTransferProgress(id, transferProgress{Label: "Sending " + label, Done: done, Total: len(data)})
+	}
+	return nil
+}
+
+// receiveChunk adds a chunk from the server to its transfer, and returns the message
+// once all chunks arrived. Transfers are per connection, and dropped on errors and
+// after transferTimeout.
+func (c *WebSocketClient) receiveChunk(transfers map[string]*incomingTransfer, raw []byte) ([]byte, error) {
+	var chunk chunkMessage
+	if err := json.Unmarshal(raw, &chunk); err != nil {
+		return nil, fmt.Errorf("invalid chunk: %v", err)
+	}
+	for id, t := range transfers {
+		if time.Since(t.started) > transferTimeout {
+			delete(transfers, id)
+			c.setTransferProgress(id, transferProgress{})
+			c.log(fmt.Sprintf("Dropped chunked message %s from server, it did not complete in time", id))
+		}
+	}
+	if chunk.Index == 0 {
+		if chunk.Size > maxTransferSize || chunk.Count <= 0 {
+			return nil, fmt.Errorf("chunked message %s too large, %d bytes", chunk.TransferID, chunk.Size)
+		}
+		first := chunk
+		first.Data = nil
+		transfers[chunk.TransferID] = &incomingTransfer{first: first, started: time.Now()}
+	}
+	t, ok := transfers[chunk.TransferID]
+	if !ok {
+		return nil, fmt.Errorf("chunked message %s: first chunk missing", chunk.TransferID)
+	}
+	done, err := t.add(chunk)
+	if err != nil || done {
+		delete(transfers, chunk.TransferID)
+		c.setTransferProgress(chunk.TransferID, transferProgress{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("chunked message %s: %v", chunk.TransferID, err)
+	}
+	if !done {
+		c.setTransferProgress(chunk.TransferID, transferProgress{Label: "Receiving snippet", Done: t.data.Len(), Total: chunk.Size})
+		return nil, nil
+	}
+	c.log(fmt.Sprintf("Received chunked message of %d chunks, %d bytes", chunk.Count, chunk.Size))
+	return t.data.Bytes(), nil
+}
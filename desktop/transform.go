@@ -0,0 +1,193 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Transform
+ * @tagline         Bidirectional content transforms between the browser and the temp file
+ * @description     Codecs that decode snippets on the way into the temp file, such as
+ *                  pretty-printing minified JSON, converting JSON to YAML or unescaping
+ *                  strings, and encode them back to the original form when sending
+ * @file            desktop/transform.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// Transform decodes the code of a snippet into the form edited in the IDE, and encodes
+// it back when it is sent to the browser: a built-in codec, or a pair of external
+// commands that read the code on stdin and write the result to stdout
+type Transform struct {
+	Pattern   string `json:"pattern"`              // fileTypes or file name globs, as in IDE mappings
+	Codec     string `json:"codec,omitempty"`      // built-in: json-pretty, json-yaml or unescape
+	Decode    string `json:"decode,omitempty"`     // external command from browser code to temp file content
+	Encode    string `json:"encode,omitempty"`     // external command from temp file content to browser code
+	FileType  string `json:"file_type,omitempty"`  // fileType of the temp file, defaults to the codec's
+	TimeoutMs int    `json:"timeout_ms,omitempty"` // for external commands, defaults to 10 seconds
+}
+
+// transformNone in an edit request edits the code as is, whatever the configured transforms
+const transformNone = "none"
+
+// sessionTransform is the transform applied to an edit session, and the fileType of the
+// snippet in the browser
+type sessionTransform struct {
+	Transform
+	browserType string
+}
+
+// name returns the name of a transform for the log and the sessions panel
+func (t Transform) name() string {
+	if t.Codec != "" {
+		return t.Codec
+	}
+	return "custom"
+}
+
+// decodedType returns the fileType of the decoded code of a snippet
+func (t Transform) decodedType(fileType string) string {
+	if t.FileType != "" {
+		return bridge.SanitizeFileName(t.FileType, 16)
+	}
+	if c, ok := bridge.Codecs[t.Codec]; ok && c.FileType != "" {
+		return c.FileType
+	}
+	return fileType
+}
+
+// convert runs the decode or encode direction of a transform
+func (t Transform) convert(decode bool, code string, key bridge.SessionKey, tmpFile, fileType string) (string, error) {
+	if t.Codec != "" {
+		c, ok := bridge.Codecs[t.Codec]
+		if !ok {
+			return "", fmt.Errorf("unknown codec %q", t.Codec)
+		}
+		if decode {
+			return c.Decode(code)
+		}
+		return c.Encode(code)
+	}
+	command := t.Encode
+	if decode {
+		command = t.Decode
+	}
+	if command == "" {
+		return "", fmt.Errorf("transform has no codec and no decode and encode commands")
+	}
+	stdout, stderr, err := bridge.RunTool(command, bridge.ToolTimeout(t.TimeoutMs), []byte(code), key, tmpFile, fileType)
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(stdout), nil
+}
+
+// decodeLossless decodes browser code, and verifies that encoding the result gives the
+// code back unchanged, so that a transform never alters a snippet that is not edited
+func (t Transform) decodeLossless(code string, key bridge.SessionKey, tmpFile, fileType string) (string, error) {
+	return bridge.DecodeLossless(code,
+		func(code string) (string, error) { return t.convert(true, code, key, tmpFile, fileType) },
+		func(code string) (string, error) { return t.convert(false, code, key, tmpFile, fileType) })
+}
+
+// selectTransform returns the transform for a snippet: the codec requested by the
+// browser, or the first configured transform that matches the fileType
+func selectTransform(rules []Transform, requested, fileType string) (Transform, bool) {
+	if requested == transformNone {
+		return Transform{}, false
+	}
+	if requested != "" {
+		_, ok := bridge.Codecs[requested]
+		return Transform{Codec: requested}, ok
+	}
+	fileName := "snippet." + bridge.SanitizeFileName(fileType, 16)
+	for _, t := range rules {
+		if bridge.MatchesFileType(t.Pattern, fileType, fileName) {
+			return t, true
+		}
+	}
+	return Transform{}, false
+}
+
+// decodeForEdit applies the transform of a snippet to the code from the browser. Returns
+// the code and fileType of the temp file; a transform that fails or is not lossless for
+// the code is logged and skipped, and the code is edited as is.
+func (c *WebSocketClient) decodeForEdit(rules []Transform, requested string, key bridge.SessionKey, code, fileType string) (string, string, *sessionTransform) {
+	t, ok := selectTransform(rules, requested, fileType)
+	if !ok {
+		if requested != "" && requested != transformNone {
+			c.log(fmt.Sprintf("Unknown transform %q requested for snippet %s, editing the code as is", requested, key))
+		}
+		return code, fileType, nil
+	}
+	decodedType := t.decodedType(fileType)
+	decoded, err := t.decodeLossless(code, key, bridge.TempFilePath(key, decodedType), fileType)
+	if err != nil {
+		c.log(fmt.Sprintf("Not applying transform %s to snippet %s, editing the code as is: %s", t.name(), key, err.Error()))
+		return code, fileType, nil
+	}
+	c.log(fmt.Sprintf("Applied transform %s to snippet %s: %s -> %s", t.name(), key, fileType, decodedType))
+	return decoded, decodedType, &sessionTransform{Transform: t, browserType: fileType}
+}
+
+// setTransform records the transform of an edit session, or removes it for nil
+func (c *WebSocketClient) setTransform(key bridge.SessionKey, t *sessionTransform) {
+	c.watchersMu.Lock()
+	if t == nil {
+		delete(c.transforms, key)
+	} else {
+		c.transforms[key] = *t
+	}
+	c.watchersMu.Unlock()
+}
+
+// transformOf returns the transform of an edit session
+func (c *WebSocketClient) transformOf(key bridge.SessionKey) (sessionTransform, bool) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	t, ok := c.transforms[key]
+	return t, ok
+}
+
+// encodeForBrowser converts the content of a temp file back to the form of the browser.
+// Returns the code and fileType to send.
+func (c *WebSocketClient) encodeForBrowser(key bridge.SessionKey, tmpFile, code, fileType string) (string, string, error) {
+	t, ok := c.transformOf(key)
+	if !ok {
+		return code, fileType, nil
+	}
+	encoded, err := t.convert(false, code, key, tmpFile, t.browserType)
+	if err != nil {
+		return "", "", fmt.Errorf("transform %s: %v", t.name(), err)
+	}
+	return encoded, t.browserType, nil
+}
+
+// decodeFromBrowser converts code from the browser to the form of the temp file
+func (c *WebSocketClient) decodeFromBrowser(key bridge.SessionKey, tmpFile, code string) (string, error) {
+	t, ok := c.transformOf(key)
+	if !ok {
+		return code, nil
+	}
+	decoded, err := t.decodeLossless(code, key, tmpFile, t.browserType)
+	if err != nil {
+		return "", fmt.Errorf("transform %s: %v", t.name(), err)
+	}
+	return decoded, nil
+}
+
+// transformLabel describes the transform of a session for the sessions panel
+func transformLabel(fileType string, t sessionTransform) string {
+	return fmt.Sprintf("%s (%s from %s)", fileType, t.name(), t.browserType)
+}
@@ -0,0 +1,54 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Validator
+ * @tagline         Validation of code sent to the browser, with structured diagnostics
+ * @description     Checks saved snippets with built-in JSON, XML and YAML parsers and with
+ *                  external linters; diagnostics are sent with the code update, and a policy
+ *                  decides whether errors block the sync
+ * @file            desktop/validator.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// Maximum number of diagnostics logged per save
+const maxLoggedDiagnostics = 10
+
+// validateOutbound runs the validators that match a snippet, see bridge.Validate, and
+// logs their diagnostics and the validators that cannot run
+func (c *WebSocketClient) validateOutbound(key bridge.SessionKey, tmpFile, fileType string, code []byte, validators []bridge.Validator) []bridge.Diagnostic {
+	diagnostics := bridge.Validate(validators, code, key, tmpFile, fileType, func(v bridge.Validator, err error) {
+		c.log(fmt.Sprintf("Validator %s failed for snippet %s, skipping it: %s", v.Name(), key, err.Error()))
+	})
+	for i, d := range diagnostics {
+		if i == maxLoggedDiagnostics {
+			c.log(fmt.Sprintf("... and %d more diagnostics for snippet %s", len(diagnostics)-i, key))
+			break
+		}
+		c.log(fmt.Sprintf("Snippet %s: %s", key, d))
+	}
+	return diagnostics
+}
+
+// sendValidationFailed tells the browser that code was kept local because of validation errors
+func (c *WebSocketClient) sendValidationFailed(key bridge.SessionKey, diagnostics []bridge.Diagnostic) {
+	reason := fmt.Sprintf("%d validation errors", bridge.CountSeverity(diagnostics, bridge.SeverityError))
+	for _, d := range diagnostics {
+		if d.Severity == bridge.SeverityError {
+			reason += ", first: " + d.String()
+			break
+		}
+	}
+	c.sendEditEvent(key, "validation_failed", reason, diagnostics...)
+}
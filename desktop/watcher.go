@@ -0,0 +1,434 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Watcher
+ * @tagline         File watching with fsnotify and polling fallback
+ * @description     Watches temp files for IDE saves using fsnotify, and falls back to
+ *                  polling by mtime, size and hash on filesystems without change events
+ * @file            desktop/watcher.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// After an atomic save, the new file is watched again with up to rewatchRetries retries,
+// starting after rewatchDelay and doubling; then the session falls back to polling
+const (
+	rewatchRetries = 5
+	rewatchDelay   = 50 * time.Millisecond
+)
+
+// fileWatch holds the state of one active file watcher
+type fileWatch struct {
+	stopCh    chan struct{}
+	doneCh    chan struct{} // closed when the watcher goroutine exits
+	stopOnce  sync.Once
+	finalSync bool // set before stopCh is closed: sync the file one last time
+	tmpFile   string
+	fileType  string
+	mode      string // effective mode: fsnotify or poll
+
+	// Shown in the sessions panel, guarded by c.watchersMu
+	editor    string    // IDE or merge tool the snippet was opened in
+	syncState string    // one of the bridge.SyncState constants
+	lastSave  time.Time // last save in the IDE
+	batch     string    // ID of the batch the snippet was opened with, empty for single snippets
+
+	localEdits bool // opened with undelivered local edits merged into the temp file, guarded by c.watchersMu
+
+	synced bridge.SyncedHash // content the browser already has, not sent back
+}
+
+// newFileWatch creates the state for a new watcher
+func newFileWatch(tmpFile, fileType string) *fileWatch {
+	return &fileWatch{
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+		tmpFile:   tmpFile,
+		fileType:  fileType,
+		syncState: bridge.SyncStateSynced,
+	}
+}
+
+// stop signals the watcher to exit, optionally after a final sync of the file
+func (w *fileWatch) stop(finalSync bool) {
+	w.stopOnce.Do(func() {
+		w.finalSync = finalSync
+		close(w.stopCh)
+	})
+}
+
+// setSynced records content that the browser already has
+func (w *fileWatch) setSynced(hash [sha256.Size]byte) {
+	w.synced.Set(hash)
+}
+
+// isSynced reports whether content is what the browser already has
+func (w *fileWatch) isSynced(hash [sha256.Size]byte) bool {
+	return w.synced.Is(hash)
+}
+
+// Watch file for changes and send updates if connected
+func (c *WebSocketClient) watchFileAndSendUpdates(w *fileWatch, key bridge.SessionKey) {
+	defer close(w.doneCh)
+	tmpFile, fileType := w.tmpFile, w.fileType
+
+	c.statusMu.Lock()
+	currentCfg := c.cfg
+	c.statusMu.Unlock()
+
+	interval := bridge.PollInterval(currentCfg.PollIntervalMs)
+	autoMode := bridge.NormalizeWatchMode(currentCfg.WatchMode) == bridge.WatchModeAuto
+	mode, reason := bridge.ResolveWatchMode(currentCfg.WatchMode, tmpFile)
+
+	// Initialize last snapshot with current file content to avoid detecting initial file creation
+	_, last, err := bridge.ReadFileSnapshot(tmpFile)
+	if err != nil {
+		c.log("Failed to read initial file content: " + err.Error())
+		return
+	}
+
+	var watcher *fsnotify.Watcher
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+	if mode == bridge.WatchModeFsnotify {
+		watcher, err = fsnotify.NewWatcher()
+		if err == nil {
+			err = watcher.Add(tmpFile)
+		}
+		if err != nil {
+			c.log("Failed to watch temp file for changes, falling back to polling: " + err.Error())
+			if watcher != nil {
+				watcher.Close()
+				watcher = nil
+			}
+			mode, reason = bridge.WatchModePoll, "fsnotify unavailable"
+		} else {
+			events, watchErrs = watcher.Events, watcher.Errors
+		}
+	}
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+	}()
+
+	// In fsnotify mode the ticker is only used in auto mode, to detect missing events
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	if mode == bridge.WatchModePoll || autoMode {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	// switchToPolling stops using file system events for the rest of the session
+	switchToPolling := func() {
+		if watcher != nil {
+			watcher.Close()
+			watcher = nil
+		}
+		events, watchErrs = nil, nil
+		if ticker == nil {
+			ticker = time.NewTicker(interval)
+			tick = ticker.C
+		}
+		mode = bridge.WatchModePoll
+		c.setWatchMode(key, mode)
+	}
+
+	c.setWatchMode(key, mode)
+	if mode == bridge.WatchModePoll {
+		c.log(fmt.Sprintf("Now watching for %s file changes in IDE (polling every %v, %s)...", key, interval, reason))
+	} else {
+		c.log(fmt.Sprintf("Now watching for %s file changes in IDE (%s)...", key, mode))
+	}
+
+	// checkFile reads the file, formats and validates it, and sends it if the content changed
+	checkFile := func() {
+		content, snap, err := bridge.ReadFileSnapshot(tmpFile)
+		if err != nil {
+			c.log("Failed to read file: " + err.Error())
+			return
+		}
+		changed := snap.Hash != last.Hash
+		last = snap
+		if !changed {
+			return
+		}
+		if w.isSynced(snap.Hash) {
+			// Echo of an update written from the browser
+			return
+		}
+		if _, ok := c.binaryOf(key); ok {
+			// Binary content is sent as is, without text processing
+			if c.handleFileChange(key, tmpFile, fileType, content, content, nil) {
+				w.setSynced(last.Hash)
+				c.setSyncState(w, bridge.SyncStateSynced, true)
+			} else {
+				c.setSyncState(w, bridge.SyncStateUnsent, true)
+			}
+			return
+		}
+		// From here on, content is UTF-8 text with LF line endings
+		if content, err = c.decodeSaved(key, content); err != nil {
+			c.setSyncState(w, bridge.SyncStateInvalid, true)
+			return
+		}
+		// rewritten records content written back to the temp file, which is not a new change
+		rewritten := func(data []byte) {
+			content = data
+			if raw, snap, err := bridge.ReadFileSnapshot(tmpFile); err == nil {
+				if text, err := c.decodeTemp(key, raw); err == nil && bytes.Equal(text, data) {
+					last = snap
+				}
+			}
+		}
+		code, guarded, err := c.unguardSaved(key, tmpFile, content)
+		if err != nil {
+			c.setSyncState(w, bridge.SyncStateInvalid, true)
+			return
+		}
+		if !bytes.Equal(guarded, content) {
+			rewritten(guarded)
+		}
+		code, written := c.formatOutbound(key, tmpFile, fileType, code, currentCfg.Formatters, currentCfg.FormatWriteBack)
+		if written {
+			rewritten(c.guardContent(key, code))
+		}
+		diagnostics := c.validateOutbound(key, tmpFile, fileType, code, currentCfg.Validators)
+		if bridge.BlocksSync(currentCfg.ValidationPolicy, diagnostics) {
+			c.log(fmt.Sprintf("Not sending snippet %s to the browser: %d validation errors. Fix them and save again.", key, bridge.CountSeverity(diagnostics, bridge.SeverityError)))
+			c.sendValidationFailed(key, diagnostics)
+			c.setSyncState(w, bridge.SyncStateInvalid, true)
+			return
+		}
+		if c.handleFileChange(key, tmpFile, fileType, code, content, diagnostics) {
+			w.setSynced(last.Hash)
+			c.setSyncState(w, bridge.SyncStateSynced, true)
+		} else {
+			c.setSyncState(w, bridge.SyncStateUnsent, true)
+		}
+	}
+
+	// rewatch watches the file again after an atomic save replaced it. The new file may
+	// not exist yet, so failures are retried with a backoff before switching to polling.
+	var retry <-chan time.Time
+	retries := 0
+	rewatch := func() {
+		if watcher == nil {
+			return
+		}
+		if err := watcher.Add(tmpFile); err != nil {
+			if retries < rewatchRetries {
+				if retries == 0 {
+					c.log(fmt.Sprintf("Failed to watch %s again after it was replaced, retrying: %s", key, err.Error()))
+				}
+				retry = time.After(rewatchDelay << retries)
+				retries++
+				return
+			}
+			c.log(fmt.Sprintf("Failed to watch %s again after %d retries, switching to polling every %v: %s", key, retries, interval, err.Error()))
+			retries = 0
+			switchToPolling()
+			checkFile()
+			return
+		}
+		if retries > 0 {
+			c.log(fmt.Sprintf("Watching %s again after %d retries", key, retries))
+			retries = 0
+			c.setWatchMode(key, mode)
+		}
+		checkFile()
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Write == fsnotify.Write {
+				checkFile()
+			} else if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				// Atomic saves replace the file, watch the new file
+				rewatch()
+			}
+		case <-retry:
+			retry = nil
+			rewatch()
+		case err, ok := <-watchErrs:
+			if !ok {
+				return
+			}
+			c.log("File watcher error: " + err.Error())
+		case <-tick:
+			info, err := os.Stat(tmpFile)
+			if err != nil {
+				continue
+			}
+			// fsnotify gets one interval to deliver the event before assuming it never will
+			changed, missed := bridge.PollChanged(last, info, mode == bridge.WatchModeFsnotify, interval, time.Now())
+			if !changed {
+				continue
+			}
+			if missed {
+				c.log(fmt.Sprintf("File system events not delivered for %s, switching to polling every %v", key, interval))
+				switchToPolling()
+			}
+			checkFile()
+		case <-w.stopCh:
+			if w.finalSync {
+				checkFile()
+			}
+			c.log("Stopped watching for file changes in IDE")
+			return
+		}
+	}
+}
+
+// handleFileChange sends the code of a changed file to the server if connected; once
+// delivered, the file content becomes the base version for merging. The code differs
+// from the content if it was formatted and not written back. Diagnostics of the validators
+// are sent with the code. Returns true if the code was sent.
+func (c *WebSocketClient) handleFileChange(key bridge.SessionKey, tmpFile, fileType string, code, content []byte, diagnostics []bridge.Diagnostic) bool {
+	if c.getStatus() != "connected" {
+		c.log("File changed, but not connected. Please save again after reconnect.")
+		return false
+	}
+	c.log(fmt.Sprintf("Detected temp file change, sending code to server, snippet: %s, fileType: %s, codeLength: %d", key, fileType, len(code)))
+	if !c.sendCodeUpdate(key, string(code), fileType, diagnostics...) {
+		return false
+	}
+	if err := saveBaseVersion(tmpFile, string(content)); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	return true
+}
+
+// handleBrowserUpdate writes code changed in the web page to the temp file of an open
+// snippet. The write is atomic and not sent back to the browser. If the temp file has
+// local changes that were not sent yet, the update is not applied.
+func (c *WebSocketClient) handleBrowserUpdate(key bridge.SessionKey, code string) {
+	c.watchersMu.Lock()
+	w, ok := c.watchers[key]
+	c.watchersMu.Unlock()
+	if !ok {
+		c.log("Ignoring browser update for snippet " + key.String() + ", it is not open in the IDE")
+		return
+	}
+	if f, ok := c.binaryOf(key); ok {
+		c.applyBinaryUpdate(key, w, f, code)
+		return
+	}
+
+	code, err := c.decodeFromBrowser(key, w.tmpFile, bridge.NormalizeText(code))
+	if err != nil {
+		c.log(fmt.Sprintf("Browser update for snippet %s not applied: %s", key, err.Error()))
+		c.sendEditEvent(key, "update_rejected", err.Error())
+		return
+	}
+	content := string(c.guardContent(key, []byte(code)))
+	raw, err := os.ReadFile(w.tmpFile)
+	if err != nil {
+		c.log("Failed to read temp file: " + err.Error())
+		return
+	}
+	// Local content that is not valid text differs from any base version
+	local, errLocal := c.decodeTemp(key, raw)
+	base, errBase := os.ReadFile(baseVersionPath(w.tmpFile))
+	switch bridge.BrowserUpdateAction(content, string(local), errLocal, string(base), errBase) {
+	case bridge.UpdateSynced:
+		w.setSynced(sha256.Sum256(raw))
+		c.setSyncState(w, bridge.SyncStateSynced, false)
+		return
+	case bridge.UpdateRejected:
+		c.log(fmt.Sprintf("Browser update for snippet %s not applied: the temp file has local changes that were not sent yet. Save in the IDE to send them.", key))
+		c.sendEditEvent(key, "update_rejected", "local changes not sent yet")
+		c.setSyncState(w, bridge.SyncStateUnsent, false)
+		return
+	}
+
+	data := c.encodeTemp(key, []byte(content))
+	w.setSynced(sha256.Sum256(data))
+	if err := bridge.WriteFileAtomic(w.tmpFile, data); err != nil {
+		c.log("Failed to write browser update to temp file: " + err.Error())
+		return
+	}
+	if err := saveBaseVersion(w.tmpFile, content); err != nil {
+		c.log("Failed to save base version: " + err.Error())
+	}
+	c.tempFiles.Record(w.tmpFile, key.String())
+	c.setSyncState(w, bridge.SyncStateSynced, false)
+	c.log(fmt.Sprintf("Applied browser update to snippet %s, codeLength: %d", key, len(code)))
+}
+
+// setWatchMode records the effective watch mode of a session and notifies the UI
+func (c *WebSocketClient) setWatchMode(key bridge.SessionKey, mode string) {
+	c.watchersMu.Lock()
+	if w, ok := c.watchers[key]; ok {
+		w.mode = mode
+	}
+	c.watchersMu.Unlock()
+	c.notifyWatchersChanged()
+}
+
+// notifyWatchersChanged signals the UI that the watcher list changed
+func (c *WebSocketClient) notifyWatchersChanged() {
+	select {
+	case c.watchersCh <- struct{}{}:
+	default:
+	}
+}
+
+// getWatcherInfos returns the active watchers sorted by snippet ID and page
+func (c *WebSocketClient) getWatcherInfos() []bridge.SessionInfo {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	infos := make([]bridge.SessionInfo, 0, len(c.watchers))
+	for key, w := range c.watchers {
+		info := bridge.SessionInfo{
+			Key:       key,
+			TmpFile:   w.tmpFile,
+			FileType:  w.fileType,
+			Mode:      w.mode,
+			Editor:    w.editor,
+			SyncState: w.syncState,
+			LastSave:  w.lastSave,
+			Batch:     w.batch,
+		}
+		if d, ok := c.detections[key]; ok {
+			info.Transform = d.label(w.fileType)
+		}
+		if t, ok := c.transforms[key]; ok {
+			info.Transform = transformLabel(w.fileType, t)
+		}
+		if f, ok := c.binaries[key]; ok {
+			info.Transform = f.Label(w.fileType)
+		}
+		infos = append(infos, info)
+	}
+	bridge.SortSessions(infos)
+	return infos
+}
@@ -19,14 +19,15 @@ import (
 	"crypto/rand"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/color"
 	"log"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -40,8 +41,9 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"fyne.io/fyne/v2/storage"
-	"github.com/fsnotify/fsnotify"
 	"github.com/gorilla/websocket"
+
+	"web-ide-bridge-desktop/bridge"
 )
 
 // Version variables that can be set via build flags
@@ -77,10 +79,47 @@ type Config struct {
 	WebSocket    string `json:"websocket_url"`
 	IDECommand   string `json:"ide_command"`
 	ConnectionID string `json:"connection_id"`
+	// IDE launch template, environment and working directory; empty template launches IDECommand with the file
+	Launch bridge.LaunchProfile `json:"launch"`
+	// Per-language IDE selection by fileType or file name glob, first match wins
+	IDEMappings []bridge.IDEMapping `json:"ide_mappings"`
+	// File watching: "auto", "fsnotify" or "poll", and the polling interval
+	WatchMode      string `json:"watch_mode,omitempty"`
+	PollIntervalMs int    `json:"poll_interval_ms,omitempty"`
+	// Merge tool for conflicting edits, placeholders {base} {local} {remote} {merged}
+	MergeTool string `json:"merge_tool,omitempty"`
+	// Remove all temp files created by the app when quitting, for shared machines
+	WipeTempFilesOnQuit bool `json:"wipe_temp_files_on_quit,omitempty"`
+	// Workspace folders: "off" (loose temp files), "page" or "session", and their scaffolding
+	// templates by fileType: relative path -> content
+	Workspace          string                       `json:"workspace,omitempty"`
+	WorkspaceTemplates map[string]map[string]string `json:"workspace_templates,omitempty"`
+	// Outbound formatter pipeline by fileType, run on saved code before it is sent to the
+	// browser, and whether formatted code is written back to the temp file
+	Formatters      []bridge.Formatter `json:"formatters"`
+	FormatWriteBack bool               `json:"format_write_back,omitempty"`
+	// Validation pipeline by fileType, and whether errors block the sync: "warn" or "block"
+	Validators       []bridge.Validator `json:"validators"`
+	ValidationPolicy string             `json:"validation_policy,omitempty"`
+	// Content transforms by fileType between the browser code and the temp file
+	Transforms []Transform `json:"transforms"`
+	// Text style of temp files: "auto", "utf-8", "utf-8-bom" or "windows-1252", and "auto",
+	// "lf" or "crlf"; auto keeps the style of the editor's saves
+	TextEncoding string `json:"text_encoding,omitempty"`
+	LineEndings  string `json:"line_endings,omitempty"`
+	// Largest WebSocket message in KB, larger messages are sent in chunks
+	MaxMessageKB int `json:"max_message_kb,omitempty"`
+	// Language detection of snippets without fileType: "auto" (also generic fileTypes
+	// such as txt), "empty" or "off"
+	DetectLanguage string `json:"detect_language,omitempty"`
+	// Local version history: "on", "encrypted" or "off", and its retention per snippet
+	History            string `json:"history,omitempty"`
+	HistoryMaxVersions int    `json:"history_max_versions,omitempty"`
+	HistoryMaxDays     int    `json:"history_max_days,omitempty"`
 }
 
 // Update defaultConfig to use app config
-func defaultConfig() Config {
+func defaultConfig(discovery *editorDiscovery) Config {
 	usr, _ := user.Current()
 	userID := usr.Username
 
@@ -106,60 +145,97 @@ func defaultConfig() Config {
 	} else {
 		fmt.Printf("[DEBUG] Using default WebSocket URL: %s\n", wsURL)
 	}
-	ide := "TextEdit"
-	if runtime.GOOS == "darwin" {
-		if len(appCfg.DefaultIDEs["darwin"]) > 0 {
-			found := false
-			checked := []string{}
-			for _, candidate := range appCfg.DefaultIDEs["darwin"] {
-				appPath := filepath.Join("/Applications", candidate+".app")
-				checked = append(checked, appPath)
-				if _, err := os.Stat(appPath); err == nil {
-					ide = candidate
-					found = true
-					break
-				}
-			}
-			if !found {
-				ide = "TextEdit"
-			}
-			fmt.Printf("[IDE Detection] Checked: %v, Selected: %s\n", checked, ide)
-		} else {
-			editors := []struct {
-				name string
-				app  string
-			}{
-				{"Visual Studio Code", "/Applications/Visual Studio Code.app"},
-				{"Cursor", "/Applications/Cursor.app"},
-				{"Xcode", "/Applications/Xcode.app"},
-				{"TextEdit", "/Applications/TextEdit.app"},
-			}
-			for _, editor := range editors {
-				if _, err := os.Stat(editor.app); err == nil {
-					ide = editor.name
-					break
-				}
-			}
-		}
-	} else if runtime.GOOS == "windows" {
-		if len(appCfg.DefaultIDEs["windows"]) > 0 {
-			ide = appCfg.DefaultIDEs["windows"][0]
-		} else {
+	// Use the first configured IDE for this OS that is installed, then the first discovered editor
+	discovered := discovery.wait()
+	editor := selectInstalledIDE(appCfg.DefaultIDEs[runtime.GOOS], discovered)
+	ide := editor.Command
+	if ide == "" {
+		ide = "TextEdit"
+		if runtime.GOOS == "windows" {
 			ide = "notepad.exe"
-		}
-	} else if runtime.GOOS == "linux" {
-		if len(appCfg.DefaultIDEs["linux"]) > 0 {
-			ide = appCfg.DefaultIDEs["linux"][0]
-		} else {
-			ide = "gedit"
+		} else if runtime.GOOS != "darwin" {
+			ide = "xdg-open"
 		}
 	}
-	return Config{
+	fmt.Printf("[IDE Detection] Configured: %v, Discovered: %d editors, Selected: %s\n", appCfg.DefaultIDEs[runtime.GOOS], len(discovered), ide)
+	cfg := Config{
 		UserID:       userID,
 		WebSocket:    wsURL,
 		IDECommand:   ide,
 		ConnectionID: generateUUID(),
 	}
+	// Editors such as Flatpak apps are launched with a template rather than `command {file}`
+	if editor.Template != "" {
+		cfg.Launch.Template = editor.Template
+		fmt.Printf("[IDE Detection] Launch template: %s\n", editor.Template)
+	}
+	applyConfigDefaults(&cfg, appCfg)
+	return cfg
+}
+
+// applyConfigDefaults fills settings missing from the user config with app config defaults
+func applyConfigDefaults(cfg *Config, appCfg AppConfig) {
+	if cfg.Launch.Template == "" && appCfg.Launch.Template != "" {
+		cfg.Launch = appCfg.Launch
+	}
+	if cfg.IDEMappings == nil {
+		cfg.IDEMappings = append([]bridge.IDEMapping{}, appCfg.IDEMappings...)
+	}
+	if cfg.MergeTool == "" {
+		cfg.MergeTool = appCfg.MergeTool
+	}
+	if cfg.WatchMode == "" {
+		cfg.WatchMode = bridge.NormalizeWatchMode(appCfg.WatchMode)
+	}
+	if cfg.Workspace == "" {
+		cfg.Workspace = bridge.NormalizeWorkspaceMode(appCfg.Workspace)
+	}
+	if cfg.WorkspaceTemplates == nil {
+		cfg.WorkspaceTemplates = appCfg.WorkspaceTemplates
+	}
+	if cfg.Formatters == nil {
+		cfg.Formatters = append([]bridge.Formatter{}, appCfg.Formatters...)
+		cfg.FormatWriteBack = cfg.FormatWriteBack || appCfg.FormatWriteBack
+	}
+	if cfg.Validators == nil {
+		cfg.Validators = append([]bridge.Validator{}, appCfg.Validators...)
+		if appCfg.Validators == nil {
+			cfg.Validators = append(cfg.Validators, bridge.DefaultValidators...)
+		}
+	}
+	if cfg.ValidationPolicy == "" {
+		cfg.ValidationPolicy = bridge.NormalizeValidationPolicy(appCfg.ValidationPolicy)
+	}
+	if cfg.Transforms == nil {
+		cfg.Transforms = append([]Transform{}, appCfg.Transforms...)
+	}
+	if cfg.TextEncoding == "" {
+		cfg.TextEncoding = bridge.NormalizeTextEncoding(appCfg.TextEncoding)
+	}
+	if cfg.LineEndings == "" {
+		cfg.LineEndings = bridge.NormalizeLineEndings(appCfg.LineEndings)
+	}
+	if cfg.MaxMessageKB <= 0 {
+		cfg.MaxMessageKB = normalizeMaxMessageKB(appCfg.MaxMessageKB)
+	}
+	if cfg.DetectLanguage == "" {
+		cfg.DetectLanguage = bridge.NormalizeDetectLanguage(appCfg.DetectLanguage)
+	}
+	if cfg.History == "" {
+		cfg.History = bridge.NormalizeHistoryMode(appCfg.History)
+	}
+	if cfg.HistoryMaxVersions <= 0 {
+		cfg.HistoryMaxVersions = appCfg.HistoryMaxVersions
+	}
+	if cfg.HistoryMaxDays <= 0 {
+		cfg.HistoryMaxDays = appCfg.HistoryMaxDays
+	}
+	if cfg.PollIntervalMs <= 0 {
+		cfg.PollIntervalMs = appCfg.PollIntervalMs
+		if cfg.PollIntervalMs <= 0 {
+			cfg.PollIntervalMs = bridge.DefaultPollIntervalMs
+		}
+	}
 }
 
 // Returns config file path, ensures config dir exists
@@ -171,10 +247,10 @@ func configPath() string {
 }
 
 // Loads config from disk, or creates default if missing
-func loadConfig() (Config, error) {
+func loadConfig(discovery *editorDiscovery) (Config, error) {
 	path := configPath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		cfg := defaultConfig()
+		cfg := defaultConfig(discovery)
 		_ = saveConfig(cfg)
 		return cfg, nil
 	}
@@ -187,6 +263,8 @@ func loadConfig() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	appCfg, _ := loadAppConfig()
+	applyConfigDefaults(&cfg, appCfg)
 	return cfg, nil
 }
 
@@ -214,16 +292,51 @@ func generateUUID() string {
 }
 
 // AppConfig struct for app/org defaults
-// { "defaults": { "ides": { ... }, "ws_url": "..." }, "temp_file_cleanup_hours": ... }
+// { "defaults": { "ides": { ... }, "ws_url": "...", "watch_mode": "auto", "poll_interval_ms": 1000 }, "temp_file_cleanup_hours": ... }
 type AppConfig struct {
-	DefaultIDEs          map[string][]string `json:"ides"`
-	WSURL                string              `json:"ws_url"`
-	TempFileCleanupHours int                 `json:"temp_file_cleanup_hours"`
+	DefaultIDEs          map[string][]string          `json:"ides"`
+	WSURL                string                       `json:"ws_url"`
+	Launch               bridge.LaunchProfile         `json:"launch"`
+	IDEMappings          []bridge.IDEMapping          `json:"ide_mappings"`
+	WatchMode            string                       `json:"watch_mode"`
+	PollIntervalMs       int                          `json:"poll_interval_ms"`
+	MergeTool            string                       `json:"merge_tool"`
+	Workspace            string                       `json:"workspace"`
+	WorkspaceTemplates   map[string]map[string]string `json:"workspace_templates"`
+	Formatters           []bridge.Formatter           `json:"formatters"`
+	FormatWriteBack      bool                         `json:"format_write_back"`
+	Validators           []bridge.Validator           `json:"validators"`
+	ValidationPolicy     string                       `json:"validation_policy"`
+	Transforms           []Transform                  `json:"transforms"`
+	TextEncoding         string                       `json:"text_encoding"`
+	LineEndings          string                       `json:"line_endings"`
+	MaxMessageKB         int                          `json:"max_message_kb"`
+	DetectLanguage       string                       `json:"detect_language"`
+	History              string                       `json:"history"`
+	HistoryMaxVersions   int                          `json:"history_max_versions"`
+	HistoryMaxDays       int                          `json:"history_max_days"`
+	TempFileCleanupHours int                          `json:"temp_file_cleanup_hours"`
+	TempFileMaxTotalMB   int                          `json:"temp_file_max_total_mb"`
+	TempFileMaxCount     int                          `json:"temp_file_max_count"`
+	WipeTempFilesOnQuit  bool                         `json:"wipe_temp_files_on_quit"`
 }
 
 type FullAppConfig struct {
 	Defaults             AppConfig `json:"defaults"`
 	TempFileCleanupHours int       `json:"temp_file_cleanup_hours"`
+	TempFileMaxTotalMB   int       `json:"temp_file_max_total_mb"`
+	TempFileMaxCount     int       `json:"temp_file_max_count"`
+	WipeTempFilesOnQuit  bool      `json:"wipe_temp_files_on_quit"`
+}
+
+// appConfig returns the defaults with the top-level temp file settings applied
+func (f FullAppConfig) appConfig() AppConfig {
+	config := f.Defaults
+	config.TempFileCleanupHours = f.TempFileCleanupHours
+	config.TempFileMaxTotalMB = f.TempFileMaxTotalMB
+	config.TempFileMaxCount = f.TempFileMaxCount
+	config.WipeTempFilesOnQuit = f.WipeTempFilesOnQuit
+	return config
 }
 
 // Load app config from desktop/web-ide-bridge.conf, /etc/web-ide-bridge.conf, or $WEB_IDE_BRIDGE_CONFIG
@@ -245,9 +358,7 @@ func loadAppConfig() (AppConfig, error) {
 			if err := json.Unmarshal(data, &fullConfig); err != nil {
 				continue
 			}
-			config = fullConfig.Defaults
-			config.TempFileCleanupHours = fullConfig.TempFileCleanupHours
-			return config, nil
+			return fullConfig.appConfig(), nil
 		}
 	}
 	// Fallback: use embedded config if present
@@ -255,9 +366,7 @@ func loadAppConfig() (AppConfig, error) {
 		fmt.Printf("[DEBUG] Using embedded config, size: %d bytes\n", len(embeddedConfig))
 		var fullConfig FullAppConfig
 		if err := json.Unmarshal(embeddedConfig, &fullConfig); err == nil {
-			config = fullConfig.Defaults
-			config.TempFileCleanupHours = fullConfig.TempFileCleanupHours
-			return config, nil
+			return fullConfig.appConfig(), nil
 		} else {
 			fmt.Printf("[DEBUG] Failed to parse embedded config: %v\n", err)
 		}
@@ -274,17 +383,40 @@ func loadAppConfig() (AppConfig, error) {
 type WebSocketClient struct {
 	cfg         Config
 	conn        *websocket.Conn
+	writeMu     sync.Mutex // serializes writes to conn
 	status      string
 	statusMu    sync.Mutex
 	logFunc     func(string)
 	stopCh      chan struct{}
 	reconnectCh chan struct{}
-	statusCh    chan string              // notify UI of status changes
-	watchers    map[string]chan struct{} // snippetId -> stop channel
+	statusCh    chan string                      // notify UI of status changes
+	watchers    map[bridge.SessionKey]*fileWatch // session -> active watcher
 	watchersMu  sync.Mutex
-	// sessionMap maps snippetId to sessionId
-	sessionMap       map[string]string
+	watchersCh  chan struct{}                             // notify UI of watcher changes
+	editors     map[bridge.SessionKey]*editorProcess      // session -> IDE process, guarded by watchersMu
+	transforms  map[bridge.SessionKey]sessionTransform    // session -> transform of its code, guarded by watchersMu
+	guards      map[bridge.SessionKey]bridge.SnippetGuard // session -> read-only context around its code, guarded by watchersMu
+	styles      map[bridge.SessionKey]bridge.TextStyle    // session -> encoding and line endings of its temp file, guarded by watchersMu
+	deltas      map[bridge.SessionKey]*deltaState         // session -> code the server has, for patches, guarded by watchersMu
+	binaries    map[bridge.SessionKey]bridge.BinaryFormat // session -> encoding of its binary content, guarded by watchersMu
+	detections  map[bridge.SessionKey]languageDetection   // session -> language detected from its code, guarded by watchersMu
+	tempFiles   *bridge.TempManifest                      // temp files owned by the app
+	history     *bridge.HistoryStore                      // versions of received and sent code
+	discovery   *editorDiscovery                          // editors found on this machine
+	// pendingEvents holds edit events raised while disconnected, guarded by statusMu
+	pendingEvents bridge.EventQueue
+	// sessionMap maps snippetId to the sessions of the snippet on different pages, guarded by watchersMu
+	sessionMap       map[string][]bridge.SessionKey
 	browserConnected bool
+	// deltaSupported is set if the server accepts code_update patches, guarded by statusMu
+	deltaSupported bool
+	// chunkSupported is set if the server accepts chunked messages, of at most
+	// serverMaxMessage bytes each, guarded by statusMu
+	chunkSupported   bool
+	serverMaxMessage int
+	// transfers holds the progress of large transfers, guarded by statusMu
+	transfers   map[string]transferProgress
+	transfersCh chan struct{} // notify UI of transfer progress
 }
 
 func NewWebSocketClient(cfg Config, logFunc func(string)) *WebSocketClient {
@@ -295,8 +427,20 @@ func NewWebSocketClient(cfg Config, logFunc func(string)) *WebSocketClient {
 		stopCh:           make(chan struct{}),
 		reconnectCh:      make(chan struct{}, 1),
 		statusCh:         make(chan string, 1),
-		watchers:         make(map[string]chan struct{}),
-		sessionMap:       make(map[string]string),
+		watchers:         make(map[bridge.SessionKey]*fileWatch),
+		watchersCh:       make(chan struct{}, 1),
+		editors:          make(map[bridge.SessionKey]*editorProcess),
+		transforms:       make(map[bridge.SessionKey]sessionTransform),
+		guards:           make(map[bridge.SessionKey]bridge.SnippetGuard),
+		styles:           make(map[bridge.SessionKey]bridge.TextStyle),
+		deltas:           make(map[bridge.SessionKey]*deltaState),
+		binaries:         make(map[bridge.SessionKey]bridge.BinaryFormat),
+		detections:       make(map[bridge.SessionKey]languageDetection),
+		transfers:        make(map[string]transferProgress),
+		transfersCh:      make(chan struct{}, 1),
+		tempFiles:        bridge.LoadTempManifest(tempManifestPath(), baseVersionsDir()),
+		history:          bridge.NewHistoryStore(historyPath()),
+		sessionMap:       make(map[string][]bridge.SessionKey),
 		browserConnected: false,
 	}
 }
@@ -313,8 +457,8 @@ func (c *WebSocketClient) Restart() {
 	// Get list of active watchers before closing
 	activeWatchers := c.getActiveWatchers()
 
-	c.Close() // Close the current connection and stop all watchers
-	c.Start() // Start a new connection
+	c.closeWithReason("desktop app restarting") // Close the current connection and stop all watchers
+	c.Start()                                   // Start a new connection
 
 	// Restore watchers after connection is established
 	if len(activeWatchers) > 0 {
@@ -332,7 +476,7 @@ func (c *WebSocketClient) RestartWithConfig(newConfig Config) {
 	// Get list of active watchers before closing
 	activeWatchers := c.getActiveWatchers()
 
-	c.Close() // Close the current connection and stop all watchers
+	c.closeWithReason("desktop app restarting") // Close the current connection and stop all watchers
 
 	// Update configuration with proper synchronization
 	c.statusMu.Lock()
@@ -360,24 +504,34 @@ func (c *WebSocketClient) connectLoop() {
 		c.statusMu.Unlock()
 
 		c.log("Connecting to " + currentCfg.WebSocket)
-		conn, _, err := websocket.DefaultDialer.Dial(currentCfg.WebSocket, nil)
+		// Negotiate permessage-deflate compression, used if the server supports it
+		dialer := *websocket.DefaultDialer
+		dialer.EnableCompression = true
+		conn, _, err := dialer.Dial(currentCfg.WebSocket, nil)
 		if err != nil {
 			c.setStatus("disconnected")
 			c.log("Failed to connect to server: " + err.Error())
 			time.Sleep(10 * time.Second)
 			continue
 		}
+		maxMessage := normalizeMaxMessageKB(currentCfg.MaxMessageKB) * 1024
+		conn.SetReadLimit(int64(maxMessage))
+		conn.EnableWriteCompression(true)
 		c.conn = conn
+		// Patches and chunks are sent once the server tells that it accepts them
+		c.setDeltaSupported(false)
+		c.setChunkSupported(false, 0)
 
 		// Send desktop_connect message to server
 		desktopConnectMsg := map[string]interface{}{
-			"type":         "desktop_connect",
-			"connectionId": currentCfg.ConnectionID,
-			"userId":       currentCfg.UserID,
-			"timestamp":    time.Now().UnixMilli(),
+			"type":           "desktop_connect",
+			"connectionId":   currentCfg.ConnectionID,
+			"userId":         currentCfg.UserID,
+			"maxMessageSize": maxMessage, // larger messages from the server are sent in chunks
+			"timestamp":      time.Now().UnixMilli(),
 		}
 		if data, err := json.Marshal(desktopConnectMsg); err == nil {
-			c.conn.WriteMessage(websocket.TextMessage, data)
+			c.writeMessage(websocket.TextMessage, data)
 			c.log("Registered with server as user: " + currentCfg.UserID)
 		} else {
 			c.log("Failed to register with server: " + err.Error())
@@ -385,13 +539,14 @@ func (c *WebSocketClient) connectLoop() {
 
 		c.setStatus("connected")
 		c.log("Connected to Web-IDE-Bridge server")
+		c.flushEditEvents(currentCfg.WebSocket)
 		pongCh := make(chan struct{})
 		go c.pingPongLoop(pongCh)
 		c.readLoop(pongCh)
 		c.setStatus("disconnected")
 		c.log("Disconnected from Web-IDE-Bridge server")
 		conn.Close()
-		c.stopAllWatchers()
+		c.stopAllWatchers("desktop app disconnected from server")
 		// Wait before reconnecting
 		time.Sleep(10 * time.Second)
 	}
@@ -405,7 +560,7 @@ func (c *WebSocketClient) pingPongLoop(pongCh chan struct{}) {
 		select {
 		case <-ticker.C:
 			if c.conn != nil {
-				c.conn.WriteMessage(websocket.PingMessage, []byte("ping"))
+				c.writeMessage(websocket.PingMessage, []byte("ping"))
 			}
 		case <-pongCh:
 			return
@@ -417,9 +572,14 @@ func (c *WebSocketClient) pingPongLoop(pongCh chan struct{}) {
 
 // Read messages from server
 func (c *WebSocketClient) readLoop(pongCh chan struct{}) {
+	// Chunked messages being received on this connection
+	incoming := make(map[string]*incomingTransfer)
 	for {
 		_, msg, err := c.conn.ReadMessage()
 		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				c.log("Received a message larger than max_message_kb from server, reconnecting")
+			}
 			close(pongCh)
 			return
 		}
@@ -435,15 +595,79 @@ func (c *WebSocketClient) readLoop(pongCh chan struct{}) {
 			continue
 		}
 		typeVal, _ := m["type"].(string)
+		if typeVal == "chunk" {
+			data, err := c.receiveChunk(incoming, msg)
+			if err != nil {
+				c.log("Dropped chunked message from server: " + err.Error())
+				continue
+			}
+			if data == nil {
+				continue
+			}
+			m = nil
+			if err := json.Unmarshal(data, &m); err != nil {
+				c.log("Received invalid message from server: " + err.Error())
+				continue
+			}
+			typeVal, _ = m["type"].(string)
+		}
 		if typeVal == "edit_request" {
-			snippetId, _ := m["snippetId"].(string)
+			key := c.messageSessionKey(m)
 			code, _ := m["code"].(string)
 			fileType, _ := m["fileType"].(string)
-			if snippetId != "" {
-				c.sessionMap[snippetId] = snippetId
+			companions := bridge.ParseCompanionFiles(m)
+			pos := parseEditPosition(m)
+			transform, _ := m["transform"].(string)
+			around := bridge.ParseSnippetContext(m)
+			binary, err := bridge.ParseBinaryFormat(m, code)
+			if err != nil {
+				c.log(fmt.Sprintf("Received invalid binary snippet %s: %s", key, err.Error()))
+				c.sendEditEvent(key, "launch_failed", err.Error())
+				continue
+			}
+			if key.SnippetID != "" {
+				c.addSession(key)
+				c.setDeltaBase(key, code)
 			}
-			c.log(fmt.Sprintf("Received edit request for code snippet: %s, fileType: %s, codeLength: %d", snippetId, fileType, len(code)))
-			go c.handleEditRequest(snippetId, code, fileType)
+			c.log(fmt.Sprintf("Received edit request for code snippet: %s, fileType: %s, codeLength: %d", key, fileType, len(code)))
+			go c.handleEditRequest(key, code, fileType, companions, pos, transform, around, binary)
+		} else if typeVal == "edit_batch_request" {
+			snippets := c.parseBatchSnippets(m)
+			for _, s := range snippets {
+				c.addSession(s.Key)
+				c.setDeltaBase(s.Key, s.Code)
+			}
+			c.log(fmt.Sprintf("Received batch edit request for %d code snippets", len(snippets)))
+			go c.handleBatchEditRequest(snippets, bridge.ParseCompanionFiles(m))
+		} else if typeVal == "browser_update" {
+			key := c.messageSessionKey(m)
+			code, _ := m["code"].(string)
+			c.setDeltaBase(key, code)
+			// Handled in order, so that the last update wins
+			c.handleBrowserUpdate(key, code)
+		} else if typeVal == "connection_ack" {
+			features, _ := m["features"].([]interface{})
+			supported := false
+			for _, f := range features {
+				if f == "delta" {
+					supported = true
+				}
+			}
+			c.setDeltaSupported(supported)
+			chunked := false
+			for _, f := range features {
+				if f == "chunk" {
+					chunked = true
+				}
+			}
+			maxMessage, _ := m["maxMessageSize"].(float64)
+			c.setChunkSupported(chunked, int(maxMessage))
+		} else if typeVal == "code_ack" {
+			hash, _ := m["hash"].(string)
+			c.handleCodeAck(c.messageSessionKey(m), hash)
+		} else if typeVal == "code_resync" {
+			hash, _ := m["hash"].(string)
+			c.handleCodeResync(c.messageSessionKey(m), hash)
 		} else if typeVal == "status_update" {
 			if val, ok := m["browserConnected"].(bool); ok {
 				c.browserConnected = val
@@ -458,238 +682,361 @@ func (c *WebSocketClient) readLoop(pongCh chan struct{}) {
 	}
 }
 
-// Handle edit_request: save code, launch IDE, start watcher
-func (c *WebSocketClient) handleEditRequest(snippetId, code, fileType string) {
-	tmpDir := os.TempDir()
-	// Add a helper to sanitize snippetId for file names
-	sanitizeSnippetId := func(snippetId string) string {
-		var b strings.Builder
-		for _, r := range snippetId {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
-				b.WriteRune(r)
-			} else {
-				b.WriteRune('_')
-			}
+// messageSessionKey returns the session of a message from the server
+func (c *WebSocketClient) messageSessionKey(m map[string]interface{}) bridge.SessionKey {
+	c.statusMu.Lock()
+	server := c.cfg.WebSocket
+	c.statusMu.Unlock()
+	snippetId, _ := m["snippetId"].(string)
+	pageUrl, _ := m["pageUrl"].(string)
+	return bridge.SessionKey{Server: server, Page: pageUrl, SnippetID: snippetId}
+}
+
+// addSession records a session of a snippet, to tell apart pages with the same snippetId
+func (c *WebSocketClient) addSession(key bridge.SessionKey) {
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	for _, k := range c.sessionMap[key.SnippetID] {
+		if k == key {
+			return
 		}
-		return b.String()
 	}
-	sanitizedId := sanitizeSnippetId(snippetId)
-	tmpFile := filepath.Join(tmpDir, "web-"+sanitizedId+"."+fileType)
+	c.sessionMap[key.SnippetID] = append(c.sessionMap[key.SnippetID], key)
+	if len(c.sessionMap[key.SnippetID]) > 1 {
+		c.log(fmt.Sprintf("Snippet %s is open on %d pages, keeping them in separate temp files", key.SnippetID, len(c.sessionMap[key.SnippetID])))
+	}
+}
 
+// Handle edit_request: save code with its read-only context and companion files, launch IDE at the cursor position, start watcher.
+// Binary snippets are saved as raw bytes, without transforms or read-only context.
+func (c *WebSocketClient) handleEditRequest(key bridge.SessionKey, code, fileType string, companions []bridge.CompanionFile, pos editPosition, transform string, around bridge.SnippetContext, binary *bridge.BinaryFormat) {
 	// Get current configuration with proper synchronization
 	c.statusMu.Lock()
 	currentCfg := c.cfg
 	c.statusMu.Unlock()
+	var applied *sessionTransform
+	var guard *bridge.SnippetGuard
+	if binary != nil {
+		c.recordHistory(key, bridge.HistoryReceived, fileType, binary.Encoding, code)
+	} else {
+		c.recordHistory(key, bridge.HistoryReceived, fileType, "", bridge.NormalizeText(code))
+	}
+	if binary != nil {
+		// From here on, code is the raw content of the temp file
+		data, err := binary.Decode(code)
+		if err != nil {
+			c.log(fmt.Sprintf("Failed to decode binary snippet %s: %s", key, err.Error()))
+			c.sendEditEvent(key, "launch_failed", err.Error())
+			return
+		}
+		code, fileType, pos = string(data), binary.Extension(fileType), editPosition{}
+		c.setDetection(key, nil)
+	} else {
+		code = bridge.NormalizeText(code)
+		fileType = c.detectFileType(currentCfg.DetectLanguage, key, code, fileType)
+		// From here on, code and fileType are those of the temp file
+		code, fileType, applied = c.decodeForEdit(currentCfg.Transforms, transform, key, code, fileType)
+		if applied != nil {
+			// Positions refer to the lines of the browser code, not to the decoded code
+			pos = editPosition{}
+		}
+		// From here on, code is the content of the temp file, with the read-only context
+		guard = bridge.NewSnippetGuard(around, fileType)
+	}
+	if guard != nil {
+		code = guard.Wrap(code)
+		if pos.Line > 0 {
+			pos.Line += guard.Lines()
+		}
+		if pos.EndLine > 0 {
+			pos.EndLine += guard.Lines()
+		}
+	}
+	mode := currentCfg.Workspace
+	if len(companions) > 0 && bridge.NormalizeWorkspaceMode(mode) == bridge.WorkspaceOff {
+		// Companion files need a folder next to the snippet
+		mode = bridge.WorkspaceSession
+	}
+	tmpFile := bridge.SessionFilePath(mode, key, fileType)
+	workspace := bridge.WorkspaceOf(tmpFile)
 
 	// Debug log (not shown in activity log)
-	log.Printf("[handleEditRequest] userId=%s, snippetId=%s, fileType=%s, codeLength=%d", currentCfg.UserID, snippetId, fileType, len(code))
-
-	c.log(fmt.Sprintf("Saving code snippet %s to temp file, and launching IDE %s", snippetId, currentCfg.IDECommand))
-	if err := os.WriteFile(tmpFile, []byte(code), 0644); err != nil {
+	log.Printf("[handleEditRequest] userId=%s, snippetId=%s, pageUrl=%s, fileType=%s, codeLength=%d, line=%d, column=%d", currentCfg.UserID, key.SnippetID, key.Page, fileType, len(code), pos.Line, pos.Column)
+
+	// Stop the previous watcher first, so that writing the temp file is not sent back
+	c.stopFileWatcher(key)
+	c.setTransform(key, applied)
+	c.setGuard(key, guard)
+	c.setBinary(key, binary)
+
+	ideCmd, profile, source := selectLaunch(currentCfg, fileType, filepath.Base(tmpFile))
+	c.log(fmt.Sprintf("Saving code snippet %s to temp file, and launching IDE %s (%s)", key, ideCmd, source))
+	if workspace != "" {
+		created, err := scaffoldWorkspace(workspace, key, fileType, filepath.Base(tmpFile), currentCfg.WorkspaceTemplates)
+		if err != nil {
+			c.log("Failed to create workspace folder: " + err.Error())
+			return
+		}
+		if created > 0 {
+			c.log(fmt.Sprintf("Created workspace folder %s with %d scaffolding files", workspace, created))
+		}
+		if err := c.tempFiles.Record(workspace, key.String()); err != nil {
+			c.log("Failed to update temp file manifest: " + err.Error())
+		}
+		written, err := bridge.WriteCompanionFiles(workspace, companions, tmpFile)
+		if err != nil {
+			c.log("Failed to write companion files: " + err.Error())
+		}
+		if written > 0 {
+			c.log(fmt.Sprintf("Wrote %d of %d companion files for snippet %s", written, len(companions), key))
+		}
+	}
+	var merge editMerge
+	var err error
+	if binary != nil {
+		err = c.writeBinaryFile(key, tmpFile, []byte(code))
+	} else {
+		merge, err = c.writeEditFile(key, tmpFile, fileType, code)
+	}
+	if err != nil {
 		c.log("Failed to save code snippet to temp file: " + err.Error())
+		c.sendEditEvent(key, "launch_failed", err.Error())
 		return
 	}
+	if err := c.tempFiles.Record(tmpFile, key.String()); err != nil {
+		c.log("Failed to update temp file manifest: " + err.Error())
+	}
 
-	var cmd *exec.Cmd
-	ideCmd := currentCfg.IDECommand
-	if runtime.GOOS == "darwin" {
-		if strings.HasSuffix(ideCmd, ".app") {
-			// Extract app name from path, e.g., /Applications/TextEdit.app -> TextEdit
-			appName := strings.TrimSuffix(filepath.Base(ideCmd), ".app")
-			cmd = exec.Command("open", "-a", appName, tmpFile)
-		} else if !strings.Contains(ideCmd, "/") {
-			// App name only (e.g., TextEdit, Cursor)
-			cmd = exec.Command("open", "-a", ideCmd, tmpFile)
-		} else {
-			// Path to binary
-			cmd = exec.Command(ideCmd, tmpFile)
+	launch, err := bridge.ResolveLaunch(profile, bridge.LaunchContext{
+		File:      tmpFile,
+		Workspace: workspace,
+		Line:      pos.Line,
+		Column:    pos.Column,
+		EndLine:   pos.EndLine,
+		EndColumn: pos.EndColumn,
+		SnippetID: key.SnippetID,
+		FileType:  fileType,
+		IDE:       ideCmd,
+	})
+	if err != nil {
+		c.log("Failed to resolve IDE launch template: " + err.Error())
+		c.sendEditEvent(key, "launch_failed", err.Error())
+		return
+	}
+	c.startFileWatcher(key, tmpFile, fileType)
+	if merge.local != "" {
+		c.setLocalEdits(key)
+	}
+	if merge.conflicts > 0 {
+		if w, ok := c.watcher(key); ok {
+			c.setSyncState(w, bridge.SyncStateConflict, false)
 		}
-	} else if runtime.GOOS == "windows" {
-		cmd = exec.Command(ideCmd, tmpFile)
-	} else {
-		cmd = exec.Command(ideCmd, tmpFile)
 	}
-
-	if err := cmd.Start(); err != nil {
+	if merge.conflicts > 0 && currentCfg.MergeTool != "" {
+		err := c.launchMergeTool(key, tmpFile, fileType, currentCfg.MergeTool, merge, code)
+		if err == nil {
+			c.setEditor(key, "merge tool")
+			c.sendEditEvent(key, "edit_started", "opened in merge tool")
+			return
+		}
+		c.log("Failed to launch merge tool, opening conflict markers in IDE: " + err.Error())
+	}
+	c.log("Launching IDE: " + launch.String())
+	if err := c.launchIDE(key, launch); err != nil {
 		c.log("Failed to launch IDE: " + err.Error())
+		c.sendEditEvent(key, "launch_failed", err.Error())
+		// The temp file may hold the only copy of merged local edits
+		c.endEditSession(key, false, c.hasLocalEdits(key))
 		return
 	}
-	c.startFileWatcher(snippetId, tmpFile, fileType)
+	c.setEditor(key, filepath.Base(ideCmd))
+	c.sendEditEvent(key, "edit_started", "opened in "+ideCmd)
 }
 
-// Start or restart a file watcher for a snippetId
-func (c *WebSocketClient) startFileWatcher(snippetId, tmpFile, fileType string) {
+// Stop the file watcher of a session, if any, and wait for it to exit
+func (c *WebSocketClient) stopFileWatcher(key bridge.SessionKey) {
 	c.watchersMu.Lock()
-	if stopCh, ok := c.watchers[snippetId]; ok {
-		c.log("Stopping previous file watcher for snippet: " + snippetId)
-		close(stopCh)
-		delete(c.watchers, snippetId)
-	}
-	stopCh := make(chan struct{})
-	c.watchers[snippetId] = stopCh
+	w, ok := c.watchers[key]
+	delete(c.watchers, key)
 	c.watchersMu.Unlock()
-	go c.watchFileAndSendUpdates(tmpFile, snippetId, fileType, stopCh)
-}
-
-// Watch file for changes and send updates if connected
-func (c *WebSocketClient) watchFileAndSendUpdates(tmpFile, snippetId, fileType string, stopCh chan struct{}) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		c.log("Failed to create file watcher: " + err.Error())
-		return
-	}
-	defer watcher.Close()
-	if err := watcher.Add(tmpFile); err != nil {
-		c.log("Failed to watch temp file for changes: " + err.Error())
+	if !ok {
 		return
 	}
-	c.log(fmt.Sprintf("Now watching for %s file changes in IDE...", snippetId))
-
-	// Initialize lastContent with current file content to avoid detecting initial file creation
-	initialContent, err := os.ReadFile(tmpFile)
-	if err != nil {
-		c.log("Failed to read initial file content: " + err.Error())
-		return
+	c.log("Stopping file watcher for snippet: " + key.String())
+	w.stop(false)
+	select {
+	case <-w.doneCh:
+	case <-time.After(5 * time.Second):
 	}
-	lastContent := string(initialContent)
+	c.notifyWatchersChanged()
+}
 
-	for {
-		select {
-		case event, ok := <-watcher.Events:
-			if !ok {
-				return
-			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				content, err := os.ReadFile(tmpFile)
-				if err != nil {
-					c.log("Failed to read file: " + err.Error())
-					continue
-				}
-				if string(content) != lastContent {
-					lastContent = string(content)
-					if c.getStatus() == "connected" {
-						c.log(fmt.Sprintf("Detected temp file change, sending code to server, snippet: %s, fileType: %s, codeLength: %d", snippetId, fileType, len(content)))
-						c.sendCodeUpdate(snippetId, string(content), fileType)
-					} else {
-						c.log("File changed, but not connected. Please save again after reconnect.")
-					}
-				}
-			}
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				return
-			}
-			c.log("File watcher error: " + err.Error())
-		case <-stopCh:
-			c.log("Stopped watching for file changes in IDE")
-			return
-		}
-	}
+// Start or restart a file watcher for a session
+func (c *WebSocketClient) startFileWatcher(key bridge.SessionKey, tmpFile, fileType string) {
+	c.stopFileWatcher(key)
+	c.watchersMu.Lock()
+	w := newFileWatch(tmpFile, fileType)
+	c.watchers[key] = w
+	c.watchersMu.Unlock()
+	c.notifyWatchersChanged()
+	go c.watchFileAndSendUpdates(w, key)
 }
 
 // Get list of active watchers (for restoration after reconnect)
-func (c *WebSocketClient) getActiveWatchers() map[string]string {
+func (c *WebSocketClient) getActiveWatchers() map[bridge.SessionKey]bridge.SessionInfo {
 	c.watchersMu.Lock()
 	defer c.watchersMu.Unlock()
 
-	activeWatchers := make(map[string]string)
-	for snippetId := range c.watchers {
-		// Reconstruct the temp file path
-		sanitizeSnippetId := func(snippetId string) string {
-			var b strings.Builder
-			for _, r := range snippetId {
-				if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
-					b.WriteRune(r)
-				} else {
-					b.WriteRune('_')
-				}
-			}
-			return b.String()
-		}
-		sanitizedId := sanitizeSnippetId(snippetId)
-		tmpFile := filepath.Join(os.TempDir(), "web-"+sanitizedId+".js") // Default to .js, will be corrected below
-
-		// Try to determine the actual file type by checking what files exist
-		for _, ext := range []string{"js", "py", "java", "cpp", "c", "go", "rs", "php", "rb", "ts", "html", "css", "json", "xml", "md", "txt"} {
-			testFile := filepath.Join(os.TempDir(), "web-"+sanitizedId+"."+ext)
-			if _, err := os.Stat(testFile); err == nil {
-				tmpFile = testFile
-				break
-			}
-		}
-
-		// Extract file type from the found file
-		ext := filepath.Ext(tmpFile)
-		if ext != "" {
-			fileType := strings.TrimPrefix(ext, ".")
-			activeWatchers[snippetId] = fileType
-		}
+	activeWatchers := make(map[bridge.SessionKey]bridge.SessionInfo)
+	for key, w := range c.watchers {
+		activeWatchers[key] = bridge.SessionInfo{Key: key, TmpFile: w.tmpFile, FileType: w.fileType, Editor: w.editor, SyncState: w.syncState, LastSave: w.lastSave, Batch: w.batch}
 	}
 	return activeWatchers
 }
 
-// Stop all file watchers (on disconnect/shutdown)
-func (c *WebSocketClient) stopAllWatchers() {
+// Stop all file watchers (on disconnect/shutdown), and tell the browser why
+func (c *WebSocketClient) stopAllWatchers(reason string) {
 	c.watchersMu.Lock()
-	for id, stopCh := range c.watchers {
-		c.log("Stopping file watcher for snippet: " + id)
-		close(stopCh)
+	stopped := make([]bridge.SessionKey, 0, len(c.watchers))
+	for key, w := range c.watchers {
+		c.log("Stopping file watcher for snippet: " + key.String())
+		w.stop(false)
+		stopped = append(stopped, key)
 	}
-	c.watchers = make(map[string]chan struct{})
+	c.watchers = make(map[bridge.SessionKey]*fileWatch)
 	c.watchersMu.Unlock()
+	c.notifyWatchersChanged()
+	for _, key := range stopped {
+		c.sendEditEvent(key, "watch_stopped", reason)
+	}
 }
 
 // Restore watchers from a saved list
-func (c *WebSocketClient) restoreWatchers(watchers map[string]string) {
-	for snippetId, fileType := range watchers {
-		// Reconstruct the temp file path
-		sanitizeSnippetId := func(snippetId string) string {
-			var b strings.Builder
-			for _, r := range snippetId {
-				if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
-					b.WriteRune(r)
-				} else {
-					b.WriteRune('_')
-				}
-			}
-			return b.String()
-		}
-		sanitizedId := sanitizeSnippetId(snippetId)
-		tmpFile := filepath.Join(os.TempDir(), "web-"+sanitizedId+"."+fileType)
-
+func (c *WebSocketClient) restoreWatchers(watchers map[bridge.SessionKey]bridge.SessionInfo) {
+	for key, info := range watchers {
 		// Check if the file still exists before restoring the watcher
-		if _, err := os.Stat(tmpFile); err == nil {
-			c.log(fmt.Sprintf("Restoring watcher for existing file: %s (%s)", snippetId, fileType))
-			c.startFileWatcher(snippetId, tmpFile, fileType)
+		if _, err := os.Stat(info.TmpFile); err == nil {
+			c.log(fmt.Sprintf("Restoring watcher for existing file: %s (%s)", key, info.FileType))
+			c.startFileWatcher(key, info.TmpFile, info.FileType)
+			c.watchersMu.Lock()
+			if w, ok := c.watchers[key]; ok {
+				w.editor, w.syncState, w.lastSave, w.batch = info.Editor, info.SyncState, info.LastSave, info.Batch
+			}
+			c.watchersMu.Unlock()
+			c.sendEditEvent(key, "edit_started", "watcher restored")
 		} else {
-			c.log(fmt.Sprintf("Skipping watcher restoration for %s - file no longer exists", snippetId))
+			c.log(fmt.Sprintf("Skipping watcher restoration for %s - file no longer exists", key))
 		}
 	}
 }
 
-// Send code update to server
-func (c *WebSocketClient) sendCodeUpdate(snippetId, code, fileType string) {
+// Send code update to server with the diagnostics of the validators, returns true if it was sent.
+// Code of a session with a transform is encoded back to the form of the browser.
+func (c *WebSocketClient) sendCodeUpdate(key bridge.SessionKey, code, fileType string, diagnostics ...bridge.Diagnostic) bool {
 	// Get current configuration with proper synchronization
 	c.statusMu.Lock()
 	currentCfg := c.cfg
 	c.statusMu.Unlock()
 
+	if key.Server != currentCfg.WebSocket {
+		c.log(fmt.Sprintf("Not sending code snippet %s, it was opened from another server", key))
+		return false
+	}
+	tmpFile := bridge.TempFilePath(key, fileType)
+	if w, ok := c.watcher(key); ok {
+		tmpFile = w.tmpFile
+	}
+	code, fileType, err := c.encodeForBrowser(key, tmpFile, code, fileType)
+	if err != nil {
+		c.log(fmt.Sprintf("Not sending code snippet %s, it cannot be converted back: %s", key, err.Error()))
+		c.sendEditEvent(key, "transform_failed", err.Error())
+		return false
+	}
+	binary, isBinary := c.binaryOf(key)
+	if isBinary {
+		// Binary content is sent in the encoding the browser sent it
+		code = binary.Encode([]byte(code))
+	}
+
 	// Debug log (not shown in activity log)
-	log.Printf("[sendCodeUpdate] userId=%s, snippetId=%s, fileType=%s, codeLength=%d", currentCfg.UserID, snippetId, fileType, len(code))
-	msg := map[string]interface{}{
+	log.Printf("[sendCodeUpdate] userId=%s, snippetId=%s, pageUrl=%s, fileType=%s, codeLength=%d", currentCfg.UserID, key.SnippetID, key.Page, fileType, len(code))
+	msg := key.SessionFields(map[string]interface{}{
 		"type":         "code_update",
 		"connectionId": currentCfg.ConnectionID,
 		"userId":       currentCfg.UserID,
-		"snippetId":    snippetId,
 		"code":         code,
 		"fileType":     fileType,
 		"timestamp":    time.Now().UnixMilli(),
+	})
+	if len(diagnostics) > 0 {
+		msg["diagnostics"] = diagnostics
+	}
+	if isBinary {
+		msg["encoding"] = binary.Encoding
+		msg["mimeType"] = binary.MimeType
+	}
+	data, full := c.deltaMessage(key, msg, code)
+	if c.conn == nil {
+		return false
+	}
+	if err := c.sendLarge(key, data); err != nil {
+		c.log(fmt.Sprintf("Failed to send code snippet %s: %s", key, err.Error()))
+		return false
+	}
+	if len(data) < full {
+		c.log(fmt.Sprintf("Sent code snippet %s to server as a patch (%d of %d bytes)", key, len(data), full))
+	} else {
+		c.log(fmt.Sprintf("Sent code snippet %s to server", key))
+	}
+	if isBinary {
+		c.recordHistory(key, bridge.HistorySent, fileType, binary.Encoding, code)
+	} else {
+		c.recordHistory(key, bridge.HistorySent, fileType, "", code)
+	}
+	return true
+}
+
+// Send edit session event to server, for the browser that requested the edit:
+// edit_started, launch_failed, editor_closed, watch_stopped, file_kept, merge_conflict,
+// update_rejected, send_failed, format_failed or validation_failed, with its diagnostics. Events raised
+// while disconnected are sent after reconnect.
+func (c *WebSocketClient) sendEditEvent(key bridge.SessionKey, event, reason string, diagnostics ...bridge.Diagnostic) {
+	// Get current configuration with proper synchronization
+	c.statusMu.Lock()
+	currentCfg := c.cfg
+	c.statusMu.Unlock()
+
+	msg := key.SessionFields(bridge.EditEvent(currentCfg.ConnectionID, currentCfg.UserID, event, reason, time.Now()))
+	if len(diagnostics) > 0 {
+		msg["diagnostics"] = diagnostics
 	}
 	data, _ := json.Marshal(msg)
-	if c.conn != nil {
-		c.conn.WriteMessage(websocket.TextMessage, data)
-		c.log(fmt.Sprintf("Sent code snippet %s to server", snippetId))
+	if key.Server == currentCfg.WebSocket && c.conn != nil && c.getStatus() == "connected" && c.writeMessage(websocket.TextMessage, data) == nil {
+		return
 	}
+	c.statusMu.Lock()
+	c.pendingEvents.Add(key.Server, data)
+	c.statusMu.Unlock()
+}
+
+// flushEditEvents sends edit events raised while disconnected from a server
+func (c *WebSocketClient) flushEditEvents(server string) {
+	c.statusMu.Lock()
+	pending := c.pendingEvents.Take(server)
+	c.statusMu.Unlock()
+	for _, data := range pending {
+		c.writeMessage(websocket.TextMessage, data)
+	}
+}
+
+// writeMessage serializes writes to the connection, which allows only one concurrent writer
+func (c *WebSocketClient) writeMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteMessage(messageType, data)
 }
 
 // Get current connection status (thread-safe)
@@ -735,6 +1082,11 @@ func (c *WebSocketClient) log(msg string) {
 
 // Graceful shutdown
 func (c *WebSocketClient) Close() {
+	c.closeWithReason("desktop app shut down")
+}
+
+// closeWithReason closes the connection; the reason is sent to browsers with open snippets
+func (c *WebSocketClient) closeWithReason(reason string) {
 	// Check if client is properly initialized
 	if c.stopCh == nil {
 		return // Not initialized
@@ -748,6 +1100,9 @@ func (c *WebSocketClient) Close() {
 
 	c.log("Shutting down Web-IDE-Bridge client...")
 
+	// Stop watchers while still connected, so that the browser learns why
+	c.stopAllWatchers(reason)
+
 	// Set status to shutdown to prevent multiple close attempts
 	c.setStatus("shutdown")
 
@@ -759,7 +1114,6 @@ func (c *WebSocketClient) Close() {
 		close(c.stopCh)
 	}
 
-	c.stopAllWatchers()
 	if c.conn != nil {
 		c.conn.Close()
 	}
@@ -819,7 +1173,9 @@ func setupAppIcon(a fyne.App) {
 // ----------------------
 
 func main() {
-	cfg, _ := loadConfig()
+	// Editors are discovered once, in the background; only a first start waits for them
+	discovery := startEditorDiscovery()
+	cfg, _ := loadConfig(discovery)
 
 	a := app.NewWithID("com.peterthoeny.web-ide-bridge")
 
@@ -855,28 +1211,37 @@ func main() {
 	logCard := widget.NewCard("", "", logSection)
 
 	wsClient := NewWebSocketClient(cfg, appendLog)
+	wsClient.discovery = discovery
 	wsClient.Start()
 
-	// Start temp file cleanup goroutine
+	// Report the editors found on this machine
+	go func() {
+		editors := discovery.wait()
+		names := make([]string, 0, len(editors))
+		for _, e := range editors {
+			names = append(names, fmt.Sprintf("%s (%s)", e.Name, e.Command))
+		}
+		wsClient.log(fmt.Sprintf("Detected %d editors: %s", len(editors), strings.Join(names, ", ")))
+		if cfg.Launch.Template == "" && !isEditorInstalled(cfg.IDECommand) {
+			wsClient.log("Warning: IDE command " + cfg.IDECommand + " was not found, please check the configuration")
+		}
+	}()
+
+	// Start temp file cleanup goroutine; only files recorded in the manifest are removed
 	cleanupHours := 24
-	if appCfg, err := loadAppConfig(); err == nil && appCfg.TempFileCleanupHours > 0 {
+	appCfg, _ := loadAppConfig()
+	if appCfg.TempFileCleanupHours > 0 {
 		cleanupHours = appCfg.TempFileCleanupHours
 	}
+	policy := bridge.CleanupPolicy{
+		MaxAge:        time.Duration(cleanupHours) * time.Hour,
+		MaxTotalBytes: int64(appCfg.TempFileMaxTotalMB) * 1024 * 1024,
+		MaxFiles:      appCfg.TempFileMaxCount,
+	}
 	go func() {
 		for {
-			tmpDir := os.TempDir()
-			files, err := os.ReadDir(tmpDir)
-			if err == nil {
-				now := time.Now()
-				for _, f := range files {
-					if strings.HasPrefix(f.Name(), "web-") {
-						info, err := f.Info()
-						if err == nil && info.ModTime().Add(time.Duration(cleanupHours)*time.Hour).Before(now) {
-							os.Remove(filepath.Join(tmpDir, f.Name()))
-						}
-					}
-				}
-			}
+			wsClient.cleanupTempFiles(policy)
+			wsClient.pruneHistory()
 			time.Sleep(1 * time.Hour)
 		}
 	}()
@@ -890,6 +1255,14 @@ func main() {
 	ideVal.Alignment = fyne.TextAlignLeading
 	connIDVal := widget.NewLabel(cfg.ConnectionID)
 	connIDVal.Alignment = fyne.TextAlignLeading
+	watchModeText := func(cfg Config) string {
+		return fmt.Sprintf("%s (poll interval %d ms)", cfg.WatchMode, cfg.PollIntervalMs)
+	}
+	watchVal := widget.NewLabel(watchModeText(cfg))
+	watchVal.Alignment = fyne.TextAlignLeading
+	mappingsVal := widget.NewLabel(formatIDEMappings(cfg))
+	mappingsVal.Alignment = fyne.TextAlignLeading
+	mappingsVal.Wrapping = fyne.TextWrapWord
 
 	// Desktop <=> Server status box
 	dsStatusLabel := widget.NewLabelWithStyle("Disconnected", fyne.TextAlignLeading, fyne.TextStyle{})
@@ -967,6 +1340,228 @@ func main() {
 		wsEntry.SetText(cfg.WebSocket)
 		ideEntry := widget.NewEntry()
 		ideEntry.SetText(cfg.IDECommand)
+		watchModeSelect := widget.NewSelect([]string{bridge.WatchModeAuto, bridge.WatchModeFsnotify, bridge.WatchModePoll}, nil)
+		watchModeSelect.SetSelected(bridge.NormalizeWatchMode(cfg.WatchMode))
+		pollEntry := widget.NewEntry()
+		pollEntry.SetText(strconv.Itoa(cfg.PollIntervalMs))
+		maxMessageEntry := widget.NewEntry()
+		maxMessageEntry.SetText(strconv.Itoa(normalizeMaxMessageKB(cfg.MaxMessageKB)))
+		templateEntry := widget.NewEntry()
+		templateEntry.SetPlaceHolder(bridge.DefaultLaunchTemplate(cfg.IDECommand))
+		templateEntry.SetText(cfg.Launch.Template)
+		workDirEntry := widget.NewEntry()
+		workDirEntry.SetPlaceHolder("e.g. {dir}")
+		workDirEntry.SetText(cfg.Launch.WorkDir)
+		envEntry := widget.NewMultiLineEntry()
+		envEntry.SetPlaceHolder("KEY=VALUE, one per line")
+		envEntry.SetText(bridge.FormatEnvLines(cfg.Launch.Env))
+		envEntry.SetMinRowsVisible(2)
+		waitCheck := widget.NewCheck("Wait for the editor to close, then end the edit session", nil)
+		waitCheck.SetChecked(cfg.Launch.Wait)
+		mergeToolEntry := widget.NewEntry()
+		mergeToolEntry.SetText(cfg.MergeTool)
+		mergeToolEntry.SetPlaceHolder("Conflict markers in IDE, or e.g. meld {local} {base} {remote} -o {merged}")
+		wipeCheck := widget.NewCheck("Remove all temp files when quitting (shared machines)", nil)
+		wipeCheck.SetChecked(cfg.WipeTempFilesOnQuit)
+		workspaceSelect := widget.NewSelect([]string{bridge.WorkspaceOff, bridge.WorkspacePage, bridge.WorkspaceSession}, nil)
+		workspaceSelect.SetSelected(bridge.NormalizeWorkspaceMode(cfg.Workspace))
+
+		// Preview of the resolved command line for a sample snippet
+		previewLabel := widget.NewLabel("")
+		previewLabel.Wrapping = fyne.TextWrapWord
+		previewLabel.TextStyle = fyne.TextStyle{Monospace: true}
+		updatePreview := func(string) {
+			file := bridge.SessionFilePath(workspaceSelect.Selected, bridge.SessionKey{Server: wsEntry.Text, Page: "https://example.com/demo", SnippetID: "example"}, "js")
+			if bridge.WorkspaceOf(file) != "" {
+				templateEntry.SetPlaceHolder(bridge.WorkspaceLaunchTemplate(ideEntry.Text))
+			} else {
+				templateEntry.SetPlaceHolder(bridge.DefaultLaunchTemplate(ideEntry.Text))
+			}
+			launch, err := bridge.ResolveLaunch(bridge.LaunchProfile{
+				Template: templateEntry.Text,
+				Env:      bridge.ParseEnvLines(envEntry.Text),
+				WorkDir:  workDirEntry.Text,
+				Wait:     waitCheck.Checked,
+			}, bridge.LaunchContext{
+				File:      file,
+				Workspace: bridge.WorkspaceOf(file),
+				Line:      12,
+				Column:    5,
+				SnippetID: "example",
+				FileType:  "js",
+				IDE:       ideEntry.Text,
+			})
+			if err != nil {
+				previewLabel.SetText("Error: " + err.Error())
+			} else {
+				previewLabel.SetText(launch.String())
+			}
+		}
+		// IDE mappings table: one row per mapping with pattern, IDE and optional template
+		type mappingRow struct {
+			pattern, ide, template *widget.Entry
+			profile                bridge.LaunchProfile // keeps env and working directory set in the config file
+		}
+		var mappingRows []*mappingRow
+		mappingsBox := container.NewVBox()
+		var refreshMappings func()
+		addMappingRow := func(m bridge.IDEMapping) {
+			row := &mappingRow{pattern: widget.NewEntry(), ide: widget.NewEntry(), template: widget.NewEntry(), profile: m.LaunchProfile}
+			row.pattern.SetPlaceHolder("sql or *.py")
+			row.pattern.SetText(m.Pattern)
+			row.ide.SetPlaceHolder(ideEntry.Text)
+			row.ide.SetText(m.IDE)
+			row.template.SetPlaceHolder("default template")
+			row.template.SetText(m.Template)
+			mappingRows = append(mappingRows, row)
+		}
+		refreshMappings = func() {
+			mappingsBox.Objects = nil
+			mappingsBox.Add(container.NewGridWithColumns(3,
+				widget.NewLabelWithStyle("fileType / Glob", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("IDE", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("Launch Template", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			))
+			for i, row := range mappingRows {
+				index := i
+				removeBtn := widget.NewButton("Remove", func() {
+					mappingRows = append(mappingRows[:index], mappingRows[index+1:]...)
+					refreshMappings()
+				})
+				mappingsBox.Add(container.NewBorder(nil, nil, nil, removeBtn,
+					container.NewGridWithColumns(3, row.pattern, row.ide, row.template)))
+			}
+			mappingsBox.Refresh()
+		}
+		for _, m := range cfg.IDEMappings {
+			addMappingRow(m)
+		}
+		refreshMappings()
+		addMappingBtn := widget.NewButton("Add Mapping", func() {
+			addMappingRow(bridge.IDEMapping{})
+			refreshMappings()
+		})
+
+		// Formatters table: one row per pipeline step with pattern, command and timeout
+		type formatterRow struct {
+			pattern, command, timeout *widget.Entry
+		}
+		var formatterRows []*formatterRow
+		formattersBox := container.NewVBox()
+		var refreshFormatters func()
+		addFormatterRow := func(f bridge.Formatter) {
+			row := &formatterRow{pattern: widget.NewEntry(), command: widget.NewEntry(), timeout: widget.NewEntry()}
+			row.pattern.SetPlaceHolder("js, ts")
+			row.pattern.SetText(f.Pattern)
+			row.command.SetPlaceHolder("prettier --stdin-filepath {file}")
+			row.command.SetText(f.Command)
+			row.timeout.SetPlaceHolder(strconv.Itoa(bridge.DefaultToolTimeoutMs))
+			if f.TimeoutMs > 0 {
+				row.timeout.SetText(strconv.Itoa(f.TimeoutMs))
+			}
+			formatterRows = append(formatterRows, row)
+		}
+		refreshFormatters = func() {
+			formattersBox.Objects = nil
+			formattersBox.Add(container.NewGridWithColumns(3,
+				widget.NewLabelWithStyle("fileType / Glob", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("Command (stdin to stdout)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("Timeout (ms)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			))
+			for i, row := range formatterRows {
+				index := i
+				removeBtn := widget.NewButton("Remove", func() {
+					formatterRows = append(formatterRows[:index], formatterRows[index+1:]...)
+					refreshFormatters()
+				})
+				formattersBox.Add(container.NewBorder(nil, nil, nil, removeBtn,
+					container.NewGridWithColumns(3, row.pattern, row.command, row.timeout)))
+			}
+			formattersBox.Refresh()
+		}
+		for _, f := range cfg.Formatters {
+			addFormatterRow(f)
+		}
+		refreshFormatters()
+		addFormatterBtn := widget.NewButton("Add Formatter", func() {
+			addFormatterRow(bridge.Formatter{})
+			refreshFormatters()
+		})
+		writeBackCheck := widget.NewCheck("Write formatted code back to the temp file", nil)
+		writeBackCheck.SetChecked(cfg.FormatWriteBack)
+
+		// Validators table: one row per pipeline step with pattern, built-in or command, and timeout
+		type validatorRow struct {
+			pattern, check, timeout *widget.Entry
+			match                   string // keeps the output expression set in the config file
+		}
+		var validatorRows []*validatorRow
+		validatorsBox := container.NewVBox()
+		var refreshValidators func()
+		addValidatorRow := func(v bridge.Validator) {
+			row := &validatorRow{pattern: widget.NewEntry(), check: widget.NewEntry(), timeout: widget.NewEntry(), match: v.Match}
+			row.pattern.SetPlaceHolder("json")
+			row.pattern.SetText(v.Pattern)
+			row.check.SetPlaceHolder("json, xml, yaml, or a linter command")
+			row.check.SetText(v.Command)
+			if v.Builtin != "" {
+				row.check.SetText(v.Builtin)
+			}
+			row.timeout.SetPlaceHolder(strconv.Itoa(bridge.DefaultToolTimeoutMs))
+			if v.TimeoutMs > 0 {
+				row.timeout.SetText(strconv.Itoa(v.TimeoutMs))
+			}
+			validatorRows = append(validatorRows, row)
+		}
+		refreshValidators = func() {
+			validatorsBox.Objects = nil
+			validatorsBox.Add(container.NewGridWithColumns(3,
+				widget.NewLabelWithStyle("fileType / Glob", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("Built-in or Command", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabelWithStyle("Timeout (ms)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			))
+			for i, row := range validatorRows {
+				index := i
+				removeBtn := widget.NewButton("Remove", func() {
+					validatorRows = append(validatorRows[:index], validatorRows[index+1:]...)
+					refreshValidators()
+				})
+				validatorsBox.Add(container.NewBorder(nil, nil, nil, removeBtn,
+					container.NewGridWithColumns(3, row.pattern, row.check, row.timeout)))
+			}
+			validatorsBox.Refresh()
+		}
+		for _, v := range cfg.Validators {
+			addValidatorRow(v)
+		}
+		refreshValidators()
+		addValidatorBtn := widget.NewButton("Add Validator", func() {
+			addValidatorRow(bridge.Validator{})
+			refreshValidators()
+		})
+		policySelect := widget.NewSelect([]string{bridge.ValidationWarn, bridge.ValidationBlock}, nil)
+		policySelect.SetSelected(bridge.NormalizeValidationPolicy(cfg.ValidationPolicy))
+		encodingSelect := widget.NewSelect([]string{bridge.EncodingAuto, bridge.EncodingUTF8, bridge.EncodingUTF8BOM, bridge.EncodingCP1252}, nil)
+		encodingSelect.SetSelected(bridge.NormalizeTextEncoding(cfg.TextEncoding))
+		detectSelect := widget.NewSelect([]string{bridge.DetectAuto, bridge.DetectEmpty, bridge.DetectOff}, nil)
+		detectSelect.SetSelected(bridge.NormalizeDetectLanguage(cfg.DetectLanguage))
+		historySelect := widget.NewSelect([]string{bridge.HistoryOn, bridge.HistoryEncrypted, bridge.HistoryOff}, nil)
+		historySelect.SetSelected(bridge.NormalizeHistoryMode(cfg.History))
+		limits := historyLimitsOf(cfg)
+		historyVersionsEntry := widget.NewEntry()
+		historyVersionsEntry.SetText(strconv.Itoa(limits.MaxVersions))
+		historyDaysEntry := widget.NewEntry()
+		historyDaysEntry.SetText(strconv.Itoa(int(limits.MaxAge / (24 * time.Hour))))
+		lineEndingsSelect := widget.NewSelect([]string{bridge.LineEndingsAuto, bridge.LineEndingsLF, bridge.LineEndingsCRLF}, nil)
+		lineEndingsSelect.SetSelected(bridge.NormalizeLineEndings(cfg.LineEndings))
+
+		ideEntry.OnChanged = updatePreview
+		templateEntry.OnChanged = updatePreview
+		workDirEntry.OnChanged = updatePreview
+		envEntry.OnChanged = updatePreview
+		waitCheck.OnChanged = func(bool) { updatePreview("") }
+		workspaceSelect.OnChanged = updatePreview
+		updatePreview("")
 
 		browseBtn := widget.NewButton("Browse", func() {
 			startDir := ""
@@ -1005,9 +1600,43 @@ func main() {
 		headerText := widget.NewLabelWithStyle("Edit Configuration", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 		header := container.NewMax(headerGradient, headerText)
 
-		// IDE field row with Browse button inline
+		// IDE field row with Browse button inline, shown for custom commands
 		ideRow := container.NewBorder(nil, nil, nil, browseBtn, ideEntry)
 
+		// Dropdown of discovered editors, with a custom option for other commands and paths
+		// While discovery still runs, only the custom option is offered
+		const customEditorOption = "Custom command or path..."
+		editors, _ := wsClient.discovery.ready()
+		editorOptions := make([]string, 0, len(editors)+1)
+		editorByOption := map[string]discoveredEditor{}
+		selectedOption := customEditorOption
+		for _, e := range editors {
+			option := fmt.Sprintf("%s  (%s, %s)", e.Name, e.Command, e.Source)
+			editorOptions = append(editorOptions, option)
+			editorByOption[option] = e
+			if e.Command == cfg.IDECommand && selectedOption == customEditorOption {
+				selectedOption = option
+			}
+		}
+		editorOptions = append(editorOptions, customEditorOption)
+		lastEditorTemplate := ""
+		editorSelect := widget.NewSelect(editorOptions, func(option string) {
+			e, ok := editorByOption[option]
+			if !ok {
+				ideRow.Show()
+				return
+			}
+			ideRow.Hide()
+			ideEntry.SetText(e.Command)
+			// Replace the template only if it is empty or came from the previously selected editor
+			if current := strings.TrimSpace(templateEntry.Text); current == "" || current == lastEditorTemplate {
+				templateEntry.SetText(e.Template)
+			}
+			lastEditorTemplate = e.Template
+		})
+		editorSelect.SetSelected(selectedOption)
+		ideField := container.NewVBox(editorSelect, ideRow)
+
 		// In showEditConfig, show only the platform-specific tip for the current OS
 		var platformTip fyne.CanvasObject
 		if runtime.GOOS == "darwin" {
@@ -1025,15 +1654,43 @@ func main() {
 		form := container.New(layout.NewFormLayout(),
 			widget.NewLabelWithStyle("User ID:", fyne.TextAlignTrailing, fyne.TextStyle{}), userEntry,
 			widget.NewLabelWithStyle("WebSocket URL:", fyne.TextAlignTrailing, fyne.TextStyle{}), wsEntry,
-			widget.NewLabelWithStyle("IDE Command:", fyne.TextAlignTrailing, fyne.TextStyle{}), ideRow,
+			widget.NewLabelWithStyle("IDE Command:", fyne.TextAlignTrailing, fyne.TextStyle{}), ideField,
 			widget.NewLabel(""), platformTip,
+			widget.NewLabelWithStyle("Launch Template:", fyne.TextAlignTrailing, fyne.TextStyle{}), templateEntry,
+			widget.NewLabel(""), widget.NewLabel("Placeholders: {file} {files} {dir} {workspace} {line} {column} {endLine} {endColumn} {snippetId} {fileType} {ide}"),
+			widget.NewLabelWithStyle("Working Directory:", fyne.TextAlignTrailing, fyne.TextStyle{}), workDirEntry,
+			widget.NewLabelWithStyle("Environment:", fyne.TextAlignTrailing, fyne.TextStyle{}), envEntry,
+			widget.NewLabel(""), waitCheck,
+			widget.NewLabelWithStyle("Preview:", fyne.TextAlignTrailing, fyne.TextStyle{}), previewLabel,
+			widget.NewLabelWithStyle("IDE Mappings:", fyne.TextAlignTrailing, fyne.TextStyle{}), mappingsBox,
+			widget.NewLabel(""), container.NewHBox(addMappingBtn),
+			widget.NewLabelWithStyle("Formatters:", fyne.TextAlignTrailing, fyne.TextStyle{}), formattersBox,
+			widget.NewLabel(""), container.NewHBox(addFormatterBtn, writeBackCheck),
+			widget.NewLabelWithStyle("Validators:", fyne.TextAlignTrailing, fyne.TextStyle{}), validatorsBox,
+			widget.NewLabel(""), container.NewHBox(addValidatorBtn, widget.NewLabel("On errors:"), policySelect),
+			widget.NewLabelWithStyle("Text Encoding:", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			container.NewHBox(encodingSelect, widget.NewLabel("Line Endings:"), lineEndingsSelect),
+			widget.NewLabelWithStyle("Detect Language:", fyne.TextAlignTrailing, fyne.TextStyle{}), detectSelect,
+			widget.NewLabelWithStyle("Version History:", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			container.NewHBox(historySelect, widget.NewLabel("Keep Versions:"), historyVersionsEntry, widget.NewLabel("Days:"), historyDaysEntry),
+			widget.NewLabel(""), widget.NewLabel("Encrypted: the key in ~/.web-ide-bridge/history.key has no passphrase; it only protects copies of the history folder"),
+			widget.NewLabelWithStyle("Watch Mode:", fyne.TextAlignTrailing, fyne.TextStyle{}), watchModeSelect,
+			widget.NewLabelWithStyle("Poll Interval (ms):", fyne.TextAlignTrailing, fyne.TextStyle{}), pollEntry,
+			widget.NewLabelWithStyle("Max Message (KB):", fyne.TextAlignTrailing, fyne.TextStyle{}), maxMessageEntry,
+			widget.NewLabelWithStyle("Merge Tool:", fyne.TextAlignTrailing, fyne.TextStyle{}), mergeToolEntry,
+			widget.NewLabelWithStyle("Temp Files:", fyne.TextAlignTrailing, fyne.TextStyle{}), wipeCheck,
+			widget.NewLabelWithStyle("Workspace Folders:", fyne.TextAlignTrailing, fyne.TextStyle{}), workspaceSelect,
 		)
 
+		// The form is taller than small screens; scroll it so that the buttons stay visible
+		formScroll := container.NewVScroll(container.NewPadded(form)) // Side padding for form
+		formScroll.SetMinSize(fyne.NewSize(0, 480))
+
 		customDialogContent := container.NewVBox(
 			header,
-			layout.NewSpacer(),        // Top margin
-			container.NewPadded(form), // Side padding for form
-			layout.NewSpacer(),        // Bottom margin
+			layout.NewSpacer(), // Top margin
+			formScroll,
+			layout.NewSpacer(), // Bottom margin
 		)
 
 		customDialog := dialog.NewCustomConfirm(
@@ -1046,6 +1703,73 @@ func main() {
 					cfg.UserID = userEntry.Text
 					cfg.WebSocket = wsEntry.Text
 					cfg.IDECommand = ideEntry.Text
+					cfg.Launch = bridge.LaunchProfile{
+						Template: strings.TrimSpace(templateEntry.Text),
+						Env:      bridge.ParseEnvLines(envEntry.Text),
+						WorkDir:  strings.TrimSpace(workDirEntry.Text),
+						Wait:     waitCheck.Checked,
+					}
+					cfg.IDEMappings = []bridge.IDEMapping{}
+					for _, row := range mappingRows {
+						pattern := strings.TrimSpace(row.pattern.Text)
+						if pattern == "" {
+							continue
+						}
+						profile := row.profile
+						profile.Template = strings.TrimSpace(row.template.Text)
+						cfg.IDEMappings = append(cfg.IDEMappings, bridge.IDEMapping{
+							Pattern:       pattern,
+							IDE:           strings.TrimSpace(row.ide.Text),
+							LaunchProfile: profile,
+						})
+					}
+					cfg.Formatters = []bridge.Formatter{}
+					for _, row := range formatterRows {
+						pattern := strings.TrimSpace(row.pattern.Text)
+						command := strings.TrimSpace(row.command.Text)
+						if pattern == "" || command == "" {
+							continue
+						}
+						timeoutMs, _ := strconv.Atoi(strings.TrimSpace(row.timeout.Text))
+						cfg.Formatters = append(cfg.Formatters, bridge.Formatter{Pattern: pattern, Command: command, TimeoutMs: timeoutMs})
+					}
+					cfg.FormatWriteBack = writeBackCheck.Checked
+					cfg.Validators = []bridge.Validator{}
+					for _, row := range validatorRows {
+						pattern := strings.TrimSpace(row.pattern.Text)
+						check := strings.TrimSpace(row.check.Text)
+						if pattern == "" || check == "" {
+							continue
+						}
+						timeoutMs, _ := strconv.Atoi(strings.TrimSpace(row.timeout.Text))
+						v := bridge.Validator{Pattern: pattern, Command: check, Match: row.match, TimeoutMs: timeoutMs}
+						switch check {
+						case "json", "xml", "yaml":
+							v = bridge.Validator{Pattern: pattern, Builtin: check}
+						}
+						cfg.Validators = append(cfg.Validators, v)
+					}
+					cfg.ValidationPolicy = bridge.NormalizeValidationPolicy(policySelect.Selected)
+					cfg.TextEncoding = bridge.NormalizeTextEncoding(encodingSelect.Selected)
+					cfg.LineEndings = bridge.NormalizeLineEndings(lineEndingsSelect.Selected)
+					cfg.DetectLanguage = bridge.NormalizeDetectLanguage(detectSelect.Selected)
+					cfg.History = bridge.NormalizeHistoryMode(historySelect.Selected)
+					if n, err := strconv.Atoi(strings.TrimSpace(historyVersionsEntry.Text)); err == nil && n > 0 {
+						cfg.HistoryMaxVersions = n
+					}
+					if n, err := strconv.Atoi(strings.TrimSpace(historyDaysEntry.Text)); err == nil && n > 0 {
+						cfg.HistoryMaxDays = n
+					}
+					cfg.WatchMode = bridge.NormalizeWatchMode(watchModeSelect.Selected)
+					if ms, err := strconv.Atoi(strings.TrimSpace(pollEntry.Text)); err == nil && ms > 0 {
+						cfg.PollIntervalMs = ms
+					}
+					if kb, err := strconv.Atoi(strings.TrimSpace(maxMessageEntry.Text)); err == nil && kb > 0 {
+						cfg.MaxMessageKB = normalizeMaxMessageKB(kb)
+					}
+					cfg.MergeTool = strings.TrimSpace(mergeToolEntry.Text)
+					cfg.WipeTempFilesOnQuit = wipeCheck.Checked
+					cfg.Workspace = bridge.NormalizeWorkspaceMode(workspaceSelect.Selected)
 					err := saveConfig(cfg)
 					if err != nil {
 						appendLog("Failed to save configuration: " + err.Error())
@@ -1054,6 +1778,8 @@ func main() {
 						userVal.SetText(cfg.UserID)
 						wsVal.SetText(cfg.WebSocket)
 						ideVal.SetText(cfg.IDECommand)
+						watchVal.SetText(watchModeText(cfg))
+						mappingsVal.SetText(formatIDEMappings(cfg))
 						go func() {
 							appendLog("Re-initializing app with new configuration...")
 							// Re-initialize the configuration similar to app restart
@@ -1100,6 +1826,8 @@ func main() {
 		widget.NewLabelWithStyle("User ID:", fyne.TextAlignTrailing, fyne.TextStyle{}), userVal,
 		widget.NewLabelWithStyle("WebSocket URL:", fyne.TextAlignTrailing, fyne.TextStyle{}), wsVal,
 		widget.NewLabelWithStyle("IDE Command:", fyne.TextAlignTrailing, fyne.TextStyle{}), ideVal,
+		widget.NewLabelWithStyle("IDE Mappings:", fyne.TextAlignTrailing, fyne.TextStyle{}), mappingsVal,
+		widget.NewLabelWithStyle("Watch Mode:", fyne.TextAlignTrailing, fyne.TextStyle{}), watchVal,
 		widget.NewLabelWithStyle("Connection ID:", fyne.TextAlignTrailing, fyne.TextStyle{}), connIDVal,
 	)
 	configSection := container.NewVBox(
@@ -1109,11 +1837,283 @@ func main() {
 	)
 	configCard := widget.NewCard("", "", configSection)
 
+	// Active Sessions Section (one row per edit session, actions apply to the selected row)
+	var sessionsMu sync.Mutex
+	var sessions []bridge.SessionInfo
+	var selected *bridge.SessionKey
+	sessionWidths := []float32{90, 130, 45, 90, 65, 105, 75, 320} // of bridge.SessionColumns
+	sessionsTable := widget.NewTableWithHeaders(
+		func() (int, int) {
+			sessionsMu.Lock()
+			defer sessionsMu.Unlock()
+			return len(sessions), len(bridge.SessionColumns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			sessionsMu.Lock()
+			defer sessionsMu.Unlock()
+			if id.Row < len(sessions) {
+				o.(*widget.Label).SetText(sessions[id.Row].Cell(id.Col))
+			}
+		},
+	)
+	sessionsTable.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	sessionsTable.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 {
+			o.(*widget.Label).SetText(bridge.SessionColumns[id.Col])
+		}
+	}
+	for i, width := range sessionWidths {
+		sessionsTable.SetColumnWidth(i, width)
+	}
+	sessionsEmpty := widget.NewLabel("No active edit sessions")
+	sessionsEmpty.TextStyle = fyne.TextStyle{Italic: true}
+	sessionsMinSize := canvas.NewRectangle(color.Transparent)
+	sessionsMinSize.SetMinSize(fyne.NewSize(0, 120))
+
+	// Actions run in the background, since stopping a watcher waits for it to exit
+	sessionAction := func(action func(key bridge.SessionKey) error) func() {
+		return func() {
+			sessionsMu.Lock()
+			if selected == nil {
+				sessionsMu.Unlock()
+				return
+			}
+			key := *selected
+			sessionsMu.Unlock()
+			go func() {
+				if err := action(key); err != nil {
+					wsClient.log("Session action failed for snippet " + key.String() + ": " + err.Error())
+				}
+			}()
+		}
+	}
+	reopenBtn := widget.NewButton("Reopen in IDE", sessionAction(wsClient.reopenSession))
+	revealBtn := widget.NewButton("Reveal File", sessionAction(wsClient.revealSession))
+	resendBtn := widget.NewButton("Resend Now", sessionAction(wsClient.resendSession))
+	stopBtn := widget.NewButton("Stop Watching", sessionAction(func(key bridge.SessionKey) error {
+		wsClient.stopSession(key)
+		return nil
+	}))
+	discard := sessionAction(func(key bridge.SessionKey) error {
+		wsClient.discardSession(key)
+		return nil
+	})
+	discardBtn := widget.NewButton("Discard", func() {
+		dialog.ShowConfirm("Discard Edit Session",
+			"Stop watching and delete the temp file? Changes not sent to the browser are lost.",
+			func(ok bool) {
+				if ok {
+					discard()
+				}
+			}, w)
+	})
+	discardBtn.Importance = widget.DangerImportance
+
+	// Version History window: versions of the selected snippet, or of all snippets
+	showHistory := func(key *bridge.SessionKey) {
+		hw := a.NewWindow("Web-IDE-Bridge Version History")
+		hw.Resize(fyne.NewSize(900, 600))
+		var entries []bridge.HistoryEntry
+		var current *bridge.HistoryEntry
+		diffLabel := widget.NewLabel("Select a version to see its changes.")
+		diffLabel.TextStyle = fyne.TextStyle{Monospace: true}
+		diffScroll := container.NewScroll(diffLabel)
+		versionsList := widget.NewList(
+			func() int { return len(entries) },
+			func() fyne.CanvasObject {
+				label := widget.NewLabel("")
+				label.Truncation = fyne.TextTruncateEllipsis
+				return label
+			},
+			func(id widget.ListItemID, o fyne.CanvasObject) {
+				if id < len(entries) {
+					o.(*widget.Label).SetText(entries[id].String())
+				}
+			},
+		)
+		// showDiff shows the changes between two versions, older first
+		showDiff := func(title string, older, newer func() (string, error)) {
+			if current == nil {
+				return
+			}
+			oldCode, err := older()
+			if err == nil {
+				var newCode string
+				if newCode, err = newer(); err == nil {
+					diffLabel.SetText(title + "\n\n" + bridge.DiffVersions(oldCode, newCode, current.Encoding != ""))
+					diffScroll.ScrollToTop()
+					return
+				}
+			}
+			diffLabel.SetText(title + "\n\n" + err.Error())
+		}
+		readVersion := func(e bridge.HistoryEntry) func() (string, error) {
+			return func() (string, error) { return wsClient.history.Read(e) }
+		}
+		diffPrevious := func() {
+			if current == nil {
+				return
+			}
+			e := *current
+			prev, ok := wsClient.history.Previous(e)
+			if !ok {
+				showDiff(fmt.Sprintf("Version #%d of %s, the first version:", e.ID, e.Key), func() (string, error) { return "", nil }, readVersion(e))
+				return
+			}
+			showDiff(fmt.Sprintf("Changes from version #%d to #%d of %s:", prev.ID, e.ID, e.Key), readVersion(prev), readVersion(e))
+		}
+		diffPrevBtn := widget.NewButton("Diff with Previous", diffPrevious)
+		diffCurrentBtn := widget.NewButton("Diff with Current File", func() {
+			if current == nil {
+				return
+			}
+			e := *current
+			showDiff(fmt.Sprintf("Changes from version #%d to the current temp file of %s:", e.ID, e.Key), readVersion(e),
+				func() (string, error) { return wsClient.currentCode(e.Key) })
+		})
+		restoreBtn := widget.NewButton("Restore This Version", func() {
+			if current == nil {
+				return
+			}
+			e := *current
+			dialog.ShowConfirm("Restore Version",
+				fmt.Sprintf("Replace the temp file of %s with version #%d and send it to the browser?", e.Key, e.ID),
+				func(ok bool) {
+					if !ok {
+						return
+					}
+					go func() {
+						if err := wsClient.restoreVersion(e); err != nil {
+							wsClient.log("Restore failed for snippet " + e.Key.String() + ": " + err.Error())
+						}
+					}()
+				}, hw)
+		})
+		restoreBtn.Importance = widget.HighImportance
+		versionButtons := []*widget.Button{diffPrevBtn, diffCurrentBtn, restoreBtn}
+		for _, btn := range versionButtons {
+			btn.Disable()
+		}
+		versionsList.OnSelected = func(id widget.ListItemID) {
+			if id >= len(entries) {
+				return
+			}
+			e := entries[id]
+			current = &e
+			for _, btn := range versionButtons {
+				btn.Enable()
+			}
+			if _, open := wsClient.watcher(e.Key); !open {
+				diffCurrentBtn.Disable()
+				restoreBtn.Disable()
+			}
+			diffPrevious()
+		}
+		// load lists the versions of the snippet, or the search results of all snippets
+		load := func(query string) {
+			var err error
+			if strings.TrimSpace(query) == "" && key != nil {
+				entries, err = wsClient.history.List(key)
+			} else {
+				entries, err = wsClient.history.Search(query)
+			}
+			if err != nil {
+				wsClient.log("Failed to read version history: " + err.Error())
+			}
+			current = nil
+			versionsList.UnselectAll()
+			versionsList.Refresh()
+			for _, btn := range versionButtons {
+				btn.Disable()
+			}
+			switch {
+			case len(entries) == 0 && strings.TrimSpace(query) != "":
+				diffLabel.SetText("No versions contain \"" + query + "\".")
+			case len(entries) == 0:
+				diffLabel.SetText("No versions recorded yet.")
+			default:
+				diffLabel.SetText(fmt.Sprintf("%d versions. Select a version to see its changes.", len(entries)))
+			}
+		}
+		searchEntry := widget.NewEntry()
+		searchEntry.SetPlaceHolder("Search the code of all snippets")
+		searchEntry.OnSubmitted = load
+		searchBtn := widget.NewButton("Search", func() { load(searchEntry.Text) })
+		scope := "all snippets"
+		if key != nil {
+			scope = "snippet " + key.String()
+		}
+		split := container.NewHSplit(versionsList, diffScroll)
+		split.Offset = 0.45
+		hw.SetContent(container.NewPadded(container.NewBorder(
+			container.NewVBox(
+				widget.NewLabelWithStyle("Versions of "+scope, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				container.NewBorder(nil, nil, nil, searchBtn, searchEntry),
+			),
+			container.NewHBox(layout.NewSpacer(), diffPrevBtn, diffCurrentBtn, restoreBtn, layout.NewSpacer()),
+			nil, nil, split,
+		)))
+		load("")
+		hw.Show()
+	}
+	historyBtn := widget.NewButton("History", func() {
+		sessionsMu.Lock()
+		var key *bridge.SessionKey
+		if selected != nil {
+			k := *selected
+			key = &k
+		}
+		sessionsMu.Unlock()
+		showHistory(key)
+	})
+	sessionButtons := []*widget.Button{reopenBtn, revealBtn, resendBtn, stopBtn, discardBtn}
+	setSessionButtons := func(enabled bool) {
+		for _, btn := range sessionButtons {
+			if enabled {
+				btn.Enable()
+			} else {
+				btn.Disable()
+			}
+		}
+	}
+	setSessionButtons(false)
+	sessionsTable.OnSelected = func(id widget.TableCellID) {
+		sessionsMu.Lock()
+		if id.Row >= 0 && id.Row < len(sessions) {
+			key := sessions[id.Row].Key
+			selected = &key
+		}
+		enabled := selected != nil
+		sessionsMu.Unlock()
+		setSessionButtons(enabled)
+	}
+	sessionsTableArea := container.NewMax(sessionsMinSize, sessionsTable)
+	sessionsTableArea.Hide()
+	// Progress of large transfers, shown while snippets are sent or received in chunks
+	transferLabel := widget.NewLabel("")
+	transferBar := widget.NewProgressBar()
+	transferRow := container.NewBorder(nil, nil, transferLabel, nil, transferBar)
+	transferRow.Hide()
+	sessionsSection := container.NewVBox(
+		sectionHeader("Active Sessions"),
+		container.NewPadded(container.NewVBox(sessionsEmpty, sessionsTableArea, transferRow)),
+		container.NewHBox(layout.NewSpacer(), reopenBtn, revealBtn, resendBtn, stopBtn, discardBtn, historyBtn, layout.NewSpacer()),
+	)
+	sessionsCard := widget.NewCard("", "", sessionsSection)
+
 	mainContent := container.NewVBox(
 		container.NewCenter(titleRow),
 		container.NewCenter(intro),
 		connStatusCard,
 		configCard,
+		sessionsCard,
 		logCard,
 	)
 
@@ -1128,6 +2128,12 @@ func main() {
 			w.SetCloseIntercept(func() {
 			appendLog("Web-IDE-Bridge application shutting down...")
 			wsClient.Close()
+			wsClient.statusMu.Lock()
+			wipe := wsClient.cfg.WipeTempFilesOnQuit || appCfg.WipeTempFilesOnQuit
+			wsClient.statusMu.Unlock()
+			if wipe {
+				appendLog("Wiping temp files: " + wsClient.tempFiles.Wipe().String())
+			}
 			w.Close()
 		})
 
@@ -1149,6 +2155,48 @@ func main() {
 		}
 	}()
 
+	// Goroutine to show the progress of large transfers
+	go func() {
+		for range wsClient.transfersCh {
+			label, fraction, ok := wsClient.transferStatus()
+			if !ok {
+				transferRow.Hide()
+				continue
+			}
+			transferLabel.SetText(label)
+			transferBar.SetValue(fraction)
+			transferRow.Show()
+		}
+	}()
+
+	// Goroutine to update the sessions table when sessions start, stop or change state
+	go func() {
+		for range wsClient.watchersCh {
+			infos := wsClient.getWatcherInfos()
+			sessionsMu.Lock()
+			sessions = infos
+			row := -1
+			if selected != nil {
+				if row = bridge.SessionRow(infos, *selected); row < 0 {
+					selected = nil
+				}
+			}
+			sessionsMu.Unlock()
+			if row < 0 {
+				sessionsTable.UnselectAll()
+				setSessionButtons(false)
+			}
+			if len(infos) == 0 {
+				sessionsTableArea.Hide()
+				sessionsEmpty.Show()
+			} else {
+				sessionsEmpty.Hide()
+				sessionsTableArea.Show()
+			}
+			sessionsTable.Refresh()
+		}
+	}()
+
 	// Add a goroutine to update sbStatusLabel and sbStatusDot based on wsClient.browserConnected
 	go func() {
 		for {
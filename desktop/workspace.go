@@ -0,0 +1,34 @@
+/**
+ * @name            Web-IDE-Bridge / Desktop / Workspace
+ * @tagline         User templates for workspace scaffolding
+ * @description     Scaffolds workspace folders with the templates of the app config and
+ *                  the user template folders next to the user config
+ * @file            desktop/workspace.go
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+package main
+
+import (
+	"path/filepath"
+
+	"web-ide-bridge-desktop/bridge"
+)
+
+// workspaceTemplatesDir returns the folder with user scaffolding templates, next to the
+// user config: templates/_all/ for all languages, templates/<fileType>/ per language
+func workspaceTemplatesDir() string {
+	return filepath.Join(filepath.Dir(configPath()), "templates")
+}
+
+// scaffoldWorkspace creates a workspace folder with its scaffolding files, including the
+// user templates, see bridge.ScaffoldWorkspace
+func scaffoldWorkspace(dir string, key bridge.SessionKey, fileType, fileName string, templates map[string]map[string]string) (int, error) {
+	return bridge.ScaffoldWorkspace(dir, workspaceTemplatesDir(), key, fileType, fileName, templates)
+}
@@ -24,9 +24,14 @@ const compression = require('compression');
 const morgan = require('morgan');
 const fs = require('fs');
 const path = require('path');
+const crypto = require('crypto');
 const { v4: uuidv4 } = require('uuid');
 const { VERSION } = require('./version.js');
 
+// Default limits of WebSocket messages and of messages reassembled from chunks
+const DEFAULT_MAX_MESSAGE_SIZE = 10 * 1024 * 1024;
+const DEFAULT_MAX_TRANSFER_SIZE = 64 * 1024 * 1024;
+
 /**
  * Web-IDE-Bridge Server
  * WebSocket relay server that bridges web applications with desktop IDEs
@@ -130,7 +135,10 @@ class WebIdeBridgeServer {
         websocketEndpoint: '/web-ide-bridge/ws',
         heartbeatInterval: 30000,
         maxConnections: 1000,
-        connectionTimeout: 300000
+        connectionTimeout: 300000,
+        maxMessageSize: DEFAULT_MAX_MESSAGE_SIZE,   // Largest WebSocket message, larger messages to the desktop are chunked
+        maxTransferSize: DEFAULT_MAX_TRANSFER_SIZE, // Largest message reassembled from chunks
+        perMessageDeflate: true           // Negotiate permessage-deflate compression
       },
       normalizeLineEndings: true, // Normalize line endings to LF
       endpoints: {
@@ -247,6 +255,25 @@ class WebIdeBridgeServer {
       throw new Error('Connection timeout must be at least 1000ms');
     }
 
+    // A config passed to the constructor is not merged with the defaults, so default the transfer settings
+    if (config.server.maxMessageSize === undefined) {
+      config.server.maxMessageSize = DEFAULT_MAX_MESSAGE_SIZE;
+    }
+    if (config.server.maxTransferSize === undefined) {
+      config.server.maxTransferSize = DEFAULT_MAX_TRANSFER_SIZE;
+    }
+    if (config.server.perMessageDeflate === undefined) {
+      config.server.perMessageDeflate = true;
+    }
+
+    if (!Number.isInteger(config.server.maxMessageSize) || config.server.maxMessageSize < 16 * 1024) {
+      throw new Error('Max message size must be at least 16KB');
+    }
+
+    if (!Number.isInteger(config.server.maxTransferSize) || config.server.maxTransferSize < config.server.maxMessageSize) {
+      throw new Error('Max transfer size must be at least the max message size');
+    }
+
     // Session validation
     if (config.environment === 'production') {
       if (config.session.secret === 'web-ide-bridge-secret' || 
@@ -298,8 +325,13 @@ class WebIdeBridgeServer {
       return { valid: false, error: 'Message must have a string connectionId field' };
     }
 
+    // Page URL identifies the edit session together with userId and snippetId
+    if (message.pageUrl !== undefined && (typeof message.pageUrl !== 'string' || message.pageUrl.length > 2048)) {
+      return { valid: false, error: 'pageUrl must be a string of 2048 characters or less' };
+    }
+
     // Validate message type
-    const validTypes = ['browser_connect', 'desktop_connect', 'status_connect', 'connection_init', 'edit_request', 'code_update', 'ping', 'info'];
+    const validTypes = ['browser_connect', 'desktop_connect', 'status_connect', 'connection_init', 'edit_request', 'edit_batch_request', 'browser_update', 'code_update', 'edit_event', 'ping', 'info', 'chunk'];
     if (!validTypes.includes(message.type)) {
       return { valid: false, error: `Unknown message type: ${message.type}` };
     }
@@ -320,15 +352,119 @@ class WebIdeBridgeServer {
         if (!message.userId || !message.snippetId || !message.code) {
           return { valid: false, error: 'edit_request requires userId, snippetId, and code' };
         }
-        if (message.code.length > 10 * 1024 * 1024) { // 10MB limit
-          return { valid: false, error: 'Code payload too large (max 10MB)' };
+        if (message.encoding !== undefined && message.encoding !== 'base64' && message.encoding !== 'dataurl') {
+          return { valid: false, error: "encoding must be 'base64' or 'dataurl'" };
+        }
+        if (message.mimeType !== undefined && (typeof message.mimeType !== 'string' || message.mimeType.length > 255)) {
+          return { valid: false, error: 'mimeType must be a string of 255 characters or less' };
+        }
+        for (const field of ['contextPrefix', 'contextSuffix']) {
+          if (message[field] !== undefined && typeof message[field] !== 'string') {
+            return { valid: false, error: `${field} must be a string` };
+          }
+        }
+        if (message.code.length + (message.contextPrefix || '').length + (message.contextSuffix || '').length > this.config.server.maxTransferSize) {
+          return { valid: false, error: this.codeTooLargeError() };
+        }
+        if (message.contextFiles !== undefined) {
+          const error = this.validateContextFiles(message.contextFiles);
+          if (error) {
+            return { valid: false, error };
+          }
+        }
+        {
+          const error = this.validateEditPosition(message) || this.validateTransform(message.transform);
+          if (error) {
+            return { valid: false, error };
+          }
+        }
+        break;
+
+      case 'edit_batch_request': {
+        if (!message.userId || !Array.isArray(message.snippets) || message.snippets.length === 0 || message.snippets.length > 50) {
+          return { valid: false, error: 'edit_batch_request requires userId and 1 to 50 snippets' };
+        }
+        let totalLength = 0;
+        for (const snippet of message.snippets) {
+          if (!snippet || !snippet.snippetId || typeof snippet.snippetId !== 'string' || typeof snippet.code !== 'string') {
+            return { valid: false, error: 'edit_batch_request snippets require snippetId and code' };
+          }
+          if (snippet.snippetId.length > 255) {
+            return { valid: false, error: 'snippetId must be 255 characters or less' };
+          }
+          const error = this.validateTransform(snippet.transform);
+          if (error) {
+            return { valid: false, error };
+          }
+          totalLength += snippet.code.length;
+        }
+        {
+          const error = this.validateTransform(message.transform);
+          if (error) {
+            return { valid: false, error };
+          }
+        }
+        if (totalLength > this.config.server.maxTransferSize) {
+          return { valid: false, error: this.codeTooLargeError() };
+        }
+        if (message.contextFiles !== undefined) {
+          const error = this.validateContextFiles(message.contextFiles);
+          if (error) {
+            return { valid: false, error };
+          }
+        }
+        break;
+      }
+
+      case 'browser_update':
+        if (!message.userId || !message.snippetId || typeof message.code !== 'string') {
+          return { valid: false, error: 'browser_update requires userId, snippetId, and code' };
+        }
+        if (message.code.length > this.config.server.maxTransferSize) {
+          return { valid: false, error: this.codeTooLargeError() };
         }
         break;
 
       case 'code_update':
-        if (!message.userId || !message.snippetId || !message.code) {
+        if (!message.userId || !message.snippetId || (!message.code && message.patch === undefined)) {
           return { valid: false, error: 'code_update requires userId, snippetId, and code' };
         }
+        if (message.patch !== undefined) {
+          if (!Array.isArray(message.patch) || message.patch.length > 100000 ||
+              !message.patch.every(op => typeof op === 'string' || Number.isInteger(op))) {
+            return { valid: false, error: 'patch must be an array of line counts and strings' };
+          }
+          if (!/^[0-9a-f]{64}$/.test(message.baseHash || '') || !/^[0-9a-f]{64}$/.test(message.hash || '')) {
+            return { valid: false, error: 'patch requires baseHash and hash' };
+          }
+        }
+        if (message.diagnostics !== undefined && (!Array.isArray(message.diagnostics) || message.diagnostics.length > 100)) {
+          return { valid: false, error: 'diagnostics must be an array of at most 100 entries' };
+        }
+        break;
+
+      case 'chunk':
+        if (typeof message.transferId !== 'string' || !message.transferId || message.transferId.length > 64) {
+          return { valid: false, error: 'chunk requires a transferId of 64 characters or less' };
+        }
+        if (!Number.isInteger(message.index) || !Number.isInteger(message.count) || message.index < 0 || message.index >= message.count) {
+          return { valid: false, error: 'chunk requires an index below its count' };
+        }
+        if (!Number.isInteger(message.size) || message.size < 0 || message.size > this.config.server.maxTransferSize) {
+          return { valid: false, error: `chunked message too large (max ${this.config.server.maxTransferSize} bytes)` };
+        }
+        if (!/^[0-9a-f]{64}$/.test(message.hash || '') || typeof message.data !== 'string') {
+          return { valid: false, error: 'chunk requires hash and data' };
+        }
+        break;
+
+      case 'edit_event':
+        if (!message.userId || !message.snippetId || !message.event || typeof message.event !== 'string') {
+          return { valid: false, error: 'edit_event requires userId, snippetId, and event' };
+        }
+        if (message.diagnostics !== undefined && (!Array.isArray(message.diagnostics) || message.diagnostics.length > 100)) {
+          return { valid: false, error: 'diagnostics must be an array of at most 100 entries' };
+        }
         break;
 
       case 'info':
@@ -341,6 +477,66 @@ class WebIdeBridgeServer {
     return { valid: true };
   }
 
+  /**
+   * Error message of code larger than the largest message reassembled from chunks. Browsers
+   * do not send chunks, so their messages are limited to maxMessageSize by the WebSocket server.
+   */
+  codeTooLargeError() {
+    const size = this.config.server.maxTransferSize;
+    const max = size >= 1024 * 1024 ? `${Math.floor(size / (1024 * 1024))}MB` : `${Math.floor(size / 1024)}KB`;
+    return `Code payload too large (max ${max})`;
+  }
+
+  /**
+   * Validate read-only companion files of an edit_request, returns an error message or null
+   */
+  validateContextFiles(contextFiles) {
+    if (!Array.isArray(contextFiles) || contextFiles.length > 50) {
+      return 'contextFiles must be an array of at most 50 files';
+    }
+    let totalLength = 0;
+    for (const file of contextFiles) {
+      if (!file || typeof file.path !== 'string' || typeof file.content !== 'string') {
+        return 'contextFiles entries require path and content strings';
+      }
+      if (!file.path || file.path.length > 255 || file.path.startsWith('/') || /^[a-zA-Z]:/.test(file.path) ||
+          file.path.split(/[\\/]/).includes('..')) {
+        return `contextFiles path must be a relative path within the workspace: ${file.path.substring(0, 255)}`;
+      }
+      totalLength += file.content.length;
+    }
+    if (totalLength > 10 * 1024 * 1024) { // 10MB limit
+      return 'contextFiles payload too large (max 10MB)';
+    }
+    return null;
+  }
+
+  /**
+   * Validate the optional cursor position or selection of an edit_request, returns an error message or null
+   */
+  validateEditPosition(message) {
+    for (const field of ['line', 'column', 'endLine', 'endColumn']) {
+      const value = message[field];
+      if (value !== undefined && (!Number.isInteger(value) || value < 1 || value > 10000000)) {
+        return `${field} must be a positive integer`;
+      }
+    }
+    if (message.line === undefined && (message.column !== undefined || message.endLine !== undefined || message.endColumn !== undefined)) {
+      return 'column, endLine, and endColumn require line';
+    }
+    return null;
+  }
+
+  /**
+   * Validate the optional transform name of an edit request, returns an error message or null
+   */
+  validateTransform(transform) {
+    if (transform !== undefined && (typeof transform !== 'string' || transform.length > 64)) {
+      return 'transform must be a string of 64 characters or less';
+    }
+    return null;
+  }
+
   /**
    * Enhanced rate limiting with sliding window
    */
@@ -373,6 +569,17 @@ class WebIdeBridgeServer {
     return record.requests.length <= maxRequests;
   }
 
+  /**
+   * Build the key of an edit session; the page URL tells apart pages with the same snippetId
+   * @param {string} userId - The user ID
+   * @param {string} pageUrl - The page URL of the browser, may be empty for older clients
+   * @param {string} snippetId - The snippet ID
+   * @returns {string} - The session key
+   */
+  getSessionKey(userId, pageUrl, snippetId) {
+    return pageUrl ? `${userId}:${pageUrl}:${snippetId}` : `${userId}:${snippetId}`;
+  }
+
   /**
    * Normalize line endings to Unix-style LF
    * @param {string} content - The content to normalize
@@ -386,6 +593,41 @@ class WebIdeBridgeServer {
     return content.replace(/\r\n/g, '\n').replace(/\r/g, '\n');
   }
 
+  /**
+   * Hash code the way the desktop app does, to identify the base version of patches
+   * @param {string} code - The code to hash
+   * @returns {string} - Hex SHA-256 hash of the code
+   */
+  hashCode(code) {
+    return crypto.createHash('sha256').update(code, 'utf8').digest('hex');
+  }
+
+  /**
+   * Apply a line patch of a code_update: a positive number copies lines of the base code,
+   * a negative number skips lines of the base code, and a string inserts text
+   * @param {string} base - The base code
+   * @param {Array} patch - The patch operations
+   * @returns {string|null} - The patched code, or null if the patch does not fit the base
+   */
+  applyLinePatch(base, patch) {
+    const lines = base.match(/[^\n]*\n|[^\n]+$/g) || [];
+    let pos = 0;
+    let code = '';
+    for (const op of patch) {
+      if (typeof op === 'string') {
+        code += op;
+      } else if (op > 0) {
+        if (pos + op > lines.length) return null;
+        code += lines.slice(pos, pos + op).join('');
+        pos += op;
+      } else if (op < 0) {
+        if (pos - op > lines.length) return null;
+        pos -= op;
+      }
+    }
+    return pos === lines.length ? code : null;
+  }
+
   /**
    * Initialize and start the server
    */
@@ -473,7 +715,9 @@ class WebIdeBridgeServer {
     // WebSocket server will be created after HTTP server is started
     this.wsOptions = {
       path: this.config.endpoints?.websocket || this.config.server.websocketEndpoint,
-      maxPayload: 10 * 1024 * 1024, // 10MB max payload
+      maxPayload: this.config.server.maxMessageSize,
+      // Compress messages of 1KB and more, if the client supports it
+      perMessageDeflate: this.config.server.perMessageDeflate ? { threshold: 1024 } : false,
       clientTracking: true
     };
   }
@@ -566,45 +810,7 @@ class WebIdeBridgeServer {
         // Clear timeout once we receive a message
         clearTimeout(connectionTimeout);
 
-        const message = JSON.parse(data);
-
-        // Validate message before processing
-        const validation = this.validateMessage(message);
-        if (!validation.valid) {
-          this.sendError(ws, validation.error);
-          return;
-        }
-
-        // Inline message routing logic
-        switch (message.type) {
-          case 'browser_connect':
-            this.handleBrowserConnect(ws, message);
-            break;
-          case 'desktop_connect':
-            this.handleDesktopConnect(ws, message);
-            break;
-          case 'edit_request':
-            this.handleEditRequest(ws, message);
-            break;
-          case 'code_update':
-            this.handleCodeUpdate(ws, message);
-            break;
-          case 'ping':
-            this.handlePing(ws, message);
-            break;
-          case 'connection_init':
-            this.handleConnectionInit(ws, message);
-            break;
-          case 'info':
-            this.handleInfoMessage(ws, message);
-            break;
-          case 'status_connect':
-            this.handleStatusConnect(ws, message);
-            break;
-          default:
-            this.sendError(ws, `Unknown message type: ${message.type}`);
-        }
-        this.metrics.messagesProcessed++;
+        this.routeMessage(ws, JSON.parse(data));
       } catch (error) {
         this.handleError(ws, 'Invalid JSON message', error);
       }
@@ -628,6 +834,142 @@ class WebIdeBridgeServer {
     });
   }
 
+  /**
+   * Validate a message from a client and route it to its handler
+   */
+  routeMessage(ws, message) {
+    // Validate message before processing
+    const validation = this.validateMessage(message);
+    if (!validation.valid) {
+      this.sendError(ws, validation.error);
+      return;
+    }
+
+    // Route by message type
+    switch (message.type) {
+      case 'browser_connect':
+        this.handleBrowserConnect(ws, message);
+        break;
+      case 'desktop_connect':
+        this.handleDesktopConnect(ws, message);
+        break;
+      case 'edit_request':
+        this.handleEditRequest(ws, message);
+        break;
+      case 'edit_batch_request':
+        this.handleEditBatchRequest(ws, message);
+        break;
+      case 'browser_update':
+        this.handleBrowserUpdate(ws, message);
+        break;
+      case 'code_update':
+        this.handleCodeUpdate(ws, message);
+        break;
+      case 'edit_event':
+        this.handleEditEvent(ws, message);
+        break;
+      case 'ping':
+        this.handlePing(ws, message);
+        break;
+      case 'connection_init':
+        this.handleConnectionInit(ws, message);
+        break;
+      case 'info':
+        this.handleInfoMessage(ws, message);
+        break;
+      case 'status_connect':
+        this.handleStatusConnect(ws, message);
+        break;
+      case 'chunk': {
+        // Route the message once all of its chunks arrived
+        const payload = this.receiveChunk(ws, message);
+        const inner = payload !== null ? JSON.parse(payload) : null;
+        if (inner && inner.type === 'chunk') {
+          this.sendError(ws, 'Error: Chunked messages cannot hold chunks');
+        } else if (inner) {
+          this.routeMessage(ws, inner);
+        }
+        break;
+      }
+      default:
+        this.sendError(ws, `Unknown message type: ${message.type}`);
+    }
+    this.metrics.messagesProcessed++;
+  }
+
+  /**
+   * Add a chunk of a chunked message to its transfer
+   * @returns {string|null} - The reassembled message once the last chunk arrived, else null
+   */
+  receiveChunk(ws, message) {
+    const { transferId, index, count, size, hash, data } = message;
+    if (!ws.transfers) ws.transfers = new Map();
+    let transfer = ws.transfers.get(transferId);
+    if (index === 0) {
+      // Transfers that never completed count against the limit until a new one starts
+      const pending = Array.from(ws.transfers.values()).reduce((sum, t) => sum + t.size, 0);
+      if (pending + size > this.config.server.maxTransferSize) {
+        ws.transfers.clear();
+      }
+      transfer = { count, size, hash, parts: [], received: 0 };
+      ws.transfers.set(transferId, transfer);
+    }
+    const fail = (reason) => {
+      ws.transfers.delete(transferId);
+      this.sendError(ws, `Error: Chunked message ${transferId} dropped: ${reason}`);
+      return null;
+    };
+    if (!transfer) {
+      return fail('first chunk missing');
+    }
+    if (index !== transfer.parts.length || count !== transfer.count || size !== transfer.size || hash !== transfer.hash) {
+      return fail(`chunk ${index + 1} of ${count} does not fit the transfer`);
+    }
+    const part = Buffer.from(data, 'base64');
+    transfer.received += part.length;
+    if (transfer.received > transfer.size) {
+      return fail('more data than announced');
+    }
+    transfer.parts.push(part);
+    if (transfer.parts.length < transfer.count) {
+      return null;
+    }
+    ws.transfers.delete(transferId);
+    const payload = Buffer.concat(transfer.parts);
+    if (payload.length !== transfer.size || crypto.createHash('sha256').update(payload).digest('hex') !== transfer.hash) {
+      return fail('integrity check failed');
+    }
+    if (this.config.debug) {
+      this._log(`Reassembled chunked message ${transferId} of ${count} chunks, ${size} bytes`);
+    }
+    return payload.toString('utf8');
+  }
+
+  /**
+   * Send a message in chunks that each fit the largest message of the client
+   */
+  sendChunked(ws, payload, maxMessageSize) {
+    const transferId = uuidv4();
+    const hash = crypto.createHash('sha256').update(payload).digest('hex');
+    // Base64 grows data by 4/3, and the chunk fields need some room
+    const chunkSize = Math.max(1024, Math.floor((maxMessageSize - 1024) * 3 / 4));
+    const count = Math.ceil(payload.length / chunkSize);
+    for (let index = 0; index < count; index++) {
+      ws.send(JSON.stringify({
+        type: 'chunk',
+        transferId,
+        index,
+        count,
+        size: payload.length,
+        hash,
+        data: payload.subarray(index * chunkSize, (index + 1) * chunkSize).toString('base64')
+      }));
+    }
+    if (this.config.debug) {
+      this._log(`Sent chunked message ${transferId} of ${count} chunks, ${payload.length} bytes, connectionId: ${ws.connectionId}`);
+    }
+  }
+
   /**
    * Handle browser client connection
    */
@@ -674,8 +1016,11 @@ class WebIdeBridgeServer {
    * Handle desktop client connection
    */
   handleDesktopConnect(ws, message) {
-    const { userId, connectionId } = message;
+    const { userId, connectionId, maxMessageSize } = message;
     ws.connectionId = connectionId;
+    if (Number.isInteger(maxMessageSize) && maxMessageSize >= 16 * 1024) {
+      ws.maxMessageSize = maxMessageSize;
+    }
 
     if (!userId) {
       this.sendError(ws, 'Error: Desktop application connection requires user identification. Please restart the desktop app and try again.');
@@ -702,7 +1047,9 @@ class WebIdeBridgeServer {
       type: 'connection_ack',
       connectionId: ws.connectionId,
       status: 'connected',
-      role: 'desktop'
+      role: 'desktop',
+      features: ['delta', 'chunk'],
+      maxMessageSize: this.config.server.maxMessageSize
     });
 
     this.sendBrowserStatusToDesktop(userId);
@@ -715,7 +1062,7 @@ class WebIdeBridgeServer {
    * Handle edit request from browser
    */
   handleEditRequest(ws, message) {
-    const { userId, snippetId, code: rawCode, fileType } = message;
+    const { userId, snippetId, pageUrl, code: rawCode, fileType, contextFiles, line, column, endLine, endColumn, transform, contextPrefix, contextSuffix, encoding, mimeType } = message;
     const code = this.normalizeLineEndings(rawCode);
 
     if (!userId || !snippetId || !code) {
@@ -736,13 +1083,16 @@ class WebIdeBridgeServer {
       return;
     }
 
-    // Store session mapping using snippetId as the key
-    const sessionKey = userId + ':' + snippetId;
+    // Store session mapping using page URL and snippetId as the key
+    const sessionKey = this.getSessionKey(userId, pageUrl, snippetId);
     this.activeSessions.set(sessionKey, {
       userId,
       snippetId: snippetId,
+      pageUrl: pageUrl || '',
       browserConnectionId: ws.connectionId, // always update to latest browser connection
       desktopConnectionId: userSession.desktopId, // always update to latest desktop connection
+      lastCode: code, // base version of patches from the desktop
+      lastHash: this.hashCode(code),
       createdAt: Date.now(),
       lastActivity: Date.now()
     });
@@ -753,8 +1103,19 @@ class WebIdeBridgeServer {
       type: 'edit_request',
       userId,
       snippetId,
+      pageUrl,
       code,
-      fileType
+      fileType,
+      contextFiles,
+      line,
+      column,
+      endLine,
+      endColumn,
+      transform,
+      contextPrefix,
+      contextSuffix,
+      encoding,
+      mimeType
     });
 
     if (this.config.debug) {
@@ -764,12 +1125,117 @@ class WebIdeBridgeServer {
     }
   }
 
+  /**
+   * Handle batch edit request from browser: several snippets of a page opened as one project
+   */
+  handleEditBatchRequest(ws, message) {
+    const { userId, pageUrl, snippets: rawSnippets, contextFiles, transform } = message;
+
+    // Find user's desktop connection
+    const userSession = this.userSessions.get(userId);
+    if (!userSession || !userSession.desktopId) {
+      this.sendError(ws, 'Error: No desktop application connected. Please start the Web-IDE-Bridge desktop app and try again.');
+      return;
+    }
+
+    const desktopConn = this.desktopConnections.get(userSession.desktopId);
+    if (!desktopConn) {
+      this.sendError(ws, 'Error: Desktop application connection lost. Please restart the Web-IDE-Bridge desktop app and try again.');
+      return;
+    }
+
+    // Store a session per snippet, so that code updates are routed back to this browser
+    const snippets = rawSnippets.map(snippet => ({
+      snippetId: snippet.snippetId,
+      code: this.normalizeLineEndings(snippet.code),
+      fileType: snippet.fileType,
+      transform: snippet.transform
+    }));
+    for (const snippet of snippets) {
+      this.activeSessions.set(this.getSessionKey(userId, pageUrl, snippet.snippetId), {
+        userId,
+        snippetId: snippet.snippetId,
+        pageUrl: pageUrl || '',
+        browserConnectionId: ws.connectionId,
+        desktopConnectionId: userSession.desktopId,
+        lastCode: snippet.code,
+        lastHash: this.hashCode(snippet.code),
+        createdAt: Date.now(),
+        lastActivity: Date.now()
+      });
+      this.metrics.totalSessions++;
+    }
+
+    // Forward to desktop
+    this.sendMessage(desktopConn.ws, {
+      type: 'edit_batch_request',
+      userId,
+      pageUrl,
+      snippets,
+      contextFiles,
+      transform
+    });
+
+    const snippetIds = snippets.map(snippet => snippet.snippetId).join(', ');
+    this._log(`Browser batch edit request for userId: ${userId}, snippets: ${snippetIds}`, 'info');
+  }
+
+  /**
+   * Handle code update from browser, for a snippet that is open in the desktop IDE
+   */
+  handleBrowserUpdate(ws, message) {
+    const { userId, snippetId, pageUrl, code: rawCode, fileType } = message;
+    const code = this.normalizeLineEndings(rawCode);
+
+    const session = this.activeSessions.get(this.getSessionKey(userId, pageUrl, snippetId));
+    if (!session) {
+      this.sendError(ws, 'Error: No active edit session for this code snippet. Please click "Edit in IDE" first.');
+      return;
+    }
+
+    const userSession = this.userSessions.get(userId);
+    const desktopConn = this.desktopConnections.get(session.desktopConnectionId) ||
+      (userSession && userSession.desktopId && this.desktopConnections.get(userSession.desktopId));
+    if (!desktopConn) {
+      this.sendError(ws, 'Error: Desktop application connection lost. Please restart the Web-IDE-Bridge desktop app and try again.');
+      return;
+    }
+
+    session.browserConnectionId = ws.connectionId;
+    session.lastActivity = Date.now();
+    session.lastCode = code;
+    session.lastHash = this.hashCode(code);
+
+    this.sendMessage(desktopConn.ws, {
+      type: 'browser_update',
+      userId,
+      snippetId,
+      pageUrl,
+      code,
+      fileType
+    });
+
+    this._log(`Browser update for userId: ${userId}, snippetId: ${snippetId}, codeLength: ${code.length}`, 'info');
+  }
+
   /**
    * Handle code update from desktop
    */
   handleCodeUpdate(ws, message) {
-    const { userId, snippetId, code: rawCode, fileType } = message;
-    const code = this.normalizeLineEndings(rawCode);
+    const { userId, snippetId, pageUrl, code: rawCode, patch, baseHash, hash, fileType, diagnostics, encoding, mimeType } = message;
+    let code;
+    if (patch !== undefined) {
+      // Patches apply to the last code of the session, else the desktop sends the full code
+      const base = this.activeSessions.get(this.getSessionKey(userId, pageUrl, snippetId));
+      code = base && base.lastHash === baseHash ? this.applyLinePatch(base.lastCode, patch) : null;
+      if (code === null || this.hashCode(code) !== hash) {
+        this._log(`Desktop code update patch does not apply for userId: ${userId}, snippetId: ${snippetId}, requesting the full code`, 'info');
+        this.sendMessage(ws, { type: 'code_resync', userId, snippetId, pageUrl, hash });
+        return;
+      }
+    } else {
+      code = this.normalizeLineEndings(rawCode);
+    }
 
     if (!userId || !snippetId || !code) {
       this.sendError(ws, 'Error: Code update is missing required information. Please try again.');
@@ -782,7 +1248,7 @@ class WebIdeBridgeServer {
       this._log(`Desktop code update for userId: ${userId}, snippetId: ${snippetId}, fileType: ${fileType}, codeLength: ${code.length}`, 'info');
     }
 
-    const sessionKey = userId + ':' + snippetId;
+    const sessionKey = this.getSessionKey(userId, pageUrl, snippetId);
     if (this.config.debug) {
       this._log(`Looking for session with key: ${sessionKey}`);
       this._log(`Available sessions: ${Array.from(this.activeSessions.keys())}`);
@@ -810,8 +1276,11 @@ class WebIdeBridgeServer {
       return;
     }
 
-    // Update session activity
+    // Update session activity, and acknowledge the code as base of the next patch
     session.lastActivity = Date.now();
+    session.lastCode = code;
+    session.lastHash = this.hashCode(code);
+    this.sendMessage(ws, { type: 'code_ack', userId, snippetId, pageUrl, hash: session.lastHash });
 
     // Get all browser connections for this user
     const userSession = this.userSessions.get(userId);
@@ -856,7 +1325,10 @@ class WebIdeBridgeServer {
         this.sendMessage(browserConn.ws, {
           type: 'code_update',
           snippetId: session.snippetId,
-          code: code
+          code: code,
+          diagnostics,
+          encoding,
+          mimeType
         });
         delivered = true;
         if (this.config.debug) {
@@ -899,6 +1371,35 @@ class WebIdeBridgeServer {
 
   }
 
+  /**
+   * Handle edit session event from desktop, such as edit_started, launch_failed or editor_closed
+   */
+  handleEditEvent(ws, message) {
+    const { userId, snippetId, pageUrl, event, reason, diagnostics } = message;
+    const session = this.activeSessions.get(this.getSessionKey(userId, pageUrl, snippetId));
+    if (!session) {
+      if (this.config.debug) {
+        this._log(`Edit event ${event} for unknown session, userId: ${userId}, snippetId: ${snippetId}`);
+      }
+      return;
+    }
+    session.lastActivity = Date.now();
+
+    // Forward to the browser connection that initiated this edit session
+    const browserConn = this.browserConnections.get(session.browserConnectionId);
+    if (browserConn) {
+      this.sendMessage(browserConn.ws, {
+        type: 'edit_event',
+        snippetId: session.snippetId,
+        event,
+        reason: reason || '',
+        diagnostics
+      });
+    }
+
+    this._log(`Desktop edit event ${event} for userId: ${userId}, snippetId: ${snippetId}${reason ? ', reason: ' + reason : ''}`, ['launch_failed', 'file_kept', 'merge_conflict', 'update_rejected', 'send_failed', 'format_failed', 'validation_failed', 'transform_failed', 'context_rejected'].includes(event) ? 'warning' : 'info');
+  }
+
   /**
    * Handle ping message
    */
@@ -1050,7 +1551,13 @@ class WebIdeBridgeServer {
   sendMessage(ws, message) {
     if (ws.readyState === WebSocket.OPEN) {
       try {
-        ws.send(JSON.stringify(message));
+        const data = JSON.stringify(message);
+        // Desktop apps tell the largest message they accept, larger messages are chunked
+        if (ws.maxMessageSize && data.length > ws.maxMessageSize / 4 && Buffer.byteLength(data) > ws.maxMessageSize) {
+          this.sendChunked(ws, Buffer.from(data, 'utf8'), ws.maxMessageSize);
+          return;
+        }
+        ws.send(data);
       } catch (error) {
         console.error('Error sending message:', error);
       }
@@ -1943,6 +2450,7 @@ class WebIdeBridgeServer {
             sessionId: id,
             userId: session.userId,
             snippetId: session.snippetId,
+            pageUrl: session.pageUrl,
             browserConnectionId: session.browserConnectionId,
             desktopConnectionId: session.desktopConnectionId,
             createdAt: new Date(session.createdAt).toISOString(),
@@ -0,0 +1,221 @@
+/**
+ * @name            Web-IDE-Bridge / Tests / Server
+ * @tagline         Delta sync tests
+ * @description     Tests for line patches of code updates from the desktop app
+ * @file            tests/server/delta.test.js
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+const fs = require('fs');
+const path = require('path');
+const WebIdeBridgeServer = require('../../server/web-ide-bridge-server');
+
+// Patches made by LineDiff of the desktop app, checked against it by the desktop tests
+const fixtures = JSON.parse(fs.readFileSync(path.join(__dirname, '..', '..', 'desktop', 'bridge', 'testdata', 'delta', 'patches.json'), 'utf8'));
+
+// Test configuration
+function createConfig() {
+  return {
+    server: {
+      port: 0,
+      host: 'localhost',
+      websocketEndpoint: '/web-ide-bridge/ws',
+      heartbeatInterval: 1000,
+      maxConnections: 100,
+      connectionTimeout: 5000
+    },
+    endpoints: {
+      health: '/web-ide-bridge/health',
+      status: '/web-ide-bridge/status',
+      debug: '/web-ide-bridge/debug',
+      websocket: '/web-ide-bridge/ws'
+    },
+    cors: { origin: ['http://localhost:3000'], credentials: true },
+    session: {
+      secret: 'test-secret',
+      name: 'test-session',
+      cookie: { maxAge: 60000, secure: false, httpOnly: true, sameSite: 'lax' },
+      resave: false,
+      saveUninitialized: false,
+      rolling: true
+    },
+    security: { rateLimiting: { enabled: false }, helmet: { enabled: false } },
+    logging: { level: 'error', enableAccessLog: false },
+    cleanup: { sessionCleanupInterval: 1000, maxSessionAge: 5000, enablePeriodicCleanup: false },
+    debug: false,
+    environment: 'test'
+  };
+}
+
+// WebSocket stand-in that records the messages sent to it
+function createMockSocket(connectionId) {
+  return {
+    readyState: 1, // WebSocket.OPEN
+    connectionId,
+    sent: [],
+    send(data) {
+      this.sent.push(JSON.parse(data));
+    }
+  };
+}
+
+describe('Delta Sync', () => {
+  let server;
+
+  beforeEach(() => {
+    server = new WebIdeBridgeServer(createConfig());
+  });
+
+  describe('Line Patches', () => {
+    test('should apply the patches of the desktop app', () => {
+      expect(fixtures.length).toBeGreaterThan(0);
+      fixtures.forEach(({ base, code, patch }) => {
+        expect(server.applyLinePatch(base, patch)).toBe(code);
+      });
+    });
+
+    test('should keep a missing trailing newline of copied lines', () => {
+      expect(server.applyLinePatch('a\nb', [2])).toBe('a\nb');
+      expect(server.applyLinePatch('a\nb', [1, -1, 'c'])).toBe('a\nc');
+    });
+
+    test('should reject patches that do not fit the base', () => {
+      fixtures.forEach(({ base, patch }) => {
+        // One line more than the base has
+        expect(server.applyLinePatch(base, [...patch, 1])).toBeNull();
+        expect(server.applyLinePatch(base, [...patch, -1])).toBeNull();
+      });
+      // Lines of the base that are neither copied nor skipped
+      expect(server.applyLinePatch('a\nb\nc\n', [2])).toBeNull();
+      expect(server.applyLinePatch('a\nb\nc\n', ['x\n'])).toBeNull();
+    });
+
+    test('should require hashes of patches', () => {
+      const message = { type: 'code_update', connectionId: 'desktop-1', userId: 'test-user', snippetId: 'snippet-1', patch: [1] };
+      expect(server.validateMessage(message).error).toBe('patch requires baseHash and hash');
+
+      message.baseHash = server.hashCode('a\n');
+      message.hash = server.hashCode('a\n');
+      expect(server.validateMessage(message).valid).toBe(true);
+
+      message.patch = [1, { lines: 2 }];
+      expect(server.validateMessage(message).error).toBe('patch must be an array of line counts and strings');
+    });
+  });
+
+  describe('Code Updates', () => {
+    const userId = 'test-user';
+    const snippetId = 'snippet-1';
+    const pageUrl = 'http://localhost:3000/page';
+    const base = 'one\ntwo\nthree\n';
+    let desktop;
+    let browser;
+
+    beforeEach(() => {
+      desktop = createMockSocket('desktop-1');
+      browser = createMockSocket('browser-1');
+      server.desktopConnections.set(desktop.connectionId, { ws: desktop, userId });
+      server.browserConnections.set(browser.connectionId, { ws: browser, userId });
+      server.userSessions.set(userId, { desktopId: desktop.connectionId, browserIds: new Set([browser.connectionId]) });
+      server.activeSessions.set(server.getSessionKey(userId, pageUrl, snippetId), {
+        userId,
+        snippetId,
+        pageUrl,
+        browserConnectionId: browser.connectionId,
+        desktopConnectionId: desktop.connectionId,
+        lastCode: base,
+        lastHash: server.hashCode(base),
+        createdAt: Date.now(),
+        lastActivity: Date.now()
+      });
+    });
+
+    function session() {
+      return server.activeSessions.get(server.getSessionKey(userId, pageUrl, snippetId));
+    }
+
+    test('should forward the full code of a patch and acknowledge it', () => {
+      const code = 'one\n2\nthree\nfour\n';
+      server.handleCodeUpdate(desktop, {
+        type: 'code_update', userId, snippetId, pageUrl,
+        patch: [1, -1, '2\n', 1, 'four\n'],
+        baseHash: server.hashCode(base),
+        hash: server.hashCode(code)
+      });
+
+      expect(desktop.sent).toEqual([{ type: 'code_ack', userId, snippetId, pageUrl, hash: server.hashCode(code) }]);
+      expect(browser.sent.length).toBe(1);
+      expect(browser.sent[0].type).toBe('code_update');
+      expect(browser.sent[0].code).toBe(code);
+      expect(session().lastCode).toBe(code);
+      expect(session().lastHash).toBe(server.hashCode(code));
+    });
+
+    test('should apply the next patch to the acknowledged code', () => {
+      const first = 'one\ntwo\nthree\nfour\n';
+      const second = 'one\nthree\nfour\n';
+      server.handleCodeUpdate(desktop, { type: 'code_update', userId, snippetId, pageUrl, patch: [3, 'four\n'], baseHash: server.hashCode(base), hash: server.hashCode(first) });
+      server.handleCodeUpdate(desktop, { type: 'code_update', userId, snippetId, pageUrl, patch: [1, -1, 2], baseHash: desktop.sent[0].hash, hash: server.hashCode(second) });
+
+      expect(desktop.sent.map(m => m.type)).toEqual(['code_ack', 'code_ack']);
+      expect(browser.sent.map(m => m.code)).toEqual([first, second]);
+    });
+
+    test('should request the full code if the base hash does not match', () => {
+      const code = 'one\n2\nthree\n';
+      server.handleCodeUpdate(desktop, {
+        type: 'code_update', userId, snippetId, pageUrl,
+        patch: [1, -1, '2\n', 1],
+        baseHash: server.hashCode('other\n'),
+        hash: server.hashCode(code)
+      });
+
+      expect(desktop.sent).toEqual([{ type: 'code_resync', userId, snippetId, pageUrl, hash: server.hashCode(code) }]);
+      expect(browser.sent).toEqual([]);
+      expect(session().lastCode).toBe(base);
+      expect(session().lastHash).toBe(server.hashCode(base));
+    });
+
+    test('should request the full code if the patched code has another hash', () => {
+      server.handleCodeUpdate(desktop, {
+        type: 'code_update', userId, snippetId, pageUrl,
+        patch: [1, -1, '2\n', 1],
+        baseHash: server.hashCode(base),
+        hash: server.hashCode('one\nTWO\nthree\n')
+      });
+
+      expect(desktop.sent.map(m => m.type)).toEqual(['code_resync']);
+      expect(browser.sent).toEqual([]);
+      expect(session().lastCode).toBe(base);
+    });
+
+    test('should request the full code of a patch without a session', () => {
+      server.activeSessions.clear();
+      server.handleCodeUpdate(desktop, {
+        type: 'code_update', userId, snippetId, pageUrl,
+        patch: [3],
+        baseHash: server.hashCode(base),
+        hash: server.hashCode(base)
+      });
+
+      expect(desktop.sent.map(m => m.type)).toEqual(['code_resync']);
+      expect(browser.sent).toEqual([]);
+    });
+
+    test('should accept the full code after a resync', () => {
+      const code = 'one\n2\nthree\n';
+      server.handleCodeUpdate(desktop, { type: 'code_update', userId, snippetId, pageUrl, patch: [1, -1, '2\n', 1], baseHash: server.hashCode('other\n'), hash: server.hashCode(code) });
+      server.handleCodeUpdate(desktop, { type: 'code_update', userId, snippetId, pageUrl, code });
+
+      expect(desktop.sent.map(m => m.type)).toEqual(['code_resync', 'code_ack']);
+      expect(desktop.sent[1].hash).toBe(server.hashCode(code));
+      expect(browser.sent.map(m => m.code)).toEqual([code]);
+    });
+  });
+});
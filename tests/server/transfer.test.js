@@ -0,0 +1,275 @@
+/**
+ * @name            Web-IDE-Bridge / Tests / Server
+ * @tagline         Chunked transfer tests
+ * @description     Tests for chunked messages and transfer limits of the Web-IDE-Bridge server
+ * @file            tests/server/transfer.test.js
+ * @version         1.1.6
+ * @release         2025-08-23
+ * @repository      https://github.com/peterthoeny/web-ide-bridge
+ * @author          Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @copyright       2025 Peter Thoeny, https://twiki.org & https://github.com/peterthoeny/
+ * @license         GPL v3, see LICENSE file
+ * @genai           99%, Cursor 1.2, Claude Sonnet 4
+ */
+
+const crypto = require('crypto');
+const WebIdeBridgeServer = require('../../server/web-ide-bridge-server');
+
+// Test configuration without transfer settings, as passed by older callers
+function createConfig(serverOverrides = {}) {
+  return {
+    server: {
+      port: 0,
+      host: 'localhost',
+      websocketEndpoint: '/web-ide-bridge/ws',
+      heartbeatInterval: 1000,
+      maxConnections: 100,
+      connectionTimeout: 5000,
+      ...serverOverrides
+    },
+    endpoints: {
+      health: '/web-ide-bridge/health',
+      status: '/web-ide-bridge/status',
+      debug: '/web-ide-bridge/debug',
+      websocket: '/web-ide-bridge/ws'
+    },
+    cors: { origin: ['http://localhost:3000'], credentials: true },
+    session: {
+      secret: 'test-secret',
+      name: 'test-session',
+      cookie: { maxAge: 60000, secure: false, httpOnly: true, sameSite: 'lax' },
+      resave: false,
+      saveUninitialized: false,
+      rolling: true
+    },
+    security: { rateLimiting: { enabled: false }, helmet: { enabled: false } },
+    logging: { level: 'error', enableAccessLog: false },
+    cleanup: { sessionCleanupInterval: 1000, maxSessionAge: 5000, enablePeriodicCleanup: false },
+    debug: false,
+    environment: 'test'
+  };
+}
+
+// WebSocket stand-in that records the messages sent to it
+function createMockSocket(connectionId = 'desktop-1') {
+  return {
+    readyState: 1, // WebSocket.OPEN
+    connectionId,
+    sent: [],
+    send(data) {
+      this.sent.push(JSON.parse(data));
+    }
+  };
+}
+
+// Split a payload into chunk messages the way the desktop app does
+function splitPayload(payload, chunkSize, transferId = 'transfer-1') {
+  const data = Buffer.from(payload, 'utf8');
+  const hash = crypto.createHash('sha256').update(data).digest('hex');
+  const count = Math.ceil(data.length / chunkSize);
+  const chunks = [];
+  for (let index = 0; index < count; index++) {
+    chunks.push({
+      type: 'chunk',
+      connectionId: 'desktop-1',
+      transferId,
+      index,
+      count,
+      size: data.length,
+      hash,
+      data: data.subarray(index * chunkSize, (index + 1) * chunkSize).toString('base64')
+    });
+  }
+  return chunks;
+}
+
+describe('Chunked Transfers', () => {
+  let server;
+
+  beforeEach(() => {
+    server = new WebIdeBridgeServer(createConfig());
+  });
+
+  describe('Transfer Limits', () => {
+    test('should default transfer settings of a config passed to the constructor', () => {
+      expect(server.config.server.maxMessageSize).toBe(10 * 1024 * 1024);
+      expect(server.config.server.maxTransferSize).toBe(64 * 1024 * 1024);
+      expect(server.config.server.perMessageDeflate).toBe(true);
+
+      server.setupWebSocket();
+      expect(server.wsOptions.maxPayload).toBe(10 * 1024 * 1024);
+    });
+
+    test('should keep configured transfer settings', () => {
+      const custom = new WebIdeBridgeServer(createConfig({ maxMessageSize: 64 * 1024, maxTransferSize: 256 * 1024, perMessageDeflate: false }));
+      expect(custom.config.server.maxMessageSize).toBe(64 * 1024);
+      expect(custom.config.server.maxTransferSize).toBe(256 * 1024);
+      expect(custom.config.server.perMessageDeflate).toBe(false);
+    });
+
+    test('should reject a transfer size below the message size', () => {
+      expect(() => {
+        new WebIdeBridgeServer(createConfig({ maxMessageSize: 1024 * 1024, maxTransferSize: 512 * 1024 }));
+      }).toThrow('Max transfer size must be at least the max message size');
+    });
+
+    test('should limit code of edit requests to the transfer size', () => {
+      const small = new WebIdeBridgeServer(createConfig({ maxMessageSize: 16 * 1024, maxTransferSize: 32 * 1024 }));
+      const message = { type: 'edit_request', connectionId: 'browser-1', userId: 'test-user', snippetId: 'snippet-1', code: 'x'.repeat(32 * 1024) };
+      expect(small.validateMessage(message).valid).toBe(true);
+
+      message.code += 'x';
+      const result = small.validateMessage(message);
+      expect(result.valid).toBe(false);
+      expect(result.error).toBe('Code payload too large (max 32KB)');
+    });
+
+    test('should reject chunks of messages larger than the transfer size', () => {
+      const [chunk] = splitPayload('{"type":"ping"}', 1024);
+      expect(server.validateMessage(chunk).valid).toBe(true);
+
+      chunk.size = server.config.server.maxTransferSize + 1;
+      const result = server.validateMessage(chunk);
+      expect(result.valid).toBe(false);
+      expect(result.error).toContain('chunked message too large');
+    });
+  });
+
+  describe('Chunk Reassembly', () => {
+    test('should reassemble a message once all chunks arrived', () => {
+      const ws = createMockSocket();
+      const payload = JSON.stringify({ type: 'code_update', userId: 'test-user', snippetId: 'snippet-1', code: 'line\n'.repeat(2000) });
+      const chunks = splitPayload(payload, 4096);
+      expect(chunks.length).toBeGreaterThan(2);
+
+      chunks.slice(0, -1).forEach(chunk => {
+        expect(server.receiveChunk(ws, chunk)).toBeNull();
+      });
+      expect(server.receiveChunk(ws, chunks[chunks.length - 1])).toBe(payload);
+      expect(ws.transfers.size).toBe(0);
+      expect(ws.sent).toEqual([]);
+    });
+
+    test('should reassemble multi-byte characters split across chunks', () => {
+      const ws = createMockSocket();
+      const payload = JSON.stringify({ code: 'äöü €'.repeat(500) });
+      const chunks = splitPayload(payload, 1001);
+
+      let result = null;
+      chunks.forEach(chunk => {
+        result = server.receiveChunk(ws, chunk);
+      });
+      expect(result).toBe(payload);
+    });
+
+    test('should send large messages in chunks that fit the client', () => {
+      const ws = createMockSocket();
+      ws.maxMessageSize = 16 * 1024;
+      const message = { type: 'edit_request', snippetId: 'snippet-1', code: 'x'.repeat(100 * 1024) };
+      server.sendMessage(ws, message);
+
+      expect(ws.sent.length).toBeGreaterThan(1);
+      ws.sent.forEach(chunk => {
+        expect(chunk.type).toBe('chunk');
+        expect(Buffer.byteLength(JSON.stringify(chunk))).toBeLessThanOrEqual(ws.maxMessageSize);
+      });
+
+      const receiver = createMockSocket('desktop-2');
+      let result = null;
+      ws.sent.forEach(chunk => {
+        result = server.receiveChunk(receiver, chunk);
+      });
+      expect(JSON.parse(result)).toEqual(message);
+    });
+
+    test('should send small messages in one piece', () => {
+      const ws = createMockSocket();
+      ws.maxMessageSize = 16 * 1024;
+      server.sendMessage(ws, { type: 'ping' });
+      expect(ws.sent).toEqual([{ type: 'ping' }]);
+    });
+
+    test('should drop a transfer whose first chunk is missing', () => {
+      const ws = createMockSocket();
+      const chunks = splitPayload('x'.repeat(3000), 1024);
+
+      expect(server.receiveChunk(ws, chunks[1])).toBeNull();
+      expect(ws.sent[0].type).toBe('error');
+      expect(ws.sent[0].message).toContain('first chunk missing');
+    });
+
+    test('should drop a transfer with chunks out of order', () => {
+      const ws = createMockSocket();
+      const chunks = splitPayload('x'.repeat(3000), 1024);
+
+      expect(server.receiveChunk(ws, chunks[0])).toBeNull();
+      expect(server.receiveChunk(ws, chunks[2])).toBeNull();
+      expect(ws.sent[0].message).toContain('chunk 3 of 3 does not fit the transfer');
+      expect(ws.transfers.has('transfer-1')).toBe(false);
+
+      // The rest of the dropped transfer is not accepted either
+      expect(server.receiveChunk(ws, chunks[1])).toBeNull();
+      expect(ws.sent[1].message).toContain('first chunk missing');
+    });
+
+    test('should drop a transfer with a hash mismatch', () => {
+      const ws = createMockSocket();
+      const chunks = splitPayload('x'.repeat(3000), 1024);
+      chunks.forEach(chunk => {
+        chunk.hash = crypto.createHash('sha256').update('other').digest('hex');
+      });
+
+      let result = null;
+      chunks.forEach(chunk => {
+        result = server.receiveChunk(ws, chunk);
+      });
+      expect(result).toBeNull();
+      expect(ws.sent[0].message).toContain('integrity check failed');
+    });
+
+    test('should drop a transfer with more data than announced', () => {
+      const ws = createMockSocket();
+      const chunks = splitPayload('x'.repeat(3000), 1024);
+      chunks.forEach(chunk => {
+        chunk.size = 2000;
+      });
+
+      let result = null;
+      chunks.forEach(chunk => {
+        result = result || server.receiveChunk(ws, chunk);
+      });
+      expect(result).toBeNull();
+      expect(ws.sent[0].message).toContain('more data than announced');
+    });
+
+    test('should drop a transfer with less data than announced', () => {
+      const ws = createMockSocket();
+      const chunks = splitPayload('x'.repeat(3000), 1024);
+      chunks.forEach(chunk => {
+        chunk.size = 4000;
+      });
+
+      let result = null;
+      chunks.forEach(chunk => {
+        result = server.receiveChunk(ws, chunk);
+      });
+      expect(result).toBeNull();
+      expect(ws.sent[0].message).toContain('integrity check failed');
+    });
+
+    test('should drop incomplete transfers that exceed the transfer size', () => {
+      const small = new WebIdeBridgeServer(createConfig({ maxMessageSize: 16 * 1024, maxTransferSize: 32 * 1024 }));
+      const ws = createMockSocket();
+      const first = splitPayload('x'.repeat(20 * 1024), 8 * 1024, 'transfer-1');
+      const second = splitPayload('y'.repeat(20 * 1024), 8 * 1024, 'transfer-2');
+
+      expect(small.receiveChunk(ws, first[0])).toBeNull();
+      expect(ws.transfers.has('transfer-1')).toBe(true);
+
+      // Both transfers together are larger than the limit, the incomplete one is dropped
+      expect(small.receiveChunk(ws, second[0])).toBeNull();
+      expect(ws.transfers.has('transfer-1')).toBe(false);
+      expect(ws.transfers.has('transfer-2')).toBe(true);
+    });
+  });
+});